@@ -7,7 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
@@ -16,14 +22,38 @@ type App struct {
 	configManager    *ConfigManager
 	fileManager      *FileManager
 	sandboxieManager *SandboxieManager
+	historyManager   *HistoryManager
+	processTracker   *ProcessTracker
+	deleteTokens     *deleteTokens
+	fileIndex        *FileIndex
+	searcher         *Searcher
+	iconCache        *IconCache
+	iconPrefetcher   *IconPrefetcher
+	iconSize         atomic.Int32 // Icon size the frontend last asked for, used for prefetching
+	configWatcher    *ConfigWatcher
+	ipcServer        *IPCServer // Answers instances started later, nil if the channel couldn't be opened
+
+	fileTypesMu      sync.Mutex
+	appliedFileTypes FileTypeSettings // Settings the file manager currently uses
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
+	configManager := NewConfigManager()
+	sandboxieManager := NewSandboxieManager(configManager.GetConfig().SandboxiePath)
+	fileTypes := configManager.GetFileTypeSettings()
+	fileManager := NewFileManager()
+	fileManager.SetFileTypes(fileTypes)
 	return &App{
-		configManager:    NewConfigManager(),
-		fileManager:      NewFileManager(),
-		sandboxieManager: NewSandboxieManager(),
+		configManager:    configManager,
+		fileManager:      fileManager,
+		appliedFileTypes: fileTypes,
+		sandboxieManager: sandboxieManager,
+		historyManager:   NewHistoryManager(),
+		processTracker:   NewProcessTracker(&sandboxieProcessSource{sandboxieManager: sandboxieManager}),
+		deleteTokens:     newDeleteTokens(),
+		searcher:         NewSearcher(fileManager, nil),
+		iconCache:        NewIconCache(filepath.Join(getConfigDir(), "iconcache"), iconMemoryLimit, iconDiskLimit),
 	}
 }
 
@@ -31,22 +61,132 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	// Index all folders in the background and tell the frontend when
+	// their content changes on disk
+	watcher, err := newChangeWatcher()
+	if err != nil {
+		watcher = nil
+	}
+	a.fileIndex = NewFileIndex(a.fileManager, watcher, func(roots []string) {
+		runtime.EventsEmit(a.ctx, "files:changed", roots)
+	})
+	a.fileIndex.SetRoots(a.indexRoots())
+	a.searcher = NewSearcher(a.fileManager, a.fileIndex)
+
+	// Pick up edits made to config.json in an external editor. Only the
+	// config folder itself is watched; the icon cache and backups live in
+	// its subfolders.
+	configChanges, err := newFolderWatcher()
+	if err != nil {
+		configChanges = nil
+	}
+	a.configWatcher = NewConfigWatcher(a.configManager, configChanges, func() {
+		a.applyFileTypes()
+		a.refreshIndexRoots()
+		a.sandboxieManager.Detect(a.configManager.GetConfig().SandboxiePath)
+		runtime.EventsEmit(a.ctx, "config:changed")
+	}, func(err error) {
+		runtime.EventsEmit(a.ctx, "config:invalid", err.Error())
+	})
+
+	// Icons of the current folder are extracted ahead of the frontend
+	// asking and streamed to it in batches
+	a.iconPrefetcher = NewIconPrefetcher(a.fileIcon, func(results []IconResult) {
+		runtime.EventsEmit(a.ctx, "icons:loaded", results)
+	})
+
+	a.processTracker.Start(func(p RunningProgram) {
+		runtime.EventsEmit(a.ctx, "process:started", p)
+	}, func(p RunningProgram) {
+		runtime.EventsEmit(a.ctx, "process:exited", p)
+	})
+
+	// Command lines given to later instances are run here, so launches
+	// are tracked and searches use the index
+	if server, err := NewIPCServer(ipcName, a.handleIPCRequest); err == nil {
+		a.ipcServer = server
+	}
+}
+
+// handleIPCRequest answers a request forwarded by a second instance
+func (a *App) handleIPCRequest(request IPCRequest) IPCResponse {
+	if request.Show || len(request.Args) == 0 {
+		runtime.WindowUnminimise(a.ctx)
+		runtime.WindowShow(a.ctx)
+		return IPCResponse{}
+	}
+	return handleCLIRequest(a, request.Args)
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	if a.ipcServer != nil {
+		a.ipcServer.Close()
+	}
+	a.processTracker.Stop()
+	if a.iconPrefetcher != nil {
+		a.iconPrefetcher.Stop()
+	}
+	if a.configWatcher != nil {
+		a.configWatcher.Close()
+	}
+	if a.fileIndex != nil {
+		a.fileIndex.Close()
+	}
+}
+
+// listDirectory returns a folder listing from the index, falling back to
+// reading the folder when it isn't indexed (yet)
+func (a *App) listDirectory(dirPath string) ([]FileInfo, error) {
+	if a.fileIndex != nil {
+		if files, ok := a.fileIndex.Directory(dirPath); ok {
+			return files, nil
+		}
+	}
+	return a.fileManager.GetDirectoryContents(dirPath)
+}
+
+// indexRoots returns the configured folders plus the real folders behind
+// the merged Start Menu, Desktop and Taskbar
+func (a *App) indexRoots() []string {
+	return append(a.configManager.GetConfig().FolderPaths, mergedRoots()...)
+}
+
+// refreshIndexRoots updates the indexed folders after the folder list changed
+func (a *App) refreshIndexRoots() {
+	if a.fileIndex != nil {
+		a.fileIndex.SetRoots(a.indexRoots())
+	}
 }
 
 // GetAppState returns the current application state
 func (a *App) GetAppState() *AppState {
 	config := a.configManager.GetConfig()
 	var files []FileInfo
-
 	if config.CurrentFolder != "" {
 		var err error
-		files, err = a.fileManager.GetDirectoryContents(config.CurrentFolder)
+		files, err = a.folderContents(config.CurrentFolder)
 		if err != nil {
 			files = []FileInfo{}
 		}
 	}
 
-	sandboxes := a.configManager.GetAvailableSandboxes()
+	// Boxes from Sandboxie.ini are merged with the manually added ones;
+	// if the ini can't be read we still show the configured list
+	discovered, err := a.sandboxieManager.ListSandboxes()
+	if err != nil {
+		discovered = []SandboxInfo{}
+	}
+	sandboxes := mergeSandboxNames(a.configManager.GetAvailableSandboxes(), discovered)
+
+	if a.iconPrefetcher != nil {
+		paths := make([]string, 0, len(files))
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+		a.iconPrefetcher.Prefetch(paths, normalizeIconSize(int(a.iconSize.Load())))
+	}
 
 	return &AppState{
 		FolderPaths:        config.FolderPaths,
@@ -54,7 +194,58 @@ func (a *App) GetAppState() *AppState {
 		Files:              files,
 		SelectedSandbox:    config.SelectedSandbox,
 		AvailableSandboxes: sandboxes,
+		Sandboxes:          discovered,
+		VirtualFolders:     getVirtualFolders(),
+		Sandboxie:          a.sandboxieManager.Status(),
+		ConfigWarnings:     a.configManager.Warnings(),
+		ActiveWorkspace:    config.ActiveWorkspace,
+		Workspaces:         a.configManager.GetWorkspaces(),
+	}
+}
+
+// folderContents lists a real or virtual folder with sandbox bindings
+// applied
+func (a *App) folderContents(path string) ([]FileInfo, error) {
+	var files []FileInfo
+	if isVirtualFolder(path) {
+		files = a.virtualFolderContents(path)
+	} else {
+		var err error
+		if files, err = a.listDirectory(path); err != nil {
+			return nil, err
+		}
 	}
+	a.applySandboxBindings(files)
+	return files, nil
+}
+
+// virtualFolderContents lists the programs of a virtual folder
+func (a *App) virtualFolderContents(id string) []FileInfo {
+	if isMergedPath(id) {
+		return a.mergedFolderContents(id)
+	}
+
+	switch id {
+	case VirtualFavorites:
+		return favoriteFiles(a.fileManager, a.configManager.GetFavorites())
+	case VirtualRecent:
+		return filesFromPaths(a.fileManager, a.historyManager.Recent(virtualFolderSize))
+	case VirtualMostUsed:
+		return filesFromPaths(a.fileManager, a.historyManager.MostUsed(virtualFolderSize, time.Now()))
+	}
+	return []FileInfo{}
+}
+
+// mergedFolderContents overlays the listings of the real folders behind a
+// merged path
+func (a *App) mergedFolderContents(path string) []FileInfo {
+	var listings [][]FileInfo
+	for _, dir := range mergedDirs(path) {
+		if files, err := a.listDirectory(dir); err == nil {
+			listings = append(listings, files)
+		}
+	}
+	return mergeListings(path, listings)
 }
 
 // SelectFolder selects a folder and returns the updated state
@@ -66,6 +257,7 @@ func (a *App) SelectFolder(folderPath string) (*AppState, error) {
 	if err := a.configManager.AddFolderPath(folderPath); err != nil {
 		return nil, err
 	}
+	a.refreshIndexRoots()
 
 	return a.GetAppState(), nil
 }
@@ -84,6 +276,7 @@ func (a *App) RemoveFolder(folderPath string) (*AppState, error) {
 	if err := a.configManager.RemoveFolderPath(folderPath); err != nil {
 		return nil, err
 	}
+	a.refreshIndexRoots()
 
 	return a.GetAppState(), nil
 }
@@ -97,24 +290,158 @@ func (a *App) SetSelectedSandbox(sandbox string) (*AppState, error) {
 	return a.GetAppState(), nil
 }
 
-// LaunchProgram launches a program in the selected sandbox
+// applySandboxBindings fills in the bound sandbox and favorite state of each file
+func (a *App) applySandboxBindings(files []FileInfo) {
+	for i := range files {
+		a.applySandboxBinding(&files[i])
+	}
+}
+
+// applySandboxBinding fills in the bound sandbox and favorite state of one file
+func (a *App) applySandboxBinding(file *FileInfo) {
+	file.Favorite = a.configManager.IsFavorite(file.Path)
+	if file.IsDir {
+		return
+	}
+	if sandbox, ok := a.configManager.BoundSandbox(file.Path); ok {
+		file.Sandbox = sandbox
+	}
+}
+
+// LaunchProgram launches a program in its bound sandbox, or the selected
+// sandbox if it has no binding, using its saved launch profile if any
 func (a *App) LaunchProgram(filePath string) (*LaunchResponse, error) {
-	config := a.configManager.GetConfig()
+	opts, _ := a.configManager.GetLaunchProfile(filePath)
+	return a.LaunchProgramWithOptions(filePath, opts)
+}
+
+// LaunchProgramWithOptions launches a program with explicit launch options
+func (a *App) LaunchProgramWithOptions(filePath string, opts LaunchOptions) (*LaunchResponse, error) {
+	return a.launchInSandbox(filePath, a.configManager.ResolveSandbox(filePath), opts), nil
+}
+
+// launchInSandbox launches a program in the given sandbox and records the
+// launch in the history
+func (a *App) launchInSandbox(filePath string, sandbox string, opts LaunchOptions) *LaunchResponse {
+	target, opts := resolveLaunchTarget(filePath, opts)
+	target, opts, err := a.fileManager.launchTarget(target, opts)
+	if err != nil {
+		return &LaunchResponse{Success: false, Message: err.Error()}
+	}
+
+	// Start.exe's PID is useless since it exits right away; the program's
+	// PID is filled into the history once the process tracker sees it
+	a.processTracker.BeginLaunch(sandbox)
+	_, err = a.sandboxieManager.LaunchProgramWithOptions(target, sandbox, opts)
+
+	record := LaunchRecord{
+		Path:      filePath,
+		Sandbox:   sandbox,
+		Args:      opts.Args,
+		Timestamp: time.Now(),
+		Success:   err == nil,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	a.historyManager.Record(record)
 
-	pid, err := a.sandboxieManager.LaunchProgram(filePath, config.SelectedSandbox)
 	if err != nil {
 		return &LaunchResponse{
 			Success: false,
 			Message: err.Error(),
-			PID:     0,
-		}, nil
+		}
 	}
 
+	// Tracked only now, so the record exists when the program shows up
+	a.processTracker.TrackLaunch(sandbox, filePath, target, func(pid int) {
+		a.historyManager.SetPID(record.Path, record.Timestamp, pid)
+	})
 	return &LaunchResponse{
 		Success: true,
-		Message: fmt.Sprintf("Program launched with PID %d", pid),
-		PID:     pid,
-	}, nil
+		Message: fmt.Sprintf("Program launched in %s", sandbox),
+	}
+}
+
+// SetLaunchProfile saves launch options used whenever the program is launched
+func (a *App) SetLaunchProfile(filePath string, opts LaunchOptions) error {
+	return a.configManager.SetLaunchProfile(filePath, opts)
+}
+
+// RemoveLaunchProfile removes the saved launch options for a program
+func (a *App) RemoveLaunchProfile(filePath string) error {
+	return a.configManager.RemoveLaunchProfile(filePath)
+}
+
+// GetLaunchProfile returns the saved launch options for a program
+func (a *App) GetLaunchProfile(filePath string) LaunchOptions {
+	opts, _ := a.configManager.GetLaunchProfile(filePath)
+	return opts
+}
+
+// GetRunningPrograms returns the tracked processes of a sandbox, or of
+// all sandboxes when box is empty
+func (a *App) GetRunningPrograms(box string) []RunningProgram {
+	return a.processTracker.RunningPrograms(box)
+}
+
+// TerminateBox terminates all programs running in a sandbox
+func (a *App) TerminateBox(box string) *BoxOperationResult {
+	if err := validateBoxName(box); err != nil {
+		return boxOperationResult(box, BoxOpTerminate, err)
+	}
+
+	err := a.sandboxieManager.TerminateAllPrograms(box)
+	// Report the exits to the frontend right away
+	a.processTracker.Poll(box)
+	return boxOperationResult(box, BoxOpTerminate, err)
+}
+
+// RequestDeleteConfirmation returns the token DeleteBoxContents and
+// TerminateAndDelete require, so a single stray call can't empty a box
+func (a *App) RequestDeleteConfirmation(box string) (*DeleteConfirmation, error) {
+	if err := validateBoxName(box); err != nil {
+		return nil, err
+	}
+	conf, err := a.deleteTokens.Issue(box)
+	if err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// DeleteBoxContents deletes the contents of a sandbox. Programs still
+// running in the box may prevent files from being deleted.
+func (a *App) DeleteBoxContents(box string, token string) *BoxOperationResult {
+	if err := validateBoxName(box); err != nil {
+		return boxOperationResult(box, BoxOpDelete, err)
+	}
+	if err := a.deleteTokens.Consume(box, token); err != nil {
+		return boxOperationResult(box, BoxOpDelete, err)
+	}
+
+	err := a.sandboxieManager.DeleteSandboxContents(box)
+	return boxOperationResult(box, BoxOpDelete, err)
+}
+
+// TerminateAndDelete terminates all programs in a sandbox, then deletes
+// its contents. The delete is skipped if terminating fails.
+func (a *App) TerminateAndDelete(box string, token string) []BoxOperationResult {
+	if err := validateBoxName(box); err != nil {
+		return []BoxOperationResult{*boxOperationResult(box, BoxOpTerminate, err)}
+	}
+	// Check the token before touching anything
+	if err := a.deleteTokens.Consume(box, token); err != nil {
+		return []BoxOperationResult{*boxOperationResult(box, BoxOpDelete, err)}
+	}
+
+	terminate := a.TerminateBox(box)
+	if !terminate.Success {
+		return []BoxOperationResult{*terminate}
+	}
+
+	err := a.sandboxieManager.DeleteSandboxContents(box)
+	return []BoxOperationResult{*terminate, *boxOperationResult(box, BoxOpDelete, err)}
 }
 
 // IsSandboxieAvailable checks if Sandboxie is installed
@@ -122,6 +449,29 @@ func (a *App) IsSandboxieAvailable() bool {
 	return a.sandboxieManager.IsAvailable()
 }
 
+// GetSandboxieStatus returns where Start.exe was found
+func (a *App) GetSandboxieStatus() SandboxieStatus {
+	return a.sandboxieManager.Status()
+}
+
+// RedetectSandboxie looks for Sandboxie again, e.g. after installing it
+func (a *App) RedetectSandboxie() SandboxieStatus {
+	return a.sandboxieManager.Detect(a.configManager.GetConfig().SandboxiePath)
+}
+
+// SetSandboxiePath sets the Sandboxie install folder or Start.exe path
+// (empty to auto-detect) and re-runs detection
+func (a *App) SetSandboxiePath(path string) (SandboxieStatus, error) {
+	if err := a.configManager.SetSandboxiePath(path); err != nil {
+		return SandboxieStatus{}, err
+	}
+	status := a.sandboxieManager.Detect(path)
+	if path != "" && status.Source != SourceConfig {
+		return status, fmt.Errorf("Start.exe not found at %s", path)
+	}
+	return status, nil
+}
+
 // OpenFolderDialog opens a folder selection dialog
 func (a *App) OpenFolderDialog() (string, error) {
 	fmt.Print("OpenFolderDialog start")
@@ -176,12 +526,144 @@ func (a *App) RemoveAvailableSandbox(sandbox string) (*AppState, error) {
 
 // GetAvailableSandboxes returns the list of available sandboxes
 func (a *App) GetAvailableSandboxes() []string {
-	return a.configManager.GetAvailableSandboxes()
+	discovered, _ := a.sandboxieManager.ListSandboxes()
+	return mergeSandboxNames(a.configManager.GetAvailableSandboxes(), discovered)
+}
+
+// GetSandboxes returns the boxes defined in Sandboxie.ini
+func (a *App) GetSandboxes() ([]SandboxInfo, error) {
+	return a.sandboxieManager.ListSandboxes()
+}
+
+// SetSandboxBinding binds a path, glob or folder to a sandbox
+func (a *App) SetSandboxBinding(pattern string, matchType string, sandbox string) (*AppState, error) {
+	binding := SandboxBinding{
+		Pattern:   pattern,
+		MatchType: matchType,
+		Sandbox:   sandbox,
+	}
+	if err := a.configManager.SetSandboxBinding(binding); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// RemoveSandboxBinding removes the binding with the given pattern
+func (a *App) RemoveSandboxBinding(pattern string) (*AppState, error) {
+	if err := a.configManager.RemoveSandboxBinding(pattern); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// GetSandboxBindings returns the configured sandbox bindings
+func (a *App) GetSandboxBindings() []SandboxBinding {
+	return a.configManager.GetSandboxBindings()
+}
+
+// Search recursively searches all configured and merged folders. Starting a new
+// search cancels the previous one, which then returns an error.
+func (a *App) Search(query string) ([]SearchResult, error) {
+	results, err := a.searcher.Search(query, a.indexRoots())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		a.applySandboxBinding(&results[i].File)
+	}
+	return results, nil
+}
+
+// AddFavorite pins a program to the Favorites folder
+func (a *App) AddFavorite(filePath string) (*AppState, error) {
+	if err := a.configManager.AddFavorite(filePath); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// RemoveFavorite unpins a program
+func (a *App) RemoveFavorite(filePath string) (*AppState, error) {
+	if err := a.configManager.RemoveFavorite(filePath); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// MoveFavorite moves a favorite to a new position
+func (a *App) MoveFavorite(filePath string, index int) (*AppState, error) {
+	if err := a.configManager.MoveFavorite(filePath, index); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// UpdateFavorite sets a favorite's display name and sandbox override
+func (a *App) UpdateFavorite(filePath string, name string, sandbox string) (*AppState, error) {
+	if err := a.configManager.UpdateFavorite(filePath, name, sandbox); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// GetFavorites returns the pinned programs in order
+func (a *App) GetFavorites() []Favorite {
+	return a.configManager.GetFavorites()
+}
+
+// GetLaunchHistory returns up to limit launches, newest first
+func (a *App) GetLaunchHistory(limit int) []LaunchRecord {
+	return a.historyManager.History(limit)
+}
+
+// GetProgramHistory returns the launches of one program, newest first
+func (a *App) GetProgramHistory(filePath string) []LaunchRecord {
+	return a.historyManager.HistoryFor(filePath)
+}
+
+// ClearLaunchHistory removes all launch history
+func (a *App) ClearLaunchHistory() (*AppState, error) {
+	if err := a.historyManager.Clear(); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// GetShortcutInfo parses a .lnk file and returns its target details
+func (a *App) GetShortcutInfo(filePath string) (*ShellLink, error) {
+	return ReadShellLink(filePath)
 }
 
 // GetFileIcon returns the base64 encoded icon for a file
 func (a *App) GetFileIcon(filePath string) string {
-	return GetFileIconBase64(filePath)
+	return a.fileIcon(filePath, defaultIconSize)
+}
+
+// fileIcon returns the icon of a file at one of the supported sizes
+func (a *App) fileIcon(filePath string, size int) string {
+	return a.iconCache.Icon(filePath, normalizeIconSize(size), extractFileIconPNG)
+}
+
+// GetFileIcons returns the icons of several files in one call, keyed by
+// path. size is the wanted size in pixels (16, 32, 48 or 256; other
+// values use the next larger one). Files without an icon are left out.
+func (a *App) GetFileIcons(paths []string, size int) map[string]string {
+	size = normalizeIconSize(size)
+	a.iconSize.Store(int32(size))
+
+	icons := make(map[string]string, len(paths))
+	var mu sync.Mutex
+	loadIcons(context.Background(), paths, func(path string) string {
+		return a.fileIcon(path, size)
+	}, func(r IconResult) {
+		if r.Icon != "" {
+			mu.Lock()
+			icons[r.Path] = r.Icon
+			mu.Unlock()
+		}
+	})
+	return icons
 }
 
 // OpenConfigFile opens the configuration file in the default text editor
@@ -192,16 +674,11 @@ func (a *App) OpenConfigFile() error {
 	// Check if the config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		// If config file doesn't exist, create an empty one
-		emptyConfig := &Config{
-			FolderPaths:        []string{},
-			SelectedSandbox:    "DefaultBox",
-			AvailableSandboxes: []string{"DefaultBox", "__ask__"},
-		}
-		data, err := json.MarshalIndent(emptyConfig, "", "  ")
+		data, err := json.MarshalIndent(newDefaultConfig(), "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to create empty config: %v", err)
 		}
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
+		if err := writeFileAtomic(configPath, data, 0644); err != nil {
 			return fmt.Errorf("failed to write empty config: %v", err)
 		}
 	}
@@ -215,9 +692,106 @@ func (a *App) OpenConfigFile() error {
 	return nil
 }
 
+// ExportConfig saves the given config sections (all when empty) to a
+// shareable file. Paths below well-known folders are stored as %VAR%.
+func (a *App) ExportConfig(path string, sections []string) error {
+	return a.configManager.ExportConfig(path, sections)
+}
+
+// PreviewImportConfig returns what importing a shared config would change
+func (a *App) PreviewImportConfig(path string, mode string) ([]ImportChange, error) {
+	return a.configManager.PreviewImport(path, mode)
+}
+
+// ImportConfig merges ("merge") or replaces ("replace") config sections
+// from a shared file and returns what changed
+func (a *App) ImportConfig(path string, mode string) ([]ImportChange, error) {
+	changes, err := a.configManager.ImportConfig(path, mode)
+	if err != nil {
+		return nil, err
+	}
+	a.applyFileTypes()
+	a.refreshIndexRoots()
+	return changes, nil
+}
+
+// CreateWorkspace adds a named workspace, optionally copying the active one
+func (a *App) CreateWorkspace(name string, copyCurrent bool) (*AppState, error) {
+	if err := a.configManager.CreateWorkspace(name, copyCurrent); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// SwitchWorkspace activates a workspace and returns the updated state
+func (a *App) SwitchWorkspace(name string) (*AppState, error) {
+	if err := a.configManager.SwitchWorkspace(name); err != nil {
+		return nil, err
+	}
+	a.refreshIndexRoots()
+	return a.GetAppState(), nil
+}
+
+// RenameWorkspace renames a workspace
+func (a *App) RenameWorkspace(oldName string, newName string) (*AppState, error) {
+	if err := a.configManager.RenameWorkspace(oldName, newName); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// DeleteWorkspace removes a workspace other than the active one
+func (a *App) DeleteWorkspace(name string) (*AppState, error) {
+	if err := a.configManager.DeleteWorkspace(name); err != nil {
+		return nil, err
+	}
+	return a.GetAppState(), nil
+}
+
+// GetFileTypeSettings returns the launchable file types, hide rules and
+// the show-all option
+func (a *App) GetFileTypeSettings() FileTypeSettings {
+	return a.configManager.GetFileTypeSettings()
+}
+
+// SetFileTypeSettings saves the file type settings and relists folders
+func (a *App) SetFileTypeSettings(settings FileTypeSettings) (*AppState, error) {
+	if err := a.configManager.SetFileTypeSettings(settings); err != nil {
+		return nil, err
+	}
+	a.applyFileTypes()
+	return a.GetAppState(), nil
+}
+
+// applyFileTypes hands the configured file types to the file manager and
+// drops listings made with the old ones
+func (a *App) applyFileTypes() {
+	a.fileTypesMu.Lock()
+	defer a.fileTypesMu.Unlock()
+
+	settings := a.configManager.GetFileTypeSettings()
+	if reflect.DeepEqual(a.appliedFileTypes, settings) {
+		return
+	}
+	a.appliedFileTypes = settings
+	a.fileManager.SetFileTypes(settings)
+	if a.fileIndex != nil {
+		a.fileIndex.Invalidate()
+	}
+}
+
+// DismissConfigWarnings clears config warnings after the user has seen them
+func (a *App) DismissConfigWarnings() {
+	a.configManager.ClearWarnings()
+}
+
 // OpenFolder opens a subfolder and returns the updated state
 func (a *App) OpenFolder(folderPath string) (*AppState, error) {
-	if err := a.fileManager.ValidateDirectory(folderPath); err != nil {
+	if isMergedPath(folderPath) {
+		if len(mergedDirs(folderPath)) == 0 {
+			return nil, fmt.Errorf("invalid folder: %s", folderPath)
+		}
+	} else if err := a.fileManager.ValidateDirectory(folderPath); err != nil {
 		return nil, fmt.Errorf("invalid folder: %v", err)
 	}
 
@@ -231,7 +805,13 @@ func (a *App) OpenFolder(folderPath string) (*AppState, error) {
 // GoBack navigates to the parent folder if possible
 func (a *App) GoBack() (*AppState, error) {
 	config := a.configManager.GetConfig()
-	if config.CurrentFolder == "" {
+	if parent, ok := mergedParent(config.CurrentFolder); ok {
+		if err := a.configManager.SetCurrentFolder(parent); err != nil {
+			return nil, err
+		}
+		return a.GetAppState(), nil
+	}
+	if config.CurrentFolder == "" || isVirtualFolder(config.CurrentFolder) {
 		return a.GetAppState(), nil
 	}
 
@@ -257,7 +837,10 @@ func (a *App) GoBack() (*AppState, error) {
 // CanGoBack checks if it's possible to navigate to the parent folder
 func (a *App) CanGoBack() bool {
 	config := a.configManager.GetConfig()
-	if config.CurrentFolder == "" {
+	if _, ok := mergedParent(config.CurrentFolder); ok {
+		return true
+	}
+	if config.CurrentFolder == "" || isVirtualFolder(config.CurrentFolder) {
 		return false
 	}
 
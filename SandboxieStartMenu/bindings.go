@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Binding match types
+const (
+	BindingExact  = "exact"  // Pattern is a full file path
+	BindingGlob   = "glob"   // Pattern is a glob matched against the path or file name
+	BindingFolder = "folder" // Pattern is a folder; everything below it matches
+)
+
+// SandboxBinding maps a program (or group of programs) to a sandbox
+type SandboxBinding struct {
+	Pattern   string `json:"pattern"`
+	MatchType string `json:"matchType"` // "exact", "glob" or "folder"
+	Sandbox   string `json:"sandbox"`
+}
+
+// validateBinding checks that a binding is well formed
+func validateBinding(b SandboxBinding) error {
+	if b.Pattern == "" {
+		return fmt.Errorf("binding pattern is empty")
+	}
+	if b.Sandbox == "" {
+		return fmt.Errorf("binding sandbox is empty")
+	}
+	switch b.MatchType {
+	case BindingExact, BindingFolder:
+	case BindingGlob:
+		if _, err := filepath.Match(strings.ToLower(b.Pattern), ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %v", b.Pattern, err)
+		}
+	default:
+		return fmt.Errorf("unknown binding match type: %s", b.MatchType)
+	}
+	return nil
+}
+
+// matchBinding reports whether the binding applies to filePath and
+// returns a score used to pick the most specific binding
+func matchBinding(b SandboxBinding, filePath string) (int, bool) {
+	path := normalizeBindingPath(filePath)
+	pattern := normalizeBindingPath(b.Pattern)
+
+	switch b.MatchType {
+	case BindingExact:
+		if path == pattern {
+			// Exact bindings always win
+			return 1 << 20, true
+		}
+	case BindingGlob:
+		target := path
+		if !strings.ContainsAny(pattern, `\/`) {
+			// Patterns without a separator match the file name only
+			target = filepath.Base(path)
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return 1<<19 + len(pattern), true
+		}
+	case BindingFolder:
+		folder := strings.TrimRight(pattern, `\/`)
+		if folder != "" && strings.HasPrefix(path, folder) {
+			rest := path[len(folder):]
+			if strings.HasPrefix(rest, `\`) || strings.HasPrefix(rest, "/") {
+				// Deeper folders are more specific
+				return len(folder), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// normalizeBindingPath makes paths comparable; Windows paths are case-insensitive
+func normalizeBindingPath(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// resolveBinding returns the sandbox bound to filePath, if any
+func resolveBinding(bindings []SandboxBinding, filePath string) (string, bool) {
+	bestScore := -1
+	sandbox := ""
+	for _, b := range bindings {
+		if score, ok := matchBinding(b, filePath); ok && score > bestScore {
+			bestScore = score
+			sandbox = b.Sandbox
+		}
+	}
+	return sandbox, bestScore >= 0
+}
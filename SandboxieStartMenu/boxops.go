@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const deleteTokenLifetime = 60 * time.Second // Time to confirm a delete
+
+// Box operation names reported in BoxOperationResult
+const (
+	BoxOpTerminate = "terminate"
+	BoxOpDelete    = "delete"
+)
+
+// BoxOperationResult reports the outcome of a box operation
+type BoxOperationResult struct {
+	Box       string `json:"box"`
+	Operation string `json:"operation"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
+
+// DeleteConfirmation is a single-use token that must be passed back to
+// delete a box's contents
+type DeleteConfirmation struct {
+	Box       string    `json:"box"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// deleteTokens hands out and checks delete confirmation tokens
+type deleteTokens struct {
+	mu     sync.Mutex
+	tokens map[string]DeleteConfirmation // Keyed by lower-case box name
+	now    func() time.Time
+}
+
+// newDeleteTokens creates an empty token store
+func newDeleteTokens() *deleteTokens {
+	return &deleteTokens{
+		tokens: make(map[string]DeleteConfirmation),
+		now:    time.Now,
+	}
+}
+
+// Issue creates a new token for box, replacing any previous one
+func (dt *deleteTokens) Issue(box string) (DeleteConfirmation, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return DeleteConfirmation{}, err
+	}
+
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	conf := DeleteConfirmation{
+		Box:       box,
+		Token:     hex.EncodeToString(buf),
+		ExpiresAt: dt.now().Add(deleteTokenLifetime),
+	}
+	dt.tokens[strings.ToLower(box)] = conf
+	return conf, nil
+}
+
+// Consume checks and invalidates the token for box
+func (dt *deleteTokens) Consume(box string, token string) error {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	key := strings.ToLower(box)
+	conf, ok := dt.tokens[key]
+	if !ok || token == "" || conf.Token != token {
+		return fmt.Errorf("invalid confirmation token for %s", box)
+	}
+	delete(dt.tokens, key)
+
+	if dt.now().After(conf.ExpiresAt) {
+		return fmt.Errorf("confirmation token for %s has expired", box)
+	}
+	return nil
+}
+
+// validateBoxName rejects names that don't refer to a single real box
+func validateBoxName(box string) error {
+	if box == "" || box == "__ask__" {
+		return fmt.Errorf("invalid sandbox: %q", box)
+	}
+	if strings.ContainsAny(box, ` :\/"`) {
+		return fmt.Errorf("invalid sandbox name: %s", box)
+	}
+	return nil
+}
+
+// boxOperationResult builds a result from an operation error
+func boxOperationResult(box, operation string, err error) *BoxOperationResult {
+	if err != nil {
+		return &BoxOperationResult{
+			Box:       box,
+			Operation: operation,
+			Success:   false,
+			Message:   err.Error(),
+		}
+	}
+
+	message := fmt.Sprintf("All programs in %s terminated", box)
+	if operation == BoxOpDelete {
+		message = fmt.Sprintf("Contents of %s deleted", box)
+	}
+	return &BoxOperationResult{
+		Box:       box,
+		Operation: operation,
+		Success:   true,
+		Message:   message,
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testStartExe = `C:\Program Files\Sandboxie-Plus\Start.exe`
+
+// fakeRunner records the commands it is given instead of running them
+type fakeRunner struct {
+	mu       sync.Mutex
+	commands []Command
+	fail     map[string]error // Error returned for commands with this argument
+}
+
+func (r *fakeRunner) Run(cmd Command) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, cmd)
+	for _, arg := range cmd.Args {
+		if err := r.fail[arg]; err != nil {
+			return nil, err
+		}
+	}
+	if slices.Contains(cmd.Args, "/listpids") {
+		return []byte("0\r\n"), nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRunner) Start(cmd Command) (int, error) {
+	r.Run(cmd)
+	return 4242, nil
+}
+
+// argv returns the arguments of every Start.exe call but /listpids polls
+func (r *fakeRunner) argv(t *testing.T) []string {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []string
+	for _, cmd := range r.commands {
+		if cmd.Path != testStartExe {
+			t.Errorf("ran %s, want Start.exe", cmd.Path)
+		}
+		if !slices.Contains(cmd.Args, "/listpids") {
+			calls = append(calls, strings.Join(cmd.Args, " "))
+		}
+	}
+	return calls
+}
+
+// newBoxOpsApp returns an App whose Start.exe calls go to a fakeRunner
+func newBoxOpsApp() (*App, *fakeRunner) {
+	runner := &fakeRunner{fail: make(map[string]error)}
+	sm := NewSandboxieManagerWithRunner(testStartExe, runner)
+	return &App{
+		sandboxieManager: sm,
+		processTracker:   NewProcessTracker(&sandboxieProcessSource{sandboxieManager: sm}),
+		deleteTokens:     newDeleteTokens(),
+	}, runner
+}
+
+func TestTerminateBox(t *testing.T) {
+	a, runner := newBoxOpsApp()
+	result := a.TerminateBox("DefaultBox")
+	if !result.Success || result.Operation != BoxOpTerminate {
+		t.Fatalf("TerminateBox = %+v", result)
+	}
+	if got, want := runner.argv(t), []string{"/box:DefaultBox /terminate"}; !slices.Equal(got, want) {
+		t.Errorf("Start.exe calls = %q, want %q", got, want)
+	}
+
+	runner.fail["/terminate"] = errors.New("access denied")
+	if result := a.TerminateBox("DefaultBox"); result.Success || result.Message != "access denied" {
+		t.Errorf("failed TerminateBox = %+v", result)
+	}
+}
+
+func TestDeleteBoxContents(t *testing.T) {
+	a, runner := newBoxOpsApp()
+
+	if result := a.DeleteBoxContents("DefaultBox", ""); result.Success {
+		t.Fatal("delete without a token succeeded")
+	}
+	conf, err := a.RequestDeleteConfirmation("DefaultBox")
+	if err != nil {
+		t.Fatalf("RequestDeleteConfirmation: %v", err)
+	}
+	if result := a.DeleteBoxContents("DefaultBox", conf.Token+"0"); result.Success {
+		t.Fatal("delete with a wrong token succeeded")
+	}
+	if result := a.DeleteBoxContents("DefaultBox", conf.Token); !result.Success {
+		t.Fatalf("DeleteBoxContents = %+v", result)
+	}
+	if result := a.DeleteBoxContents("DefaultBox", conf.Token); result.Success {
+		t.Fatal("token was accepted twice")
+	}
+
+	if got, want := runner.argv(t), []string{"/box:DefaultBox delete_sandbox_silent"}; !slices.Equal(got, want) {
+		t.Errorf("Start.exe calls = %q, want %q", got, want)
+	}
+}
+
+func TestTerminateAndDelete(t *testing.T) {
+	a, runner := newBoxOpsApp()
+	conf, _ := a.RequestDeleteConfirmation("Browsers")
+	results := a.TerminateAndDelete("Browsers", conf.Token)
+	if len(results) != 2 || !results[0].Success || !results[1].Success {
+		t.Fatalf("TerminateAndDelete = %+v", results)
+	}
+	want := []string{"/box:Browsers /terminate", "/box:Browsers delete_sandbox_silent"}
+	if got := runner.argv(t); !slices.Equal(got, want) {
+		t.Errorf("Start.exe calls = %q, want %q", got, want)
+	}
+
+	// A failed terminate skips the delete and still uses up the token
+	a, runner = newBoxOpsApp()
+	runner.fail["/terminate"] = errors.New("access denied")
+	conf, _ = a.RequestDeleteConfirmation("Browsers")
+	results = a.TerminateAndDelete("Browsers", conf.Token)
+	if len(results) != 1 || results[0].Success || results[0].Operation != BoxOpTerminate {
+		t.Fatalf("TerminateAndDelete = %+v", results)
+	}
+	if got := runner.argv(t); !slices.Equal(got, want[:1]) {
+		t.Errorf("Start.exe calls = %q, want %q", got, want[:1])
+	}
+	if result := a.DeleteBoxContents("Browsers", conf.Token); result.Success {
+		t.Error("token was accepted after TerminateAndDelete")
+	}
+}
+
+func TestBoxOperationsRejectBadNames(t *testing.T) {
+	a, runner := newBoxOpsApp()
+	for _, box := range []string{"", "__ask__", "Default Box", `..\Other`, "a/b", `x"y`, "C:"} {
+		if result := a.TerminateBox(box); result.Success {
+			t.Errorf("TerminateBox(%q) succeeded", box)
+		}
+		if _, err := a.RequestDeleteConfirmation(box); err == nil {
+			t.Errorf("RequestDeleteConfirmation(%q) succeeded", box)
+		}
+		if result := a.DeleteBoxContents(box, "token"); result.Success {
+			t.Errorf("DeleteBoxContents(%q) succeeded", box)
+		}
+	}
+	if got := runner.argv(t); len(got) != 0 {
+		t.Errorf("Start.exe was run: %q", got)
+	}
+}
+
+func TestDeleteTokens(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	dt := newDeleteTokens()
+	dt.now = func() time.Time { return now }
+
+	// Tokens are bound to their box; names match case-insensitively
+	a, _ := dt.Issue("BoxA")
+	if err := dt.Consume("BoxB", a.Token); err == nil {
+		t.Error("token accepted for another box")
+	}
+	if err := dt.Consume("boxa", a.Token); err != nil {
+		t.Errorf("Consume after a wrong-box attempt: %v", err)
+	}
+	if err := dt.Consume("BoxA", a.Token); err == nil {
+		t.Error("token reused")
+	}
+
+	// A new token replaces the previous one
+	first, _ := dt.Issue("BoxA")
+	second, _ := dt.Issue("BoxA")
+	if first.Token == second.Token {
+		t.Fatal("tokens repeat")
+	}
+	if err := dt.Consume("BoxA", first.Token); err == nil {
+		t.Error("replaced token accepted")
+	}
+	if err := dt.Consume("BoxA", second.Token); err != nil {
+		t.Errorf("Consume: %v", err)
+	}
+
+	// Tokens expire, and an expired token is gone for good
+	expiring, _ := dt.Issue("BoxA")
+	if !expiring.ExpiresAt.Equal(now.Add(deleteTokenLifetime)) {
+		t.Errorf("ExpiresAt = %v", expiring.ExpiresAt)
+	}
+	now = now.Add(deleteTokenLifetime + time.Second)
+	if err := dt.Consume("BoxA", expiring.Token); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expired token: %v", err)
+	}
+	if err := dt.Consume("BoxA", expiring.Token); err == nil {
+		t.Error("expired token accepted on retry")
+	}
+
+	// Just inside the lifetime is fine
+	valid, _ := dt.Issue("BoxA")
+	now = now.Add(deleteTokenLifetime)
+	if err := dt.Consume("BoxA", valid.Token); err != nil {
+		t.Errorf("token at the end of its lifetime: %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cliUsage is printed for -h and for malformed command lines
+const cliUsage = `Usage: SandboxieStartMenu <command> [options]
+
+Commands:
+  launch [--box NAME] [--dir DIR] [--wait] [--hide] [--elevate] [--silent] PATH [ARGS...]
+                          Launch a program in its bound or the selected sandbox
+  list-boxes              List the sandboxes
+  list [FOLDER]           List a folder, the current folder by default
+  search QUERY            Search all folders
+  terminate BOX           Terminate all programs in a sandbox
+  delete --yes [--terminate] BOX
+                          Delete the contents of a sandbox
+  config get [KEY]        Print a config.json field, or the whole config
+  config set KEY VALUE    Set a config.json field; VALUE is JSON or a plain string
+
+SandboxieStartMenu --box NAME --launch PATH is the same as launch.
+Output is JSON. The exit code is 0 on success, 1 if the command failed
+and 2 for usage errors.
+`
+
+// cliCommand runs one subcommand and returns the value printed as JSON.
+// A failed command may return both a value and an error.
+type cliCommand func(a *App, args []string) (interface{}, error)
+
+// cliCommands maps subcommand names to their implementation
+var cliCommands = map[string]cliCommand{
+	"launch":     cliLaunch,
+	"list-boxes": cliListBoxes,
+	"list":       cliList,
+	"search":     cliSearch,
+	"terminate":  cliTerminate,
+	"delete":     cliDelete,
+	"config":     cliConfig,
+}
+
+// cliUsageError is a malformed command line, reported with the usage text
+type cliUsageError struct {
+	message string
+}
+
+func (e *cliUsageError) Error() string {
+	return e.message
+}
+
+// usageErrorf returns a cliUsageError
+func usageErrorf(format string, args ...interface{}) error {
+	return &cliUsageError{message: fmt.Sprintf(format, args...)}
+}
+
+// isHelpFlag reports whether arg asks for the usage text
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// isCLI reports whether the command line asks for CLI mode instead of the
+// window
+func isCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if _, ok := cliCommands[args[0]]; ok || isHelpFlag(args[0]) {
+		return true
+	}
+	return slices.ContainsFunc(args, func(arg string) bool {
+		name, _, _ := strings.Cut(arg, "=")
+		return name == "-launch" || name == "--launch"
+	})
+}
+
+// runCLI runs a command line without starting the window and returns the
+// process exit code
+func runCLI(a *App, args []string, stdout io.Writer, stderr io.Writer) int {
+	name, args := splitCLICommand(args)
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		fmt.Fprint(stdout, cliUsage)
+		return 0
+	}
+
+	command, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command: %s\n\n%s", name, cliUsage)
+		return 2
+	}
+
+	result, err := command(a, args)
+	var usageErr *cliUsageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(stderr, "%s\n\n%s", usageErr.message, cliUsage)
+		return 2
+	}
+	return writeCLIResult(stdout, result, err)
+}
+
+// splitCLICommand returns the subcommand of a command line and its
+// arguments
+func splitCLICommand(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "launch", args // The --box ... --launch PATH form
+}
+
+// writeCLIResult prints a command's result, or its error when there is no
+// result, and returns the exit code
+func writeCLIResult(stdout io.Writer, result interface{}, err error) int {
+	if result == nil && err != nil {
+		result = map[string]string{"error": err.Error()}
+	}
+	if result != nil {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(result)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// runCommandLine runs a launch, list or search in the running instance, so
+// launches are tracked there and searches use its index, or here when no
+// instance is running. Other commands always run here; the running
+// instance picks up config changes through its config watcher. It returns
+// the exit code.
+func runCommandLine(args []string, stdout io.Writer, stderr io.Writer) int {
+	if !isForwardedCommand(args) {
+		return runCLI(NewApp(), args, stdout, stderr)
+	}
+	response, err := SendIPCRequest(ipcName, IPCRequest{Args: absCLIPaths(args)})
+	if errors.Is(err, errNoPrimary) {
+		return runCLI(NewApp(), args, stdout, stderr)
+	}
+	if err != nil {
+		return writeCLIResult(stdout, nil, err)
+	}
+	io.WriteString(stdout, response.Stdout)
+	io.WriteString(stderr, response.Stderr)
+	return response.ExitCode
+}
+
+// absCLIPaths makes the relative PATH, --launch, --dir and FOLDER arguments
+// of a command line absolute, since the running instance resolves them
+// against its own working directory
+func absCLIPaths(args []string) []string {
+	args = slices.Clone(args)
+	name, rest := splitCLICommand(args)
+
+	switch name {
+	case "launch":
+		launchFlag := false
+		for i := 0; i < len(rest); i++ {
+			arg := rest[i]
+			if arg == "--" {
+				if !launchFlag && i+1 < len(rest) {
+					rest[i+1] = absCLIPath(rest[i+1])
+				}
+				break
+			}
+			if !strings.HasPrefix(arg, "-") || arg == "-" {
+				// The first positional argument is the program, unless
+				// --launch named it; the rest are its arguments
+				if !launchFlag {
+					rest[i] = absCLIPath(arg)
+				}
+				break
+			}
+			flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			switch flagName {
+			case "launch", "dir":
+				launchFlag = launchFlag || flagName == "launch"
+				if hasValue {
+					rest[i] = arg[:len(arg)-len(value)] + absCLIPath(value)
+				} else if i+1 < len(rest) {
+					i++
+					rest[i] = absCLIPath(rest[i])
+				}
+			case "box":
+				if !hasValue {
+					i++
+				}
+			}
+		}
+	case "list":
+		for i, arg := range rest {
+			if !strings.HasPrefix(arg, "-") && !isVirtualFolder(arg) {
+				rest[i] = absCLIPath(arg)
+			}
+		}
+	}
+	return args
+}
+
+// absCLIPath returns path made absolute, or unchanged if it can't be
+func absCLIPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// newCLIFlags returns a flag set that reports errors instead of exiting
+func newCLIFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseCLIFlags parses args and checks the number of positional arguments;
+// maxArgs < 0 means no limit
+func parseCLIFlags(fs *flag.FlagSet, args []string, minArgs int, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return usageErrorf("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		return usageErrorf("%s: wrong number of arguments", fs.Name())
+	}
+	return nil
+}
+
+// cliLaunch launches a program. Without --box it goes to the sandbox the
+// window would use; the saved launch profile applies unless overridden.
+func cliLaunch(a *App, args []string) (interface{}, error) {
+	fs := newCLIFlags("launch")
+	box := fs.String("box", "", "sandbox to launch in")
+	path := fs.String("launch", "", "program to launch")
+	dir := fs.String("dir", "", "working directory")
+	wait := fs.Bool("wait", false, "Start.exe /wait")
+	hide := fs.Bool("hide", false, "Start.exe /hide_window")
+	elevate := fs.Bool("elevate", false, "Start.exe /elevate")
+	silent := fs.Bool("silent", false, "Start.exe /silent")
+	if err := parseCLIFlags(fs, args, 0, -1); err != nil {
+		return nil, err
+	}
+
+	programArgs := fs.Args()
+	if *path == "" {
+		if len(programArgs) == 0 {
+			return nil, usageErrorf("launch: no program given")
+		}
+		*path, programArgs = programArgs[0], programArgs[1:]
+	}
+
+	opts, _ := a.configManager.GetLaunchProfile(*path)
+	if len(programArgs) > 0 {
+		opts.Args = programArgs
+	}
+	if *dir != "" {
+		opts.WorkingDir = *dir
+	}
+	opts.Wait = opts.Wait || *wait
+	opts.HideWindow = opts.HideWindow || *hide
+	opts.Elevate = opts.Elevate || *elevate
+	opts.Silent = opts.Silent || *silent
+
+	sandbox := *box
+	if sandbox == "" {
+		sandbox = a.configManager.ResolveSandbox(*path)
+	}
+	result := a.launchInSandbox(*path, sandbox, opts)
+	if !result.Success {
+		return result, errors.New(result.Message)
+	}
+	return result, nil
+}
+
+// cliListBoxes lists the configured and discovered sandboxes
+func cliListBoxes(a *App, args []string) (interface{}, error) {
+	if err := parseCLIFlags(newCLIFlags("list-boxes"), args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	discovered, err := a.sandboxieManager.ListSandboxes()
+	if err != nil {
+		discovered = []SandboxInfo{}
+	}
+	return struct {
+		Selected  string        `json:"selected"`
+		Available []string      `json:"available"`
+		Sandboxes []SandboxInfo `json:"sandboxes"`
+	}{
+		Selected:  a.configManager.GetConfig().SelectedSandbox,
+		Available: mergeSandboxNames(a.configManager.GetAvailableSandboxes(), discovered),
+		Sandboxes: discovered,
+	}, nil
+}
+
+// cliList lists a folder, which may be a virtual folder ID
+func cliList(a *App, args []string) (interface{}, error) {
+	fs := newCLIFlags("list")
+	if err := parseCLIFlags(fs, args, 0, 1); err != nil {
+		return nil, err
+	}
+
+	folder := fs.Arg(0)
+	if folder == "" {
+		folder = a.configManager.GetConfig().CurrentFolder
+	}
+	if folder == "" {
+		return nil, fmt.Errorf("no folder selected")
+	}
+	files, err := a.folderContents(folder)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// cliSearch searches every configured and merged folder
+func cliSearch(a *App, args []string) (interface{}, error) {
+	fs := newCLIFlags("search")
+	if err := parseCLIFlags(fs, args, 1, -1); err != nil {
+		return nil, err
+	}
+	results, err := a.Search(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// cliTerminate terminates every program in a sandbox
+func cliTerminate(a *App, args []string) (interface{}, error) {
+	fs := newCLIFlags("terminate")
+	if err := parseCLIFlags(fs, args, 1, 1); err != nil {
+		return nil, err
+	}
+	return boxOperationOutcome(a.TerminateBox(fs.Arg(0)))
+}
+
+// cliDelete deletes the contents of a sandbox. --yes stands in for the
+// confirmation the window asks for.
+func cliDelete(a *App, args []string) (interface{}, error) {
+	fs := newCLIFlags("delete")
+	yes := fs.Bool("yes", false, "confirm the delete")
+	terminate := fs.Bool("terminate", false, "terminate the box's programs first")
+	if err := parseCLIFlags(fs, args, 1, 1); err != nil {
+		return nil, err
+	}
+	if !*yes {
+		return nil, usageErrorf("delete: deleting a sandbox's contents requires --yes")
+	}
+
+	box := fs.Arg(0)
+	conf, err := a.RequestDeleteConfirmation(box)
+	if err != nil {
+		return nil, err
+	}
+	if !*terminate {
+		return boxOperationOutcome(a.DeleteBoxContents(box, conf.Token))
+	}
+
+	results := a.TerminateAndDelete(box, conf.Token)
+	for _, r := range results {
+		if !r.Success {
+			return results, errors.New(r.Message)
+		}
+	}
+	return results, nil
+}
+
+// boxOperationOutcome turns a failed box operation into an error as well
+func boxOperationOutcome(result *BoxOperationResult) (interface{}, error) {
+	if !result.Success {
+		return result, errors.New(result.Message)
+	}
+	return result, nil
+}
+
+// cliConfig reads or changes config.json fields
+func cliConfig(a *App, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, usageErrorf("config: expected get or set")
+	}
+
+	switch args[0] {
+	case "get":
+		fs := newCLIFlags("config get")
+		if err := parseCLIFlags(fs, args[1:], 0, 1); err != nil {
+			return nil, err
+		}
+		return configValueOutcome(a.configManager.GetConfigValue(fs.Arg(0)))
+	case "set":
+		fs := newCLIFlags("config set")
+		if err := parseCLIFlags(fs, args[1:], 2, 2); err != nil {
+			return nil, err
+		}
+		key := fs.Arg(0)
+		if err := a.configManager.SetConfigValue(key, fs.Arg(1)); err != nil {
+			return nil, err
+		}
+		return configValueOutcome(a.configManager.GetConfigValue(key))
+	}
+	return nil, usageErrorf("config: unknown subcommand %s", args[0])
+}
+
+// configValueOutcome drops the nil value of a failed config read, so the
+// error is printed instead
+func configValueOutcome(value json.RawMessage, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
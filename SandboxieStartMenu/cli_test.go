@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAbsCLIPaths(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs := func(path string) string { return filepath.Join(wd, path) }
+	root := filepath.Join(string(filepath.Separator), "opt", "app.exe")
+	if vol := filepath.VolumeName(wd); vol != "" {
+		root = vol + root
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "launch path and program args",
+			args: []string{"launch", "--box", "Box", "--wait", "app.exe", "input.txt"},
+			want: []string{"launch", "--box", "Box", "--wait", abs("app.exe"), "input.txt"},
+		},
+		{
+			name: "launch with --dir and --box=",
+			args: []string{"launch", "--box=Box", "--dir", "work", "app.exe"},
+			want: []string{"launch", "--box=Box", "--dir", abs("work"), abs("app.exe")},
+		},
+		{
+			name: "launch with --dir=",
+			args: []string{"launch", "-dir=work", "app.exe"},
+			want: []string{"launch", "-dir=" + abs("work"), abs("app.exe")},
+		},
+		{
+			name: "--launch form leaves positional args alone",
+			args: []string{"--box", "Box", "--launch", "app.exe", "input.txt"},
+			want: []string{"--box", "Box", "--launch", abs("app.exe"), "input.txt"},
+		},
+		{
+			name: "--launch= form",
+			args: []string{"--launch=app.exe"},
+			want: []string{"--launch=" + abs("app.exe")},
+		},
+		{
+			name: "path after --",
+			args: []string{"launch", "--", "-odd.exe"},
+			want: []string{"launch", "--", abs("-odd.exe")},
+		},
+		{
+			name: "absolute path unchanged",
+			args: []string{"launch", root},
+			want: []string{"launch", root},
+		},
+		{
+			name: "list folder",
+			args: []string{"list", "Tools"},
+			want: []string{"list", abs("Tools")},
+		},
+		{
+			name: "list virtual folder",
+			args: []string{"list", `virtual:startmenu\Accessories`},
+			want: []string{"list", `virtual:startmenu\Accessories`},
+		},
+		{
+			name: "other commands unchanged",
+			args: []string{"search", "note", "pad"},
+			want: []string{"search", "note", "pad"},
+		},
+		{
+			name: "config values unchanged",
+			args: []string{"config", "set", "currentFolder", "Tools"},
+			want: []string{"config", "set", "currentFolder", "Tools"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := slices.Clone(tt.args)
+			got := absCLIPaths(tt.args)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("absCLIPaths(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+			if !slices.Equal(tt.args, original) {
+				t.Errorf("absCLIPaths modified its argument")
+			}
+		})
+	}
+}
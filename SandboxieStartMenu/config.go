@@ -1,23 +1,44 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 )
 
 // Config holds the application configuration
 type Config struct {
-	FolderPaths        []string `json:"folderPaths"`
-	CurrentFolder      string   `json:"currentFolder"`
-	SelectedSandbox    string   `json:"selectedSandbox"`
-	AvailableSandboxes []string `json:"availableSandboxes"`
+	Version            int              `json:"version"` // Schema version, see configMigrations
+	FolderPaths        []string         `json:"folderPaths"`
+	CurrentFolder      string           `json:"currentFolder"`
+	SelectedSandbox    string           `json:"selectedSandbox"`
+	AvailableSandboxes []string         `json:"availableSandboxes"`
+	SandboxBindings    []SandboxBinding `json:"sandboxBindings"`
+	LaunchProfiles     []LaunchProfile  `json:"launchProfiles"`
+	Favorites          []Favorite       `json:"favorites"`
+	SandboxiePath      string           `json:"sandboxiePath,omitempty"` // Start.exe or install folder, empty to auto-detect
+	ActiveWorkspace    string           `json:"activeWorkspace"`
+	Workspaces         []Workspace      `json:"workspaces"`
+	FileTypes          []FileType       `json:"fileTypes"`    // Launchable extensions and their launch templates
+	HideRules          []string         `json:"hideRules"`    // Glob patterns of file and folder names to hide
+	ShowAllFiles       bool             `json:"showAllFiles"` // List files of every type, not just FileTypes
 }
 
-// ConfigManager handles loading and saving configuration
+// ConfigManager handles loading and saving configuration. All access to
+// the config goes through mu; readers get copies, writers use Update.
 type ConfigManager struct {
+	mu         sync.RWMutex
 	configPath string
 	config     *Config
+	warnings   []string          // Problems found while loading, shown to the user once
+	diskHash   [sha256.Size]byte // Content of config.json as last loaded or saved
+	rejected   [sha256.Size]byte // External edit that failed validation
 }
 
 // NewConfigManager creates a new configuration manager
@@ -45,190 +66,305 @@ func getConfigDir() string {
 	return configDir
 }
 
-// Load loads configuration from disk
+// Load loads configuration from disk, migrating old versions and falling
+// back to the newest valid backup when the file is corrupted
 func (cm *ConfigManager) Load() error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	data, err := os.ReadFile(cm.configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Create initial config with empty folders
-			cm.config = &Config{
-				FolderPaths:        []string{},
-				CurrentFolder:      "",
-				SelectedSandbox:    "DefaultBox",
-				AvailableSandboxes: []string{"DefaultBox", "__ask__"},
-			}
+			cm.config = newDefaultConfig()
 			// Ensure default folders are added
-			cm.ensureDefaultFolders()
+			cm.ensureDefaultFolders(cm.config)
+			cm.ensureWorkspaces(cm.config)
 			return nil
 		}
 		return err
 	}
 
-	if err := json.Unmarshal(data, cm.config); err != nil {
-		return err
+	config, version, err := decodeConfig(data)
+	if err != nil {
+		config = cm.recoverConfig(err)
+		if config == nil {
+			config = newDefaultConfig()
+		}
+		cm.ensureDefaultSandboxes(config)
+		cm.ensureFileTypes(config)
+		cm.ensureDefaultFolders(config)
+		cm.ensureWorkspaces(config)
+		cm.config = config
+		return cm.saveLocked()
+	}
+	if version > currentConfigVersion {
+		cm.warnings = append(cm.warnings, fmt.Sprintf("配置文件版本 (%d) 比当前程序支持的版本 (%d) 更新，部分设置可能会丢失", version, currentConfigVersion))
 	}
 
 	// Ensure DefaultBox and __ask__ are always in the list
-	cm.ensureDefaultSandboxes()
+	cm.ensureDefaultSandboxes(config)
+	cm.ensureFileTypes(config)
 	// Ensure default folders are always in the list
-	cm.ensureDefaultFolders()
+	cm.ensureDefaultFolders(config)
+	cm.ensureWorkspaces(config)
+	cm.config = config
+	cm.diskHash = sha256.Sum256(data)
+
+	// The file parsed, so keep it as a known-good backup (before any migration rewrites it)
+	cm.backupConfig(data)
+	if version < currentConfigVersion {
+		return cm.saveLocked()
+	}
 	return nil
 }
 
-// Save saves configuration to disk
+// newDefaultConfig returns the configuration used on first start
+func newDefaultConfig() *Config {
+	return &Config{
+		Version:            currentConfigVersion,
+		FolderPaths:        []string{},
+		CurrentFolder:      "",
+		SelectedSandbox:    "DefaultBox",
+		AvailableSandboxes: []string{"DefaultBox", "__ask__"},
+		FileTypes:          append(slices.Clone(builtinFileTypes), defaultFileTypes...),
+		HideRules:          []string{},
+	}
+}
+
+// Save saves configuration to disk atomically
 func (cm *ConfigManager) Save() error {
-	data, err := json.MarshalIndent(cm.config, "", "  ")
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.saveLocked()
+}
+
+// saveLocked writes cm.config to disk; cm.mu must be held
+func (cm *ConfigManager) saveLocked() error {
+	cm.resolveConflictLocked()
+	return cm.writeLocked(cm.config)
+}
+
+// writeLocked writes config to disk, stamped with the current version,
+// and remembers what was written; cm.mu must be held
+func (cm *ConfigManager) writeLocked(config *Config) error {
+	config.Version = currentConfigVersion
+	config.syncActiveWorkspace()
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(cm.configPath, data, 0644)
+	if err := writeFileAtomic(cm.configPath, data, 0644); err != nil {
+		return err
+	}
+	cm.diskHash = sha256.Sum256(data)
+	return nil
+}
+
+// Update runs fn on a copy of the configuration and, if fn succeeds,
+// saves the copy and makes it current. When fn or the save fails, the
+// configuration is left unchanged. Edits made to config.json by other
+// programs since the last load are merged in before fn runs.
+func (cm *ConfigManager) Update(fn func(config *Config) error) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.resolveConflictLocked()
+	config := cm.config.clone()
+	if err := fn(config); err != nil {
+		return err
+	}
+	if err := cm.writeLocked(config); err != nil {
+		return err
+	}
+	cm.config = config
+	return nil
 }
 
-// GetConfig returns the current configuration
+// GetConfig returns a snapshot of the current configuration. Changing the
+// snapshot does not affect the manager; use Update for that.
 func (cm *ConfigManager) GetConfig() *Config {
-	return cm.config
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.config.clone()
 }
 
-// ensureDefaultSandboxes ensures DefaultBox and __ask__ are always in the list
-func (cm *ConfigManager) ensureDefaultSandboxes() {
-	// Ensure DefaultBox exists
-	hasDefaultBox := false
-	hasAsk := false
-
-	for _, s := range cm.config.AvailableSandboxes {
-		if s == "DefaultBox" {
-			hasDefaultBox = true
-		}
-		if s == "__ask__" {
-			hasAsk = true
+// readOnlyConfigKeys can be read with GetConfigValue but not set; the
+// workspace fields change through the workspace methods
+var readOnlyConfigKeys = []string{"version", "activeWorkspace", "workspaces"}
+
+// configField returns the field of config whose JSON name is key
+func configField(config *Config, key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name == key {
+			return v.Field(i), true
 		}
 	}
+	return reflect.Value{}, false
+}
 
-	if !hasDefaultBox {
-		cm.config.AvailableSandboxes = append([]string{"DefaultBox"}, cm.config.AvailableSandboxes...)
+// GetConfigValue returns one top-level config field, by its name in
+// config.json, as JSON. An empty key returns the whole config.
+func (cm *ConfigManager) GetConfigValue(key string) (json.RawMessage, error) {
+	config := cm.GetConfig()
+	if key == "" {
+		return json.Marshal(config)
 	}
-	if !hasAsk {
-		cm.config.AvailableSandboxes = append(cm.config.AvailableSandboxes, "__ask__")
+	field, ok := configField(config, key)
+	if !ok {
+		return nil, fmt.Errorf("unknown config key: %s", key)
 	}
+	return json.Marshal(field.Interface())
 }
 
-// ensureDefaultFolders ensures default folders (Start Menu and Desktop) are always in the list
-func (cm *ConfigManager) ensureDefaultFolders() {
-	// Get default folder paths
-	defaultFolders := cm.getDefaultFolderPaths()
+// SetConfigValue sets one top-level config field from JSON. A value that
+// isn't valid JSON is taken as a string, so plain names need no quotes.
+func (cm *ConfigManager) SetConfigValue(key string, value string) error {
+	if slices.Contains(readOnlyConfigKeys, key) {
+		return fmt.Errorf("config key is read-only: %s", key)
+	}
+	data := []byte(value)
+	if !json.Valid(data) {
+		data, _ = json.Marshal(value)
+	}
 
-	// Add any missing default folders
-	for _, defaultFolder := range defaultFolders {
-		found := false
-		for _, existingFolder := range cm.config.FolderPaths {
-			if existingFolder == defaultFolder {
-				found = true
-				break
-			}
+	return cm.Update(func(config *Config) error {
+		field, ok := configField(config, key)
+		if !ok {
+			return fmt.Errorf("unknown config key: %s", key)
 		}
-		if !found {
-			// Insert at beginning to keep default folders first
-			cm.config.FolderPaths = append([]string{defaultFolder}, cm.config.FolderPaths...)
+		parsed := reflect.New(field.Type())
+		if err := json.Unmarshal(data, parsed.Interface()); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
 		}
-	}
+		field.Set(parsed.Elem())
+
+		if err := validateConfig(config); err != nil {
+			return err
+		}
+		cm.ensureDefaultSandboxes(config)
+		cm.ensureFileTypes(config)
+		cm.ensureDefaultFolders(config)
+		return nil
+	})
+}
 
-	// Ensure we have a current folder if folders exist
-	if cm.config.CurrentFolder == "" && len(cm.config.FolderPaths) > 0 {
-		cm.config.CurrentFolder = cm.config.FolderPaths[0]
+// clone returns a deep copy of the configuration
+func (c *Config) clone() *Config {
+	copied := *c
+	copied.FolderPaths = slices.Clone(c.FolderPaths)
+	copied.AvailableSandboxes = slices.Clone(c.AvailableSandboxes)
+	copied.SandboxBindings = slices.Clone(c.SandboxBindings)
+	copied.Favorites = slices.Clone(c.Favorites)
+	copied.LaunchProfiles = slices.Clone(c.LaunchProfiles)
+	for i := range copied.LaunchProfiles {
+		copied.LaunchProfiles[i].Options = copied.LaunchProfiles[i].Options.clone()
+	}
+	copied.FileTypes = slices.Clone(c.FileTypes)
+	copied.HideRules = slices.Clone(c.HideRules)
+	copied.Workspaces = slices.Clone(c.Workspaces)
+	for i := range copied.Workspaces {
+		copied.Workspaces[i] = copied.Workspaces[i].clone()
 	}
+	return &copied
 }
 
-// getDefaultFolderPaths returns the list of default folder paths that should always exist
-func (cm *ConfigManager) getDefaultFolderPaths() []string {
-	var defaultFolders []string
+// ensureDefaultSandboxes ensures DefaultBox and __ask__ are always in the list
+func (cm *ConfigManager) ensureDefaultSandboxes(config *Config) {
+	// Ensure DefaultBox exists
+	hasDefaultBox := false
+	hasAsk := false
 
-	// Add Start Menu folder if it exists
-	programData := os.Getenv("PROGRAMDATA")
-	if programData != "" {
-		startMenuPath := filepath.Join(programData, "Microsoft", "Windows", "Start Menu", "Programs")
-		if _, err := os.Stat(startMenuPath); err == nil {
-			defaultFolders = append(defaultFolders, startMenuPath)
+	for _, s := range config.AvailableSandboxes {
+		if s == "DefaultBox" {
+			hasDefaultBox = true
 		}
-	}
-
-	// Add Desktop folder if it exists
-	userProfile := os.Getenv("USERPROFILE")
-	if userProfile != "" {
-		desktopPath := filepath.Join(userProfile, "Desktop")
-		if _, err := os.Stat(desktopPath); err == nil {
-			defaultFolders = append(defaultFolders, desktopPath)
+		if s == "__ask__" {
+			hasAsk = true
 		}
 	}
 
-	return defaultFolders
+	if !hasDefaultBox {
+		config.AvailableSandboxes = append([]string{"DefaultBox"}, config.AvailableSandboxes...)
+	}
+	if !hasAsk {
+		config.AvailableSandboxes = append(config.AvailableSandboxes, "__ask__")
+	}
 }
 
-// isDefaultFolder checks if a path is a default folder
-func (cm *ConfigManager) isDefaultFolder(path string) bool {
-	defaultFolders := cm.getDefaultFolderPaths()
-	for _, defaultFolder := range defaultFolders {
-		if path == defaultFolder {
-			return true
-		}
+// ensureDefaultFolders makes sure a folder is selected. The Start Menu
+// and Desktop are merged virtual folders, so they are never in FolderPaths.
+func (cm *ConfigManager) ensureDefaultFolders(config *Config) {
+	if config.CurrentFolder == "" {
+		config.CurrentFolder = VirtualStartMenu
 	}
-	return false
 }
 
 // AddFolderPath adds a folder path to the configuration
 func (cm *ConfigManager) AddFolderPath(path string) error {
-	for _, p := range cm.config.FolderPaths {
-		if p == path {
-			return nil // Already exists
+	return cm.Update(func(config *Config) error {
+		for _, p := range config.FolderPaths {
+			if p == path {
+				return nil // Already exists
+			}
 		}
-	}
-	cm.config.FolderPaths = append(cm.config.FolderPaths, path)
-	cm.config.CurrentFolder = path
-	return cm.Save()
+		config.FolderPaths = append(config.FolderPaths, path)
+		config.CurrentFolder = path
+		return nil
+	})
 }
 
 // RemoveFolderPath removes a folder path from the configuration
 func (cm *ConfigManager) RemoveFolderPath(path string) error {
-	// Don't allow removing default folders
-	if cm.isDefaultFolder(path) {
-		return nil
-	}
-
-	for i, p := range cm.config.FolderPaths {
-		if p == path {
-			cm.config.FolderPaths = append(cm.config.FolderPaths[:i], cm.config.FolderPaths[i+1:]...)
-			if cm.config.CurrentFolder == path && len(cm.config.FolderPaths) > 0 {
-				cm.config.CurrentFolder = cm.config.FolderPaths[0]
+	return cm.Update(func(config *Config) error {
+		for i, p := range config.FolderPaths {
+			if p == path {
+				config.FolderPaths = append(config.FolderPaths[:i], config.FolderPaths[i+1:]...)
+				if config.CurrentFolder == path {
+					config.CurrentFolder = VirtualStartMenu
+					if len(config.FolderPaths) > 0 {
+						config.CurrentFolder = config.FolderPaths[0]
+					}
+				}
+				return nil
 			}
-			return cm.Save()
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // SetCurrentFolder sets the current folder
 func (cm *ConfigManager) SetCurrentFolder(path string) error {
-	cm.config.CurrentFolder = path
-	return cm.Save()
+	return cm.Update(func(config *Config) error {
+		config.CurrentFolder = path
+		return nil
+	})
 }
 
 // SetSelectedSandbox sets the selected sandbox
 func (cm *ConfigManager) SetSelectedSandbox(sandbox string) error {
-	cm.config.SelectedSandbox = sandbox
-	return cm.Save()
+	return cm.Update(func(config *Config) error {
+		config.SelectedSandbox = sandbox
+		return nil
+	})
 }
 
 // AddAvailableSandbox adds a sandbox to the available sandboxes list
 func (cm *ConfigManager) AddAvailableSandbox(sandbox string) error {
-	// Check if sandbox already exists
-	for _, s := range cm.config.AvailableSandboxes {
-		if s == sandbox {
-			return nil // Already exists
+	return cm.Update(func(config *Config) error {
+		// Check if sandbox already exists
+		for _, s := range config.AvailableSandboxes {
+			if s == sandbox {
+				return nil // Already exists
+			}
 		}
-	}
 
-	cm.config.AvailableSandboxes = append(cm.config.AvailableSandboxes, sandbox)
-	return cm.Save()
+		config.AvailableSandboxes = append(config.AvailableSandboxes, sandbox)
+		return nil
+	})
 }
 
 // RemoveAvailableSandbox removes a sandbox from the available sandboxes list
@@ -238,20 +374,144 @@ func (cm *ConfigManager) RemoveAvailableSandbox(sandbox string) error {
 		return nil
 	}
 
-	newSandboxes := []string{}
-	for _, s := range cm.config.AvailableSandboxes {
-		if s != sandbox {
-			newSandboxes = append(newSandboxes, s)
+	return cm.Update(func(config *Config) error {
+		newSandboxes := []string{}
+		for _, s := range config.AvailableSandboxes {
+			if s != sandbox {
+				newSandboxes = append(newSandboxes, s)
+			}
 		}
-	}
 
-	cm.config.AvailableSandboxes = newSandboxes
-	return cm.Save()
+		config.AvailableSandboxes = newSandboxes
+		return nil
+	})
 }
 
 // GetAvailableSandboxes returns the list of available sandboxes
 func (cm *ConfigManager) GetAvailableSandboxes() []string {
-	return cm.config.AvailableSandboxes
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return slices.Clone(cm.config.AvailableSandboxes)
+}
+
+// SetSandboxBinding adds a binding, replacing any existing binding with the same pattern
+func (cm *ConfigManager) SetSandboxBinding(binding SandboxBinding) error {
+	if err := validateBinding(binding); err != nil {
+		return err
+	}
+
+	return cm.Update(func(config *Config) error {
+		for i, b := range config.SandboxBindings {
+			if b.MatchType == binding.MatchType && normalizeBindingPath(b.Pattern) == normalizeBindingPath(binding.Pattern) {
+				config.SandboxBindings[i] = binding
+				return nil
+			}
+		}
+
+		config.SandboxBindings = append(config.SandboxBindings, binding)
+		return nil
+	})
+}
+
+// RemoveSandboxBinding removes the binding with the given pattern
+func (cm *ConfigManager) RemoveSandboxBinding(pattern string) error {
+	return cm.Update(func(config *Config) error {
+		newBindings := []SandboxBinding{}
+		for _, b := range config.SandboxBindings {
+			if normalizeBindingPath(b.Pattern) != normalizeBindingPath(pattern) {
+				newBindings = append(newBindings, b)
+			}
+		}
+
+		config.SandboxBindings = newBindings
+		return nil
+	})
+}
+
+// GetSandboxBindings returns the configured sandbox bindings
+func (cm *ConfigManager) GetSandboxBindings() []SandboxBinding {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return slices.Clone(cm.config.SandboxBindings)
+}
+
+// BoundSandbox returns the sandbox explicitly chosen for a file: the
+// favorite's sandbox first, then the most specific binding
+func (cm *ConfigManager) BoundSandbox(filePath string) (string, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if i := findFavorite(cm.config.Favorites, filePath); i >= 0 && cm.config.Favorites[i].Sandbox != "" {
+		return cm.config.Favorites[i].Sandbox, true
+	}
+	return resolveBinding(cm.config.SandboxBindings, filePath)
+}
+
+// ResolveSandbox returns the sandbox a file should launch in: its bound
+// sandbox if any, otherwise the globally selected sandbox
+func (cm *ConfigManager) ResolveSandbox(filePath string) string {
+	if sandbox, ok := cm.BoundSandbox(filePath); ok {
+		return sandbox
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.config.SelectedSandbox
+}
+
+// SetLaunchProfile saves the launch options for a program
+func (cm *ConfigManager) SetLaunchProfile(path string, opts LaunchOptions) error {
+	if err := validateLaunchOptions(opts); err != nil {
+		return err
+	}
+
+	return cm.Update(func(config *Config) error {
+		for i, p := range config.LaunchProfiles {
+			if normalizeBindingPath(p.Path) == normalizeBindingPath(path) {
+				config.LaunchProfiles[i].Options = opts.clone()
+				return nil
+			}
+		}
+
+		config.LaunchProfiles = append(config.LaunchProfiles, LaunchProfile{Path: path, Options: opts.clone()})
+		return nil
+	})
+}
+
+// RemoveLaunchProfile removes the saved launch options for a program
+func (cm *ConfigManager) RemoveLaunchProfile(path string) error {
+	return cm.Update(func(config *Config) error {
+		newProfiles := []LaunchProfile{}
+		for _, p := range config.LaunchProfiles {
+			if normalizeBindingPath(p.Path) != normalizeBindingPath(path) {
+				newProfiles = append(newProfiles, p)
+			}
+		}
+
+		config.LaunchProfiles = newProfiles
+		return nil
+	})
+}
+
+// GetLaunchProfile returns the saved launch options for a program
+func (cm *ConfigManager) GetLaunchProfile(path string) (LaunchOptions, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	for _, p := range cm.config.LaunchProfiles {
+		if normalizeBindingPath(p.Path) == normalizeBindingPath(path) {
+			return p.Options.clone(), true
+		}
+	}
+	return LaunchOptions{}, false
+}
+
+// SetSandboxiePath sets the explicit Sandboxie location, empty to auto-detect
+func (cm *ConfigManager) SetSandboxiePath(path string) error {
+	return cm.Update(func(config *Config) error {
+		config.SandboxiePath = path
+		return nil
+	})
 }
 
 // GetConfigPath returns the path to the configuration file
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// newTestConfigManager returns a manager whose config.json lives in a
+// temporary folder
+func newTestConfigManager(t *testing.T) *ConfigManager {
+	t.Helper()
+	t.Setenv("APPDATA", t.TempDir())
+	return NewConfigManager()
+}
+
+// TestConfigManagerConcurrentUpdates hammers the mutating methods from
+// several goroutines; run with -race. Every update must survive, both in
+// memory and on disk.
+func TestConfigManagerConcurrentUpdates(t *testing.T) {
+	cm := newTestConfigManager(t)
+
+	const workers = 8
+	const perWorker = 6
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				path := fmt.Sprintf(`C:\Tools\w%d\f%d`, w, i)
+				if err := cm.AddFolderPath(path); err != nil {
+					t.Errorf("AddFolderPath: %v", err)
+					return
+				}
+				if err := cm.SetSelectedSandbox(fmt.Sprintf("Box%d", w)); err != nil {
+					t.Errorf("SetSelectedSandbox: %v", err)
+					return
+				}
+				// Odd folders are removed again
+				if i%2 == 1 {
+					if err := cm.RemoveFolderPath(path); err != nil {
+						t.Errorf("RemoveFolderPath: %v", err)
+						return
+					}
+				}
+				config := cm.GetConfig()
+				config.FolderPaths = append(config.FolderPaths, "scribble on the snapshot")
+			}
+		}(w)
+	}
+
+	// Readers run alongside the writers
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				config := cm.GetConfig()
+				if slices.Contains(config.FolderPaths, "scribble on the snapshot") {
+					t.Error("GetConfig snapshot shares memory with the manager")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	var want []string
+	for w := 0; w < workers; w++ {
+		for i := 0; i < perWorker; i += 2 {
+			want = append(want, fmt.Sprintf(`C:\Tools\w%d\f%d`, w, i))
+		}
+	}
+	slices.Sort(want)
+
+	check := func(name string, config *Config) {
+		t.Helper()
+		got := slices.Clone(config.FolderPaths)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: %d folders, want %d", name, len(got), len(want))
+		}
+		if !slices.Contains([]string{"Box0", "Box1", "Box2", "Box3", "Box4", "Box5", "Box6", "Box7"}, config.SelectedSandbox) {
+			t.Errorf("%s: selected sandbox = %q", name, config.SelectedSandbox)
+		}
+	}
+	check("memory", cm.GetConfig())
+	check("disk", NewConfigManager().GetConfig())
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const profileVersion = 1 // Format version of exported config files
+
+// Config sections that can be exported and imported
+const (
+	SectionFolders        = "folders"
+	SectionSandboxes      = "sandboxes"
+	SectionBindings       = "bindings"
+	SectionFavorites      = "favorites"
+	SectionLaunchProfiles = "launchProfiles"
+	SectionFileTypes      = "fileTypes"
+)
+
+// allConfigSections lists every shareable section in export order
+var allConfigSections = []string{SectionFolders, SectionSandboxes, SectionBindings, SectionFavorites, SectionLaunchProfiles, SectionFileTypes}
+
+// Import modes
+const (
+	ImportMerge   = "merge"   // Add new entries and update existing ones
+	ImportReplace = "replace" // Replace each section present in the file
+)
+
+// portableEnvVars are substituted into exported paths, most specific first
+var portableEnvVars = []string{"LOCALAPPDATA", "APPDATA", "USERPROFILE", "PROGRAMDATA", "ProgramFiles(x86)", "ProgramFiles", "PUBLIC", "SystemRoot"}
+
+// profileSectionKeys maps the JSON fields of a profile to their section,
+// for profiles that don't list their sections
+var profileSectionKeys = map[string]string{
+	"folderPaths":        SectionFolders,
+	"availableSandboxes": SectionSandboxes,
+	"sandboxBindings":    SectionBindings,
+	"favorites":          SectionFavorites,
+	"launchProfiles":     SectionLaunchProfiles,
+	"fileTypes":          SectionFileTypes,
+	"hideRules":          SectionFileTypes,
+	"showAllFiles":       SectionFileTypes,
+}
+
+// ConfigProfile is a shareable subset of the configuration. Paths below
+// well-known folders are stored as %VAR%\... so they work on other machines.
+type ConfigProfile struct {
+	Version            int              `json:"version"`
+	Sections           []string         `json:"sections"`
+	FolderPaths        []string         `json:"folderPaths,omitempty"`
+	AvailableSandboxes []string         `json:"availableSandboxes,omitempty"`
+	SandboxBindings    []SandboxBinding `json:"sandboxBindings,omitempty"`
+	Favorites          []Favorite       `json:"favorites,omitempty"`
+	LaunchProfiles     []LaunchProfile  `json:"launchProfiles,omitempty"`
+	FileTypes          []FileType       `json:"fileTypes,omitempty"`
+	HideRules          []string         `json:"hideRules,omitempty"`
+	ShowAllFiles       *bool            `json:"showAllFiles,omitempty"`
+}
+
+// ImportChange describes one change an import makes
+type ImportChange struct {
+	Section string `json:"section"`
+	Action  string `json:"action"` // "add", "remove" or "update"
+	Item    string `json:"item"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// normalizeSections validates section names; none means all sections
+func normalizeSections(sections []string) ([]string, error) {
+	if len(sections) == 0 {
+		return allConfigSections, nil
+	}
+
+	wanted := map[string]bool{}
+	for _, s := range sections {
+		known := false
+		for _, k := range allConfigSections {
+			if s == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown config section: %s", s)
+		}
+		wanted[s] = true
+	}
+
+	var result []string
+	for _, k := range allConfigSections {
+		if wanted[k] {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+// hasSection reports whether section is in sections
+func hasSection(sections []string, section string) bool {
+	for _, s := range sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// portablePath replaces a well-known folder prefix with its %VAR%
+func portablePath(path string) string {
+	for _, name := range portableEnvVars {
+		value := strings.TrimRight(os.Getenv(name), `\/`)
+		if value == "" || len(path) < len(value) || !strings.EqualFold(path[:len(value)], value) {
+			continue
+		}
+		rest := path[len(value):]
+		if rest == "" || rest[0] == '\\' || rest[0] == '/' {
+			return "%" + name + "%" + rest
+		}
+	}
+	return path
+}
+
+// exportProfile builds a profile with the given sections of config
+func exportProfile(config *Config, sections []string) *ConfigProfile {
+	profile := &ConfigProfile{Version: profileVersion, Sections: sections}
+
+	if hasSection(sections, SectionFolders) {
+		for _, p := range config.FolderPaths {
+			profile.FolderPaths = append(profile.FolderPaths, portablePath(p))
+		}
+	}
+	if hasSection(sections, SectionSandboxes) {
+		profile.AvailableSandboxes = append(profile.AvailableSandboxes, config.AvailableSandboxes...)
+	}
+	if hasSection(sections, SectionBindings) {
+		for _, b := range config.SandboxBindings {
+			b.Pattern = portablePath(b.Pattern)
+			profile.SandboxBindings = append(profile.SandboxBindings, b)
+		}
+	}
+	if hasSection(sections, SectionFavorites) {
+		for _, f := range config.Favorites {
+			f.Path = portablePath(f.Path)
+			profile.Favorites = append(profile.Favorites, f)
+		}
+	}
+	if hasSection(sections, SectionLaunchProfiles) {
+		for _, p := range config.LaunchProfiles {
+			opts := p.Options.clone()
+			opts.WorkingDir = portablePath(opts.WorkingDir)
+			profile.LaunchProfiles = append(profile.LaunchProfiles, LaunchProfile{Path: portablePath(p.Path), Options: opts})
+		}
+	}
+	if hasSection(sections, SectionFileTypes) {
+		profile.FileTypes = append(profile.FileTypes, config.FileTypes...)
+		profile.HideRules = append(profile.HideRules, config.HideRules...)
+		showAll := config.ShowAllFiles
+		profile.ShowAllFiles = &showAll
+	}
+	return profile
+}
+
+// readProfile loads an exported profile and expands its path variables
+func readProfile(path string) (*ConfigProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %v", err)
+	}
+
+	profile := &ConfigProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("invalid profile: %v", err)
+	}
+	if profile.Version > profileVersion {
+		return nil, fmt.Errorf("profile version %d is newer than supported version %d", profile.Version, profileVersion)
+	}
+	sections := profile.Sections
+	if len(sections) == 0 {
+		// A hand-written profile may leave out the list; it then holds
+		// only the sections it has fields for, so a replace doesn't clear
+		// the others
+		if sections, err = presentSections(data); err != nil {
+			return nil, err
+		}
+		if len(sections) == 0 {
+			return nil, fmt.Errorf("profile contains no config sections")
+		}
+	}
+	if sections, err = normalizeSections(sections); err != nil {
+		return nil, err
+	}
+	profile.Sections = sections
+
+	for i := range profile.FolderPaths {
+		profile.FolderPaths[i] = expandWindowsEnv(profile.FolderPaths[i])
+	}
+	for i := range profile.SandboxBindings {
+		profile.SandboxBindings[i].Pattern = expandWindowsEnv(profile.SandboxBindings[i].Pattern)
+	}
+	for i := range profile.Favorites {
+		profile.Favorites[i].Path = expandWindowsEnv(profile.Favorites[i].Path)
+	}
+	for i := range profile.LaunchProfiles {
+		profile.LaunchProfiles[i].Path = expandWindowsEnv(profile.LaunchProfiles[i].Path)
+		profile.LaunchProfiles[i].Options.WorkingDir = expandWindowsEnv(profile.LaunchProfiles[i].Options.WorkingDir)
+	}
+	return profile, nil
+}
+
+// presentSections returns the sections a profile has fields for
+func presentSections(data []byte) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid profile: %v", err)
+	}
+
+	var sections []string
+	for key := range fields {
+		if section, ok := profileSectionKeys[key]; ok && !hasSection(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// applyProfile merges or replaces the profile's sections into config
+func applyProfile(config *Config, profile *ConfigProfile, mode string) error {
+	switch mode {
+	case ImportMerge, ImportReplace:
+	default:
+		return fmt.Errorf("unknown import mode: %s", mode)
+	}
+	replace := mode == ImportReplace
+
+	if hasSection(profile.Sections, SectionFolders) {
+		if replace {
+			config.FolderPaths = []string{}
+		}
+		for _, p := range profile.FolderPaths {
+			if !containsFold(config.FolderPaths, p) {
+				config.FolderPaths = append(config.FolderPaths, p)
+			}
+		}
+		if !containsFold(config.FolderPaths, config.CurrentFolder) && !isVirtualFolder(config.CurrentFolder) {
+			config.CurrentFolder = ""
+		}
+	}
+
+	if hasSection(profile.Sections, SectionSandboxes) {
+		if replace {
+			config.AvailableSandboxes = []string{}
+		}
+		for _, s := range profile.AvailableSandboxes {
+			if !containsFold(config.AvailableSandboxes, s) {
+				config.AvailableSandboxes = append(config.AvailableSandboxes, s)
+			}
+		}
+	}
+
+	if hasSection(profile.Sections, SectionBindings) {
+		if replace {
+			config.SandboxBindings = []SandboxBinding{}
+		}
+	nextBinding:
+		for _, binding := range profile.SandboxBindings {
+			for i, b := range config.SandboxBindings {
+				if b.MatchType == binding.MatchType && normalizeBindingPath(b.Pattern) == normalizeBindingPath(binding.Pattern) {
+					config.SandboxBindings[i] = binding
+					continue nextBinding
+				}
+			}
+			config.SandboxBindings = append(config.SandboxBindings, binding)
+		}
+	}
+
+	if hasSection(profile.Sections, SectionFavorites) {
+		if replace {
+			config.Favorites = []Favorite{}
+		}
+		for _, f := range profile.Favorites {
+			if i := findFavorite(config.Favorites, f.Path); i >= 0 {
+				config.Favorites[i] = f
+			} else {
+				config.Favorites = append(config.Favorites, f)
+			}
+		}
+	}
+
+	if hasSection(profile.Sections, SectionLaunchProfiles) {
+		if replace {
+			config.LaunchProfiles = []LaunchProfile{}
+		}
+	nextProfile:
+		for _, lp := range profile.LaunchProfiles {
+			for i, p := range config.LaunchProfiles {
+				if normalizeBindingPath(p.Path) == normalizeBindingPath(lp.Path) {
+					config.LaunchProfiles[i] = lp
+					continue nextProfile
+				}
+			}
+			config.LaunchProfiles = append(config.LaunchProfiles, lp)
+		}
+	}
+
+	if hasSection(profile.Sections, SectionFileTypes) {
+		if replace {
+			config.FileTypes = []FileType{}
+			config.HideRules = []string{}
+		}
+		for _, t := range profile.FileTypes {
+			if i := findFileType(config.FileTypes, t.Extension); i >= 0 {
+				config.FileTypes[i] = t
+			} else {
+				config.FileTypes = append(config.FileTypes, t)
+			}
+		}
+		for _, rule := range profile.HideRules {
+			if !containsFold(config.HideRules, rule) {
+				config.HideRules = append(config.HideRules, rule)
+			}
+		}
+		if profile.ShowAllFiles != nil {
+			config.ShowAllFiles = *profile.ShowAllFiles
+		}
+	}
+
+	return validateConfig(config)
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffItem is one entry of a section, keyed for comparison
+type diffItem struct {
+	key   string
+	label string
+	value string
+}
+
+// diffSection compares the entries of one section before and after an import
+func diffSection(section string, before, after []diffItem) []ImportChange {
+	old := map[string]diffItem{}
+	for _, item := range before {
+		old[item.key] = item
+	}
+	seen := map[string]bool{}
+
+	changes := []ImportChange{}
+	for _, item := range after {
+		seen[item.key] = true
+		prev, ok := old[item.key]
+		switch {
+		case !ok:
+			changes = append(changes, ImportChange{Section: section, Action: "add", Item: item.label, To: item.value})
+		case prev.value != item.value:
+			changes = append(changes, ImportChange{Section: section, Action: "update", Item: item.label, From: prev.value, To: item.value})
+		}
+	}
+	for _, item := range before {
+		if !seen[item.key] {
+			changes = append(changes, ImportChange{Section: section, Action: "remove", Item: item.label, From: item.value})
+		}
+	}
+	return changes
+}
+
+// diffConfigs lists what changed between two configs in the given sections
+func diffConfigs(before, after *Config, sections []string) []ImportChange {
+	strs := func(list []string) []diffItem {
+		items := []diffItem{}
+		for _, s := range list {
+			items = append(items, diffItem{key: strings.ToLower(s), label: s})
+		}
+		return items
+	}
+	bindings := func(list []SandboxBinding) []diffItem {
+		items := []diffItem{}
+		for _, b := range list {
+			items = append(items, diffItem{key: b.MatchType + ":" + normalizeBindingPath(b.Pattern), label: b.Pattern, value: b.Sandbox})
+		}
+		return items
+	}
+	favorites := func(list []Favorite) []diffItem {
+		items := []diffItem{}
+		for _, f := range list {
+			items = append(items, diffItem{key: normalizeBindingPath(f.Path), label: f.Path, value: strings.TrimSpace(f.Name + " " + f.Sandbox)})
+		}
+		return items
+	}
+	profiles := func(list []LaunchProfile) []diffItem {
+		items := []diffItem{}
+		for _, p := range list {
+			opts, _ := json.Marshal(p.Options)
+			items = append(items, diffItem{key: normalizeBindingPath(p.Path), label: p.Path, value: string(opts)})
+		}
+		return items
+	}
+
+	fileTypes := func(c *Config) []diffItem {
+		items := []diffItem{}
+		for _, t := range c.FileTypes {
+			items = append(items, diffItem{key: "type:" + normalizeExtension(t.Extension), label: t.Extension, value: t.Launch})
+		}
+		for _, rule := range c.HideRules {
+			items = append(items, diffItem{key: "hide:" + strings.ToLower(rule), label: rule})
+		}
+		return append(items, diffItem{key: "showAllFiles", label: "showAllFiles", value: fmt.Sprint(c.ShowAllFiles)})
+	}
+
+	changes := []ImportChange{}
+	for _, section := range sections {
+		switch section {
+		case SectionFolders:
+			changes = append(changes, diffSection(section, strs(before.FolderPaths), strs(after.FolderPaths))...)
+		case SectionSandboxes:
+			changes = append(changes, diffSection(section, strs(before.AvailableSandboxes), strs(after.AvailableSandboxes))...)
+		case SectionBindings:
+			changes = append(changes, diffSection(section, bindings(before.SandboxBindings), bindings(after.SandboxBindings))...)
+		case SectionFavorites:
+			changes = append(changes, diffSection(section, favorites(before.Favorites), favorites(after.Favorites))...)
+		case SectionLaunchProfiles:
+			changes = append(changes, diffSection(section, profiles(before.LaunchProfiles), profiles(after.LaunchProfiles))...)
+		case SectionFileTypes:
+			changes = append(changes, diffSection(section, fileTypes(before), fileTypes(after))...)
+		}
+	}
+	return changes
+}
+
+// ExportConfig writes the given sections (all when empty) to path
+func (cm *ConfigManager) ExportConfig(path string, sections []string) error {
+	sections, err := normalizeSections(sections)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(exportProfile(cm.GetConfig(), sections), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// importedConfig returns config with the profile applied
+func (cm *ConfigManager) importedConfig(config *Config, profile *ConfigProfile, mode string) (*Config, error) {
+	imported := config.clone()
+	if err := applyProfile(imported, profile, mode); err != nil {
+		return nil, err
+	}
+	// DefaultBox, __ask__ and the default folders survive a replace
+	cm.ensureDefaultSandboxes(imported)
+	cm.ensureFileTypes(imported)
+	cm.ensureDefaultFolders(imported)
+	cm.ensureWorkspaces(imported)
+	return imported, nil
+}
+
+// PreviewImport returns the changes ImportConfig would make, without
+// changing anything
+func (cm *ConfigManager) PreviewImport(path string, mode string) ([]ImportChange, error) {
+	profile, err := readProfile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := cm.GetConfig()
+	imported, err := cm.importedConfig(config, profile, mode)
+	if err != nil {
+		return nil, err
+	}
+	return diffConfigs(config, imported, profile.Sections), nil
+}
+
+// ImportConfig applies an exported profile and returns what changed
+func (cm *ConfigManager) ImportConfig(path string, mode string) ([]ImportChange, error) {
+	profile, err := readProfile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ImportChange
+	err = cm.Update(func(config *Config) error {
+		imported, err := cm.importedConfig(config, profile, mode)
+		if err != nil {
+			return err
+		}
+		changes = diffConfigs(config, imported, profile.Sections)
+		*config = *imported
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setPortableEnv points the well-known folders at fixed Windows paths
+func setPortableEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range portableEnvVars {
+		t.Setenv(name, "")
+	}
+	t.Setenv("USERPROFILE", `C:\Users\Ann`)
+	t.Setenv("APPDATA", `C:\Users\Ann\AppData\Roaming`)
+	t.Setenv("LOCALAPPDATA", `C:\Users\Ann\AppData\Local`)
+	t.Setenv("PROGRAMDATA", `C:\ProgramData`)
+}
+
+// writeProfile writes a profile file and returns its path
+func writeProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profile.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPortablePathRoundTrip(t *testing.T) {
+	setPortableEnv(t)
+
+	tests := []struct {
+		path     string
+		portable string
+	}{
+		{`C:\Users\Ann\Desktop\app.lnk`, `%USERPROFILE%\Desktop\app.lnk`},
+		{`C:\Users\Ann\AppData\Local\Programs\Code\Code.exe`, `%LOCALAPPDATA%\Programs\Code\Code.exe`},
+		{`C:\Users\Ann\AppData\Roaming\Microsoft`, `%APPDATA%\Microsoft`},
+		{`C:\ProgramData`, `%PROGRAMDATA%`},
+		{`C:\ProgramData/Tools`, `%PROGRAMDATA%/Tools`},
+		{`D:\Tools\app.exe`, `D:\Tools\app.exe`},
+		{`C:\Users\Anna\app.exe`, `C:\Users\Anna\app.exe`}, // Not below C:\Users\Ann
+		{``, ``},
+	}
+	for _, tt := range tests {
+		portable := portablePath(tt.path)
+		if portable != tt.portable {
+			t.Errorf("portablePath(%q) = %q, want %q", tt.path, portable, tt.portable)
+		}
+		if back := expandWindowsEnv(portable); back != tt.path {
+			t.Errorf("expandWindowsEnv(%q) = %q, want %q", portable, back, tt.path)
+		}
+	}
+
+	// The prefix is matched regardless of case
+	if got := portablePath(`c:\users\ann\Desktop`); got != `%USERPROFILE%\Desktop` {
+		t.Errorf("portablePath with other case = %q", got)
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	setPortableEnv(t)
+
+	path := writeProfile(t, `{
+		"version": 1,
+		"sections": ["favorites", "folders"],
+		"folderPaths": ["%USERPROFILE%\\Desktop", "D:\\Tools"],
+		"favorites": [{"path": "%APPDATA%\\app.lnk"}],
+		"sandboxBindings": [{"pattern": "*.exe", "matchType": "glob", "sandbox": "Box"}]
+	}`)
+	profile, err := readProfile(path)
+	if err != nil {
+		t.Fatalf("readProfile: %v", err)
+	}
+	if want := []string{SectionFolders, SectionFavorites}; !slices.Equal(profile.Sections, want) {
+		t.Errorf("Sections = %q, want %q", profile.Sections, want)
+	}
+	if want := []string{`C:\Users\Ann\Desktop`, `D:\Tools`}; !slices.Equal(profile.FolderPaths, want) {
+		t.Errorf("FolderPaths = %q, want %q", profile.FolderPaths, want)
+	}
+	if got := profile.Favorites[0].Path; got != `C:\Users\Ann\AppData\Roaming\app.lnk` {
+		t.Errorf("favorite path = %q", got)
+	}
+}
+
+func TestReadProfileWithoutSections(t *testing.T) {
+	path := writeProfile(t, `{
+		"version": 1,
+		"favorites": [{"path": "C:\\app.lnk"}],
+		"hideRules": ["Uninstall*"]
+	}`)
+	profile, err := readProfile(path)
+	if err != nil {
+		t.Fatalf("readProfile: %v", err)
+	}
+	if want := []string{SectionFavorites, SectionFileTypes}; !slices.Equal(profile.Sections, want) {
+		t.Errorf("Sections = %q, want only the sections present, %q", profile.Sections, want)
+	}
+}
+
+func TestReadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid JSON", `{`, "invalid profile"},
+		{"newer version", `{"version": 2, "sections": ["folders"]}`, "newer than supported"},
+		{"unknown section", `{"version": 1, "sections": ["passwords"]}`, "unknown config section"},
+		{"no sections", `{"version": 1}`, "no config sections"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readProfile(writeProfile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readProfile error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// testShareConfig returns a config with an entry in every section
+func testShareConfig() *Config {
+	return &Config{
+		FolderPaths:        []string{`C:\Tools`},
+		CurrentFolder:      `C:\Tools`,
+		AvailableSandboxes: []string{"DefaultBox", "Work"},
+		SandboxBindings:    []SandboxBinding{{Pattern: `C:\Tools\a.exe`, MatchType: BindingExact, Sandbox: "Work"}},
+		Favorites:          []Favorite{{Path: `C:\Tools\a.exe`, Name: "A"}},
+		LaunchProfiles:     []LaunchProfile{{Path: `C:\Tools\a.exe`, Options: LaunchOptions{Args: []string{"-x"}}}},
+		FileTypes:          []FileType{{Extension: ".exe"}},
+		HideRules:          []string{"Uninstall*"},
+	}
+}
+
+func TestApplyProfileMerge(t *testing.T) {
+	config := testShareConfig()
+	showAll := true
+	profile := &ConfigProfile{
+		Sections:           allConfigSections,
+		FolderPaths:        []string{`c:\tools`, `D:\Games`},
+		AvailableSandboxes: []string{"work", "Games"},
+		SandboxBindings:    []SandboxBinding{{Pattern: `c:\tools\A.exe`, MatchType: BindingExact, Sandbox: "Games"}},
+		Favorites:          []Favorite{{Path: `D:\Games\g.exe`}},
+		FileTypes:          []FileType{{Extension: ".exe", Launch: "{path}"}, {Extension: ".ps1", Launch: "powershell -File {path}"}},
+		HideRules:          []string{"uninstall*", "Readme*"},
+		ShowAllFiles:       &showAll,
+	}
+	if err := applyProfile(config, profile, ImportMerge); err != nil {
+		t.Fatalf("applyProfile: %v", err)
+	}
+
+	if want := []string{`C:\Tools`, `D:\Games`}; !slices.Equal(config.FolderPaths, want) {
+		t.Errorf("FolderPaths = %q, want %q", config.FolderPaths, want)
+	}
+	if want := []string{"DefaultBox", "Work", "Games"}; !slices.Equal(config.AvailableSandboxes, want) {
+		t.Errorf("AvailableSandboxes = %q, want %q", config.AvailableSandboxes, want)
+	}
+	if len(config.SandboxBindings) != 1 || config.SandboxBindings[0].Sandbox != "Games" {
+		t.Errorf("SandboxBindings = %+v, want the existing binding updated", config.SandboxBindings)
+	}
+	if len(config.Favorites) != 2 {
+		t.Errorf("Favorites = %+v, want both", config.Favorites)
+	}
+	if len(config.LaunchProfiles) != 1 {
+		t.Errorf("LaunchProfiles = %+v, want the existing one kept", config.LaunchProfiles)
+	}
+	if len(config.FileTypes) != 2 || config.FileTypes[0].Launch != "{path}" {
+		t.Errorf("FileTypes = %+v", config.FileTypes)
+	}
+	if want := []string{"Uninstall*", "Readme*"}; !slices.Equal(config.HideRules, want) {
+		t.Errorf("HideRules = %q, want %q", config.HideRules, want)
+	}
+	if !config.ShowAllFiles {
+		t.Error("ShowAllFiles not imported")
+	}
+	if config.CurrentFolder != `C:\Tools` {
+		t.Errorf("CurrentFolder = %q, want it kept", config.CurrentFolder)
+	}
+}
+
+func TestApplyProfileReplace(t *testing.T) {
+	config := testShareConfig()
+	profile := &ConfigProfile{
+		Sections:    []string{SectionFolders, SectionFavorites},
+		FolderPaths: []string{`D:\Games`},
+	}
+	if err := applyProfile(config, profile, ImportReplace); err != nil {
+		t.Fatalf("applyProfile: %v", err)
+	}
+
+	if want := []string{`D:\Games`}; !slices.Equal(config.FolderPaths, want) {
+		t.Errorf("FolderPaths = %q, want %q", config.FolderPaths, want)
+	}
+	if config.CurrentFolder != "" {
+		t.Errorf("CurrentFolder = %q, want it cleared with its folder", config.CurrentFolder)
+	}
+	if len(config.Favorites) != 0 {
+		t.Errorf("Favorites = %+v, want the listed section cleared", config.Favorites)
+	}
+
+	// Sections the profile doesn't hold are left alone
+	original := testShareConfig()
+	if !slices.Equal(config.AvailableSandboxes, original.AvailableSandboxes) ||
+		len(config.SandboxBindings) != 1 || len(config.LaunchProfiles) != 1 ||
+		len(config.FileTypes) != 1 || len(config.HideRules) != 1 {
+		t.Errorf("replace changed sections not in the profile: %+v", config)
+	}
+}
+
+func TestImportReplaceWithoutSections(t *testing.T) {
+	path := writeProfile(t, `{"version": 1, "folderPaths": ["D:\\Games"]}`)
+	profile, err := readProfile(path)
+	if err != nil {
+		t.Fatalf("readProfile: %v", err)
+	}
+
+	config := testShareConfig()
+	if err := applyProfile(config, profile, ImportReplace); err != nil {
+		t.Fatalf("applyProfile: %v", err)
+	}
+	if want := []string{`D:\Games`}; !slices.Equal(config.FolderPaths, want) {
+		t.Errorf("FolderPaths = %q, want %q", config.FolderPaths, want)
+	}
+	if len(config.SandboxBindings) != 1 || len(config.Favorites) != 1 || len(config.LaunchProfiles) != 1 || len(config.FileTypes) != 1 {
+		t.Errorf("replace cleared sections missing from the file: %+v", config)
+	}
+}
+
+func TestApplyProfileErrors(t *testing.T) {
+	if err := applyProfile(testShareConfig(), &ConfigProfile{}, "overwrite"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+
+	// An invalid binding is rejected by validation
+	profile := &ConfigProfile{
+		Sections:        []string{SectionBindings},
+		SandboxBindings: []SandboxBinding{{Pattern: `C:\a.exe`, MatchType: "regex", Sandbox: "Box"}},
+	}
+	if err := applyProfile(testShareConfig(), profile, ImportMerge); err == nil {
+		t.Error("invalid binding accepted")
+	}
+}
+
+func TestDiffConfigs(t *testing.T) {
+	before := testShareConfig()
+	after := before.clone()
+	after.FolderPaths = []string{`D:\Games`}
+	after.SandboxBindings[0].Sandbox = "Games"
+	after.AvailableSandboxes = append(after.AvailableSandboxes, "Games")
+	after.ShowAllFiles = true
+
+	// The sandboxes section wasn't asked for
+	changes := diffConfigs(before, after, []string{SectionFolders, SectionBindings, SectionFileTypes})
+	want := []ImportChange{
+		{Section: SectionFolders, Action: "add", Item: `D:\Games`},
+		{Section: SectionFolders, Action: "remove", Item: `C:\Tools`},
+		{Section: SectionBindings, Action: "update", Item: `C:\Tools\a.exe`, From: "Work", To: "Games"},
+		{Section: SectionFileTypes, Action: "update", Item: "showAllFiles", From: "false", To: "true"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("diffConfigs =\n%+v\nwant\n%+v", changes, want)
+	}
+
+	if changes := diffConfigs(before, before.clone(), allConfigSections); len(changes) != 0 {
+		t.Errorf("diffConfigs of equal configs = %+v", changes)
+	}
+}
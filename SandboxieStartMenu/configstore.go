@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	currentConfigVersion = 4 // Bump together with a new entry in configMigrations
+	maxConfigBackups     = 5 // Number of known-good configs kept in the backups folder
+	configBackupPrefix   = "config-"
+	configBackupLayout   = "20060102-150405.000000" // Sorts chronologically
+)
+
+// configMigration upgrades a raw config by one version
+type configMigration func(raw map[string]interface{}) error
+
+// configMigrations[i] upgrades a config from version i to i+1
+var configMigrations = []configMigration{
+	migrateConfigV0,
+	migrateConfigV1,
+	migrateConfigV2,
+	migrateConfigV3,
+}
+
+// migrateConfigV0 upgrades configs written before the version field existed
+func migrateConfigV0(raw map[string]interface{}) error {
+	// Early bindings had no match type and were always exact paths
+	if bindings, ok := raw["sandboxBindings"].([]interface{}); ok {
+		for _, b := range bindings {
+			if binding, ok := b.(map[string]interface{}); ok {
+				if mt, _ := binding["matchType"].(string); mt == "" {
+					binding["matchType"] = BindingExact
+				}
+			}
+		}
+	}
+
+	// Hand-edited configs sometimes list the same box twice
+	if boxes, ok := raw["availableSandboxes"].([]interface{}); ok {
+		seen := map[string]bool{}
+		unique := []interface{}{}
+		for _, b := range boxes {
+			name, _ := b.(string)
+			if name == "" || seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			unique = append(unique, name)
+		}
+		raw["availableSandboxes"] = unique
+	}
+	return nil
+}
+
+// migrateConfigV1 moves the single set of folders, box and bindings into
+// a default workspace
+func migrateConfigV1(raw map[string]interface{}) error {
+	if _, ok := raw["workspaces"]; ok {
+		return nil
+	}
+
+	workspace := map[string]interface{}{"name": defaultWorkspaceName}
+	for _, key := range []string{"folderPaths", "currentFolder", "selectedSandbox", "sandboxBindings"} {
+		if value, ok := raw[key]; ok {
+			workspace[key] = value
+		}
+	}
+	raw["workspaces"] = []interface{}{workspace}
+	raw["activeWorkspace"] = defaultWorkspaceName
+	return nil
+}
+
+// migrateConfigV2 drops the Start Menu and Desktop folders that used to be
+// added to every config; they are merged virtual folders now
+func migrateConfigV2(raw map[string]interface{}) error {
+	legacy := legacyDefaultFolders()
+	migrateFolders := func(m map[string]interface{}) {
+		if folders, ok := m["folderPaths"].([]interface{}); ok {
+			kept := []interface{}{}
+			for _, f := range folders {
+				if path, _ := f.(string); path != "" {
+					if _, isLegacy := legacy[path]; isLegacy {
+						continue
+					}
+				}
+				kept = append(kept, f)
+			}
+			m["folderPaths"] = kept
+		}
+		if current, _ := m["currentFolder"].(string); current != "" {
+			if merged, ok := mergedPathForLegacy(legacy, current); ok {
+				m["currentFolder"] = merged
+			}
+		}
+	}
+
+	migrateFolders(raw)
+	if workspaces, ok := raw["workspaces"].([]interface{}); ok {
+		for _, w := range workspaces {
+			if workspace, ok := w.(map[string]interface{}); ok {
+				migrateFolders(workspace)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateConfigV3 registers the document and Internet Shortcut types
+// added in version 4, keeping the types the user already configured
+func migrateConfigV3(raw map[string]interface{}) error {
+	types, _ := raw["fileTypes"].([]interface{})
+	registered := make(map[string]bool)
+	for _, t := range types {
+		if fileType, ok := t.(map[string]interface{}); ok {
+			ext, _ := fileType["extension"].(string)
+			registered[normalizeExtension(ext)] = true
+		}
+	}
+	for _, t := range defaultFileTypes {
+		if !registered[t.Extension] {
+			types = append(types, map[string]interface{}{"extension": t.Extension, "launch": t.Launch})
+		}
+	}
+	raw["fileTypes"] = types
+	return nil
+}
+
+// decodeConfig parses config data and migrates it to the current version.
+// It also returns the version the data was written with.
+func decodeConfig(data []byte) (*Config, int, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	if raw == nil {
+		return nil, 0, fmt.Errorf("config is empty")
+	}
+
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version < 0 {
+		return nil, version, fmt.Errorf("invalid config version: %d", version)
+	}
+
+	for v := version; v < currentConfigVersion; v++ {
+		if err := configMigrations[v](raw); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config from version %d: %v", v, err)
+		}
+	}
+	if version < currentConfigVersion {
+		raw["version"] = currentConfigVersion
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, version, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(migrated, config); err != nil {
+		return nil, version, err
+	}
+	return config, version, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so a crash never leaves a half-written file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// configBackupDir returns the folder holding config backups
+func (cm *ConfigManager) configBackupDir() string {
+	return filepath.Join(filepath.Dir(cm.configPath), "backups")
+}
+
+// listConfigBackups returns the backup files, newest first
+func (cm *ConfigManager) listConfigBackups() []string {
+	entries, err := os.ReadDir(cm.configBackupDir())
+	if err != nil {
+		return nil
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, configBackupPrefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		backups = append(backups, filepath.Join(cm.configBackupDir(), name))
+	}
+
+	// Timestamped names sort chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups
+}
+
+// backupConfig stores data as the newest backup unless it matches the
+// latest one, then drops backups beyond maxConfigBackups
+func (cm *ConfigManager) backupConfig(data []byte) error {
+	backups := cm.listConfigBackups()
+	if len(backups) > 0 {
+		if latest, err := os.ReadFile(backups[0]); err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(cm.configBackupDir(), 0755); err != nil {
+		return err
+	}
+	path := cm.newConfigBackupPath(time.Now())
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+
+	backups = cm.listConfigBackups()
+	for i := maxConfigBackups; i < len(backups); i++ {
+		os.Remove(backups[i])
+	}
+	return nil
+}
+
+// newConfigBackupPath returns an unused backup path for a backup taken at
+// now. Saves within the same microsecond get later stamps, keeping the
+// names unique and in order.
+func (cm *ConfigManager) newConfigBackupPath(now time.Time) string {
+	for {
+		path := filepath.Join(cm.configBackupDir(), configBackupPrefix+now.Format(configBackupLayout)+".json")
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		now = now.Add(time.Microsecond)
+	}
+}
+
+// recoverConfig is called when config.json cannot be parsed. The broken
+// file is kept aside for inspection and the newest valid backup is loaded.
+// cm.mu must be held.
+func (cm *ConfigManager) recoverConfig(parseErr error) *Config {
+	brokenPath := cm.configPath + ".corrupt-" + time.Now().Format(configBackupLayout)
+	if err := os.Rename(cm.configPath, brokenPath); err != nil {
+		brokenPath = cm.configPath
+	}
+
+	for _, backup := range cm.listConfigBackups() {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+		config, _, err := decodeConfig(data)
+		if err != nil {
+			continue
+		}
+		cm.warnings = append(cm.warnings, fmt.Sprintf("配置文件已损坏 (%v)，已从备份 %s 恢复。损坏的文件保存为 %s",
+			parseErr, filepath.Base(backup), filepath.Base(brokenPath)))
+		return config
+	}
+
+	cm.warnings = append(cm.warnings, fmt.Sprintf("配置文件已损坏 (%v) 且没有可用的备份，已恢复默认配置。损坏的文件保存为 %s",
+		parseErr, filepath.Base(brokenPath)))
+	return nil
+}
+
+// validateConfig checks an externally edited config before it is used
+func validateConfig(config *Config) error {
+	for _, path := range config.FolderPaths {
+		if path == "" {
+			return fmt.Errorf("folder path is empty")
+		}
+	}
+	for _, b := range config.SandboxBindings {
+		if err := validateBinding(b); err != nil {
+			return err
+		}
+	}
+	for _, p := range config.LaunchProfiles {
+		if p.Path == "" {
+			return fmt.Errorf("launch profile path is empty")
+		}
+		if err := validateLaunchOptions(p.Options); err != nil {
+			return fmt.Errorf("launch profile %s: %v", p.Path, err)
+		}
+	}
+	for _, f := range config.Favorites {
+		if f.Path == "" {
+			return fmt.Errorf("favorite path is empty")
+		}
+	}
+	if err := validateFileTypes(config.FileTypes, config.HideRules); err != nil {
+		return err
+	}
+	for i, w := range config.Workspaces {
+		if strings.TrimSpace(w.Name) == "" {
+			return fmt.Errorf("workspace name is empty")
+		}
+		if findWorkspace(config.Workspaces, w.Name) != i {
+			return fmt.Errorf("duplicate workspace: %s", w.Name)
+		}
+		for _, b := range w.SandboxBindings {
+			if err := validateBinding(b); err != nil {
+				return fmt.Errorf("workspace %s: %v", w.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readExternalConfig reads config.json and reports whether another
+// program changed it since it was last loaded or saved; cm.mu must be held
+func (cm *ConfigManager) readExternalConfig() ([]byte, bool, error) {
+	data, err := os.ReadFile(cm.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return data, sha256.Sum256(data) != cm.diskHash, nil
+}
+
+// applyExternalConfig validates external config data and makes it
+// current; cm.mu must be held
+func (cm *ConfigManager) applyExternalConfig(data []byte) error {
+	config, version, err := decodeConfig(data)
+	if err == nil && version > currentConfigVersion {
+		err = fmt.Errorf("config version %d is newer than supported version %d", version, currentConfigVersion)
+	}
+	if err == nil {
+		err = validateConfig(config)
+	}
+	if err != nil {
+		cm.rejected = sha256.Sum256(data)
+		return err
+	}
+
+	cm.ensureDefaultSandboxes(config)
+	cm.ensureFileTypes(config)
+	cm.ensureDefaultFolders(config)
+	cm.ensureWorkspaces(config)
+	cm.config = config
+	cm.diskHash = sha256.Sum256(data)
+	cm.backupConfig(data)
+	return nil
+}
+
+// Reload picks up edits made to config.json by other programs. It returns
+// false when the file still holds what was last loaded or saved. Invalid
+// edits are reported once and leave the current configuration in place.
+func (cm *ConfigManager) Reload() (bool, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	data, changed, err := cm.readExternalConfig()
+	if err != nil || !changed || sha256.Sum256(data) == cm.rejected {
+		return false, err
+	}
+	if err := cm.applyExternalConfig(data); err != nil {
+		return false, fmt.Errorf("invalid config.json: %v", err)
+	}
+	return true, nil
+}
+
+// resolveConflictLocked runs before every save. An external edit that has
+// not been reloaded yet is merged in if it is valid; otherwise it is moved
+// aside so the save does not silently destroy it. cm.mu must be held.
+func (cm *ConfigManager) resolveConflictLocked() {
+	data, changed, err := cm.readExternalConfig()
+	if err != nil || !changed {
+		return
+	}
+	if cm.applyExternalConfig(data) == nil {
+		return
+	}
+
+	conflictPath := cm.configPath + ".conflict-" + time.Now().Format(configBackupLayout)
+	if err := os.Rename(cm.configPath, conflictPath); err != nil {
+		return
+	}
+	cm.warnings = append(cm.warnings, fmt.Sprintf("配置文件被外部程序修改但内容无效，已另存为 %s 并以当前配置覆盖",
+		filepath.Base(conflictPath)))
+}
+
+// Warnings returns problems found while loading the configuration
+func (cm *ConfigManager) Warnings() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return append([]string{}, cm.warnings...)
+}
+
+// ClearWarnings drops warnings once the user has seen them
+func (cm *ConfigManager) ClearWarnings() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.warnings = nil
+}
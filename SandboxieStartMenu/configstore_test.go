@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigBackupsWithinOneSecond(t *testing.T) {
+	cm := &ConfigManager{configPath: filepath.Join(t.TempDir(), "config.json")}
+
+	// Saves in a burst used to overwrite each other's backup
+	const saves = maxConfigBackups + 3
+	for i := 0; i < saves; i++ {
+		if err := cm.backupConfig([]byte(fmt.Sprintf(`{"version":%d}`, i))); err != nil {
+			t.Fatalf("backupConfig: %v", err)
+		}
+	}
+
+	backups := cm.listConfigBackups()
+	if len(backups) != maxConfigBackups {
+		t.Fatalf("%d backups kept, want %d", len(backups), maxConfigBackups)
+	}
+	for i, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf(`{"version":%d}`, saves-1-i); string(data) != want {
+			t.Errorf("backup %d (%s) = %s, want %s", i, filepath.Base(backup), data, want)
+		}
+	}
+
+	// Unchanged data adds no backup
+	cm.backupConfig([]byte(fmt.Sprintf(`{"version":%d}`, saves-1)))
+	if got := cm.listConfigBackups(); got[0] != backups[0] {
+		t.Errorf("identical config backed up again as %s", filepath.Base(got[0]))
+	}
+}
+
+func TestNewConfigBackupPath(t *testing.T) {
+	cm := &ConfigManager{configPath: filepath.Join(t.TempDir(), "config.json")}
+	os.MkdirAll(cm.configBackupDir(), 0755)
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.Local)
+
+	first := cm.newConfigBackupPath(now)
+	if want := "config-20240506-070809.123456.json"; filepath.Base(first) != want {
+		t.Fatalf("backup name = %s, want %s", filepath.Base(first), want)
+	}
+	os.WriteFile(first, nil, 0644)
+
+	second := cm.newConfigBackupPath(now)
+	if second == first || filepath.Base(second) <= filepath.Base(first) {
+		t.Errorf("second backup %s does not sort after %s", filepath.Base(second), filepath.Base(first))
+	}
+}
+
+// writeTestConfig puts content into config.json of a temporary config
+// folder and returns the file's path
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	t.Setenv("APPDATA", t.TempDir())
+	path := filepath.Join(getConfigDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMigratesVersion0(t *testing.T) {
+	t.Setenv("PROGRAMDATA", filepath.FromSlash("/data"))
+	t.Setenv("USERPROFILE", filepath.FromSlash("/home/ann"))
+	startMenu := filepath.Join(os.Getenv("PROGRAMDATA"), "Microsoft", "Windows", "Start Menu", "Programs")
+	desktop := filepath.Join(os.Getenv("USERPROFILE"), "Desktop")
+	tools := filepath.FromSlash("/tools")
+
+	old, _ := json.Marshal(map[string]interface{}{
+		"folderPaths":        []string{startMenu, tools, desktop},
+		"currentFolder":      filepath.Join(desktop, "Games", "Old"),
+		"selectedSandbox":    "Work",
+		"availableSandboxes": []string{"DefaultBox", "Work", "work", ""},
+		"sandboxBindings":    []map[string]string{{"pattern": filepath.Join(tools, "a.exe"), "sandbox": "Work"}},
+		"fileTypes":          []map[string]string{{"extension": ".pdf", "launch": "reader {path}"}},
+	})
+	path := writeTestConfig(t, string(old))
+
+	cm := NewConfigManager()
+	config := cm.GetConfig()
+
+	if config.Version != currentConfigVersion {
+		t.Errorf("Version = %d, want %d", config.Version, currentConfigVersion)
+	}
+	// V0: match types filled in, duplicate and empty boxes dropped
+	wantBinding := SandboxBinding{Pattern: filepath.Join(tools, "a.exe"), MatchType: BindingExact, Sandbox: "Work"}
+	if !slices.Equal(config.SandboxBindings, []SandboxBinding{wantBinding}) {
+		t.Errorf("SandboxBindings = %+v, want %+v", config.SandboxBindings, wantBinding)
+	}
+	if want := []string{"DefaultBox", "Work", "__ask__"}; !slices.Equal(config.AvailableSandboxes, want) {
+		t.Errorf("AvailableSandboxes = %q, want %q", config.AvailableSandboxes, want)
+	}
+	// V2: the old default folders became the merged virtual folders
+	if want := []string{tools}; !slices.Equal(config.FolderPaths, want) {
+		t.Errorf("FolderPaths = %q, want %q", config.FolderPaths, want)
+	}
+	if want := VirtualDesktop + `\Games\Old`; config.CurrentFolder != want {
+		t.Errorf("CurrentFolder = %q, want %q", config.CurrentFolder, want)
+	}
+	// V1: the settings moved into the default workspace
+	if config.ActiveWorkspace != defaultWorkspaceName || len(config.Workspaces) != 1 {
+		t.Fatalf("workspaces = %q / %+v, want only %q", config.ActiveWorkspace, config.Workspaces, defaultWorkspaceName)
+	}
+	if w := config.Workspaces[0]; w.Name != defaultWorkspaceName || !slices.Equal(w.FolderPaths, config.FolderPaths) ||
+		w.CurrentFolder != config.CurrentFolder || w.SelectedSandbox != "Work" || !slices.Equal(w.SandboxBindings, config.SandboxBindings) {
+		t.Errorf("default workspace = %+v", w)
+	}
+	// V3: the new default types are added, the user's own kept
+	if i := findFileType(config.FileTypes, ".pdf"); i < 0 || config.FileTypes[i].Launch != "reader {path}" {
+		t.Errorf("FileTypes = %+v, want .pdf kept with its template", config.FileTypes)
+	}
+	for _, ext := range []string{".url", ".docx", ".exe"} {
+		if findFileType(config.FileTypes, ext) < 0 {
+			t.Errorf("FileTypes lacks %s", ext)
+		}
+	}
+
+	// The migrated config is saved; the original is kept as a backup
+	saved, _, err := decodeConfig(mustReadFile(t, path))
+	if err != nil || saved.Version != currentConfigVersion || !slices.Equal(saved.FolderPaths, []string{tools}) {
+		t.Errorf("saved config = %+v, %v", saved, err)
+	}
+	backups := cm.listConfigBackups()
+	if len(backups) != 1 || string(mustReadFile(t, backups[0])) != string(old) {
+		t.Errorf("backups = %q, want the original file", backups)
+	}
+	if warnings := cm.Warnings(); len(warnings) != 0 {
+		t.Errorf("Warnings = %q, want none", warnings)
+	}
+}
+
+func TestLoadMigratesWorkspaceFolders(t *testing.T) {
+	t.Setenv("PROGRAMDATA", filepath.FromSlash("/data"))
+	t.Setenv("USERPROFILE", filepath.FromSlash("/home/ann"))
+	startMenu := filepath.Join(os.Getenv("PROGRAMDATA"), "Microsoft", "Windows", "Start Menu", "Programs")
+	games := filepath.FromSlash("/games")
+
+	// A version 2 config already has workspaces, whose folders migrate too
+	old, _ := json.Marshal(map[string]interface{}{
+		"version":         2,
+		"folderPaths":     []string{startMenu},
+		"currentFolder":   startMenu,
+		"activeWorkspace": "Home",
+		"workspaces": []map[string]interface{}{
+			{"name": "Home", "folderPaths": []string{startMenu}, "currentFolder": startMenu},
+			{"name": "Games", "folderPaths": []string{startMenu, games}, "currentFolder": filepath.Join(startMenu, "Steam")},
+		},
+	})
+	writeTestConfig(t, string(old))
+
+	config := NewConfigManager().GetConfig()
+	if len(config.FolderPaths) != 0 || config.CurrentFolder != VirtualStartMenu {
+		t.Errorf("active folders = %q / %q, want none / %q", config.FolderPaths, config.CurrentFolder, VirtualStartMenu)
+	}
+	if len(config.Workspaces) != 2 {
+		t.Fatalf("Workspaces = %+v", config.Workspaces)
+	}
+	if w := config.Workspaces[1]; !slices.Equal(w.FolderPaths, []string{games}) || w.CurrentFolder != VirtualStartMenu+`\Steam` {
+		t.Errorf("Games workspace = %+v", w)
+	}
+}
+
+func TestLoadRecoversCorruptConfig(t *testing.T) {
+	path := writeTestConfig(t, `{"version": 4, "folderPaths": ["/tools"], "selectedSandbox": "Work"}`)
+	cm := NewConfigManager() // Keeps the good file as a backup
+	if len(cm.listConfigBackups()) != 1 {
+		t.Fatalf("no backup taken of a valid config")
+	}
+
+	broken := []byte(`{"version": 4, "folderPaths": [`)
+	if err := os.WriteFile(path, broken, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cm = NewConfigManager()
+
+	config := cm.GetConfig()
+	if !slices.Equal(config.FolderPaths, []string{"/tools"}) || config.SelectedSandbox != "Work" {
+		t.Errorf("recovered config = %+v, want the backup's settings", config)
+	}
+
+	// The broken file is kept aside and config.json is valid again
+	corrupt, _ := filepath.Glob(path + ".corrupt-*")
+	if len(corrupt) != 1 || string(mustReadFile(t, corrupt[0])) != string(broken) {
+		t.Errorf("corrupt files = %q, want one with the broken content", corrupt)
+	}
+	if _, _, err := decodeConfig(mustReadFile(t, path)); err != nil {
+		t.Errorf("config.json after recovery: %v", err)
+	}
+
+	warnings := cm.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], filepath.Base(corrupt[0])) || !strings.Contains(warnings[0], configBackupPrefix) {
+		t.Errorf("Warnings = %q, want one naming the backup and the corrupt file", warnings)
+	}
+}
+
+func TestLoadCorruptConfigWithoutBackup(t *testing.T) {
+	path := writeTestConfig(t, `not json`)
+
+	cm := NewConfigManager()
+	config := cm.GetConfig()
+	if len(config.FolderPaths) != 0 || config.SelectedSandbox != "DefaultBox" {
+		t.Errorf("config = %+v, want the defaults", config)
+	}
+	if corrupt, _ := filepath.Glob(path + ".corrupt-*"); len(corrupt) != 1 {
+		t.Errorf("corrupt files = %q, want one", corrupt)
+	}
+	if warnings := cm.Warnings(); len(warnings) != 1 {
+		t.Errorf("Warnings = %q, want one", warnings)
+	}
+}
+
+// mustReadFile returns the content of path
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
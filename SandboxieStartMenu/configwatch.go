@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	configReloadDebounce = 300 * time.Millisecond // Editors often save a file in several steps
+	configPollInterval   = 2 * time.Second        // Used when the config folder can't be watched
+)
+
+// ConfigWatcher reloads config.json when another program (usually a text
+// editor opened by OpenConfigFile) changes it
+type ConfigWatcher struct {
+	configManager *ConfigManager
+	watcher       changeWatcher
+	onReload      func()
+	onError       func(err error)
+	stamp         configFileStamp // config.json as last seen
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// configFileStamp identifies one version of config.json
+type configFileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// statConfigFile returns the stamp of the file at path
+func statConfigFile(path string) configFileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return configFileStamp{}
+	}
+	return configFileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+// NewConfigWatcher starts watching the config folder. onReload is called
+// after a valid external edit was applied, onError when an edit was
+// rejected. A nil watcher makes it poll the file instead.
+func NewConfigWatcher(configManager *ConfigManager, watcher changeWatcher, onReload func(), onError func(err error)) *ConfigWatcher {
+	w := &ConfigWatcher{
+		configManager: configManager,
+		watcher:       watcher,
+		onReload:      onReload,
+		onError:       onError,
+		stamp:         statConfigFile(configManager.GetConfigPath()),
+		stop:          make(chan struct{}),
+	}
+
+	if w.watcher != nil && w.watcher.Watch(filepath.Dir(configManager.GetConfigPath())) != nil {
+		w.watcher.Close()
+		w.watcher = nil
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// run waits for folder changes, debounced, or polls without a watcher
+func (w *ConfigWatcher) run() {
+	defer w.wg.Done()
+
+	var events <-chan string
+	var poll <-chan time.Time
+	if w.watcher != nil {
+		events = w.watcher.Events()
+	} else {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	debounce := time.NewTimer(configReloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-events:
+			// The folder also holds history.json and temporary files
+			if w.configFileChanged() {
+				debounce.Reset(configReloadDebounce)
+			}
+		case <-debounce.C:
+			w.check()
+		case <-poll:
+			if w.configFileChanged() {
+				w.check()
+			}
+		}
+	}
+}
+
+// configFileChanged reports whether config.json was written since the
+// last call; changes to other files in the folder are ignored
+func (w *ConfigWatcher) configFileChanged() bool {
+	stamp := statConfigFile(w.configManager.GetConfigPath())
+	if stamp == w.stamp {
+		return false
+	}
+	w.stamp = stamp
+	return true
+}
+
+// check reloads the config if it changed on disk
+func (w *ConfigWatcher) check() {
+	changed, err := w.configManager.Reload()
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+	if changed && w.onReload != nil {
+		w.onReload()
+	}
+}
+
+// Close stops watching
+func (w *ConfigWatcher) Close() {
+	close(w.stop)
+	w.wg.Wait()
+	if w.watcher != nil {
+		w.watcher.Close()
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWatcher is a changeWatcher whose events the test sends
+type fakeWatcher struct {
+	mu     sync.Mutex
+	roots  []string
+	events chan string
+	closed bool
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{events: make(chan string, 16)}
+}
+
+func (w *fakeWatcher) Watch(root string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roots = append(w.roots, root)
+	return nil
+}
+
+func (w *fakeWatcher) Unwatch(root string) {}
+
+func (w *fakeWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *fakeWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+// editConfigFile changes one field of config.json the way an editor would
+func editConfigFile(t *testing.T, path string, key string, value interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields[key] = value
+	data, _ = json.MarshalIndent(fields, "", "  ")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigWatcherReloadsConfigFile(t *testing.T) {
+	cm := newTestConfigManager(t)
+	if err := cm.SetSelectedSandbox("Before"); err != nil {
+		t.Fatal(err)
+	}
+	configPath := cm.GetConfigPath()
+	dir := filepath.Dir(configPath)
+
+	watcher := newFakeWatcher()
+	reloads := make(chan struct{}, 4)
+	w := NewConfigWatcher(cm, watcher, func() { reloads <- struct{}{} }, func(err error) {
+		t.Errorf("config rejected: %v", err)
+	})
+
+	if len(watcher.roots) != 1 || watcher.roots[0] != dir {
+		t.Fatalf("watched %q, want %q", watcher.roots, dir)
+	}
+
+	// Other files in the folder don't cause reloads
+	if err := os.WriteFile(filepath.Join(dir, "history.json"), []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.events <- dir
+	if !w.waitIdle(t) {
+		t.Fatal("watcher did not process the event")
+	}
+	select {
+	case <-reloads:
+		t.Fatal("reloaded after history.json changed")
+	default:
+	}
+
+	editConfigFile(t, configPath, "selectedSandbox", "After")
+	watcher.events <- dir
+	select {
+	case <-reloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("external edit not reloaded")
+	}
+	if got := cm.GetConfig().SelectedSandbox; got != "After" {
+		t.Errorf("SelectedSandbox = %q after reload", got)
+	}
+
+	w.Close()
+	if !watcher.closed {
+		t.Error("Close did not close the folder watcher")
+	}
+}
+
+// waitIdle waits until the watcher's run loop has drained its events
+func (w *ConfigWatcher) waitIdle(t *testing.T) bool {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(w.watcher.Events()) == 0 {
+			time.Sleep(2 * configReloadDebounce)
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestConfigFileChanged(t *testing.T) {
+	cm := newTestConfigManager(t)
+	if err := cm.SetSelectedSandbox("Box"); err != nil {
+		t.Fatal(err)
+	}
+	w := &ConfigWatcher{configManager: cm, stamp: statConfigFile(cm.GetConfigPath())}
+	dir := filepath.Dir(cm.GetConfigPath())
+
+	if w.configFileChanged() {
+		t.Error("unchanged config reported as changed")
+	}
+
+	for _, other := range []string{"history.json", "config.json.tmp", filepath.Join("iconcache", "x.icon")} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, other)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, other), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if w.configFileChanged() {
+			t.Errorf("writing %s reported as a config change", other)
+		}
+	}
+
+	editConfigFile(t, cm.GetConfigPath(), "selectedSandbox", "Other box")
+	if !w.configFileChanged() {
+		t.Error("config edit not noticed")
+	}
+	if w.configFileChanged() {
+		t.Error("config edit reported twice")
+	}
+
+	os.Remove(cm.GetConfigPath())
+	if !w.configFileChanged() {
+		t.Error("removed config not noticed")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package main
+
+// attachParentConsole does nothing; outside Windows the app inherits the
+// terminal it was started from
+func attachParentConsole() {}
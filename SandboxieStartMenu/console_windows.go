@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// attachParentProcess is ATTACH_PARENT_PROCESS for AttachConsole
+const attachParentProcess = ^uint32(0)
+
+// attachParentConsole connects stdout and stderr to the console of the
+// program that started us. The app is built as a GUI program and gets no
+// console of its own, so CLI output run from cmd or PowerShell would be
+// lost. Redirected output (pipes, files) is inherited and left alone.
+func attachParentConsole() {
+	stdout, _ := windows.GetStdHandle(windows.STD_OUTPUT_HANDLE)
+	stderr, _ := windows.GetStdHandle(windows.STD_ERROR_HANDLE)
+	if isValidHandle(stdout) && isValidHandle(stderr) {
+		return
+	}
+
+	attachConsole := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if ok, _, _ := attachConsole.Call(uintptr(attachParentProcess)); ok == 0 {
+		return // Started without a console, e.g. from a hotkey tool
+	}
+	console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	if !isValidHandle(stdout) {
+		os.Stdout = console
+	}
+	if !isValidHandle(stderr) {
+		os.Stderr = console
+	}
+}
+
+// isValidHandle reports whether a standard handle is usable
+func isValidHandle(h windows.Handle) bool {
+	return h != 0 && h != windows.InvalidHandle
+}
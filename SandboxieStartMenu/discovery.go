@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sandboxieHomeEnv names the environment variable pointing at a Sandboxie
+// install folder (or directly at Start.exe)
+const sandboxieHomeEnv = "SBIE_HOME"
+
+// Where Start.exe was found, reported to the UI
+const (
+	SourceConfig      = "config"
+	SourceEnvironment = "environment"
+	SourceRegistry    = "registry"
+	SourceService     = "service"
+	SourcePath        = "path"
+	SourceDefault     = "default"
+)
+
+// SandboxieStatus describes the Sandboxie install in use
+type SandboxieStatus struct {
+	Available    bool     `json:"available"`
+	StartExePath string   `json:"startExePath"`
+	Source       string   `json:"source"` // Which discovery step found Start.exe, empty if none did
+	Tried        []string `json:"tried"`  // Locations checked, in order
+}
+
+// installProbe is one step of the discovery chain. It returns candidate
+// paths: install folders, Start.exe itself or a service image path.
+type installProbe struct {
+	source string
+	find   func() []string
+}
+
+// defaultStartExePaths are the standard install locations
+func defaultStartExePaths() []string {
+	var paths []string
+	for _, env := range []string{"ProgramFiles", "ProgramW6432", "ProgramFiles(x86)"} {
+		if dir := os.Getenv(env); dir != "" {
+			paths = append(paths,
+				filepath.Join(dir, "Sandboxie-Plus", "Start.exe"),
+				filepath.Join(dir, "Sandboxie", "Start.exe"),
+			)
+		}
+	}
+	return append(paths,
+		"C:\\Program Files\\Sandboxie-Plus\\Start.exe",
+		"C:\\Program Files\\Sandboxie\\Start.exe",
+		"C:\\Program Files (x86)\\Sandboxie\\Start.exe",
+	)
+}
+
+// pathStartExe looks for Start.exe in the folders on PATH
+func pathStartExe() []string {
+	path, err := exec.LookPath("Start.exe")
+	if err != nil {
+		return nil
+	}
+	return []string{path}
+}
+
+// discoveryChain returns the probes in priority order: explicit config,
+// environment, uninstall registry key, SbieSvc service, PATH, then defaults
+func discoveryChain(configuredPath string) []installProbe {
+	return []installProbe{
+		{SourceConfig, func() []string { return []string{configuredPath} }},
+		{SourceEnvironment, func() []string { return []string{os.Getenv(sandboxieHomeEnv)} }},
+		{SourceRegistry, registryInstallLocations},
+		{SourceService, serviceImagePaths},
+		{SourcePath, pathStartExe},
+		{SourceDefault, defaultStartExePaths},
+	}
+}
+
+// discoverSandboxie runs the discovery chain and returns the first
+// Start.exe that exists
+func discoverSandboxie(probes []installProbe) SandboxieStatus {
+	status := SandboxieStatus{Tried: []string{}}
+	seen := make(map[string]bool)
+
+	for _, probe := range probes {
+		for _, candidate := range probe.find() {
+			startExe := startExeFromCandidate(candidate)
+			if startExe == "" || seen[strings.ToLower(startExe)] {
+				continue
+			}
+			seen[strings.ToLower(startExe)] = true
+			status.Tried = append(status.Tried, startExe)
+
+			if info, err := os.Stat(startExe); err == nil && !info.IsDir() {
+				status.Available = true
+				status.StartExePath = startExe
+				status.Source = probe.source
+				return status
+			}
+		}
+	}
+	return status
+}
+
+// startExeFromCandidate turns an install folder, a file inside it (e.g.
+// SbieSvc.exe or an uninstaller) or a quoted service command line into
+// the path of Start.exe
+func startExeFromCandidate(candidate string) string {
+	candidate = strings.TrimSpace(expandWindowsEnv(candidate))
+	if candidate == "" {
+		return ""
+	}
+
+	// Service image paths and DisplayIcon values may be quoted and carry
+	// arguments or an icon index
+	if strings.HasPrefix(candidate, `"`) {
+		if end := strings.Index(candidate[1:], `"`); end >= 0 {
+			candidate = candidate[1 : end+1]
+		}
+	} else if i := strings.Index(strings.ToLower(candidate), ".exe"); i >= 0 {
+		candidate = candidate[:i+len(".exe")]
+	}
+	candidate = strings.TrimPrefix(candidate, `\??\`)
+
+	if strings.EqualFold(filepath.Base(candidate), "Start.exe") {
+		return filepath.Clean(candidate)
+	}
+	if strings.EqualFold(filepath.Ext(candidate), ".exe") {
+		return filepath.Join(filepath.Dir(candidate), "Start.exe")
+	}
+	return filepath.Join(candidate, "Start.exe")
+}
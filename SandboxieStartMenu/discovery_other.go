@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+// registryInstallLocations returns nothing; there is no registry to read
+func registryInstallLocations() []string {
+	return nil
+}
+
+// serviceImagePaths returns nothing; there is no service manager to ask
+func serviceImagePaths() []string {
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// makeInstall creates an install folder, with Start.exe if withStartExe
+func makeInstall(t *testing.T, dir string, withStartExe bool) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if withStartExe {
+		if err := os.WriteFile(filepath.Join(dir, "Start.exe"), []byte("MZ"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestStartExeFromCandidate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Sandboxie-Plus")
+	startExe := filepath.Join(dir, "Start.exe")
+	t.Setenv("SBIE_TEST_DIR", dir)
+
+	tests := []struct {
+		name      string
+		candidate string
+		want      string
+	}{
+		{"install folder", dir, startExe},
+		{"install folder with separator", dir + string(filepath.Separator), startExe},
+		{"Start.exe itself", startExe, startExe},
+		{"Start.exe in other case", filepath.Join(dir, "START.EXE"), filepath.Join(dir, "START.EXE")},
+		{"other exe in the folder", filepath.Join(dir, "SbieSvc.exe"), startExe},
+		{"quoted service command line", `"` + filepath.Join(dir, "SbieSvc.exe") + `" -k`, startExe},
+		{"display icon with index", filepath.Join(dir, "unins000.exe") + ",0", startExe},
+		{"environment variable", "%SBIE_TEST_DIR%", startExe},
+		{"empty", "", ""},
+		{"blank", "   ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startExeFromCandidate(tt.candidate); got != tt.want {
+				t.Errorf("startExeFromCandidate(%q) = %q, want %q", tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverSandboxie(t *testing.T) {
+	base := t.TempDir()
+	empty := makeInstall(t, filepath.Join(base, "Empty"), false)
+	plus := makeInstall(t, filepath.Join(base, "Plus"), true)
+	classic := makeInstall(t, filepath.Join(base, "Classic"), true)
+
+	laterProbed := false
+	status := discoverSandboxie([]installProbe{
+		{SourceConfig, func() []string { return []string{""} }},
+		{SourceEnvironment, func() []string { return []string{empty} }},
+		// The same missing Start.exe in another spelling is only tried once
+		{SourceRegistry, func() []string {
+			return []string{strings.ToUpper(empty), filepath.Join(empty, "unins000.exe"), filepath.Join(plus, "SbieSvc.exe"), plus}
+		}},
+		{SourceService, func() []string { laterProbed = true; return []string{classic} }},
+	})
+
+	want := SandboxieStatus{
+		Available:    true,
+		StartExePath: filepath.Join(plus, "Start.exe"),
+		Source:       SourceRegistry,
+		Tried:        []string{filepath.Join(empty, "Start.exe"), filepath.Join(plus, "Start.exe")},
+	}
+	if status.Available != want.Available || status.StartExePath != want.StartExePath || status.Source != want.Source || !slices.Equal(status.Tried, want.Tried) {
+		t.Errorf("discoverSandboxie = %+v, want %+v", status, want)
+	}
+	if laterProbed {
+		t.Error("probes after the one that found Start.exe were run")
+	}
+}
+
+func TestDiscoverSandboxieNotFound(t *testing.T) {
+	base := t.TempDir()
+	empty := makeInstall(t, filepath.Join(base, "Empty"), false)
+	other := makeInstall(t, filepath.Join(base, "Other"), false)
+	// A folder named Start.exe is not a program
+	makeInstall(t, filepath.Join(other, "Start.exe"), false)
+
+	status := discoverSandboxie([]installProbe{
+		{SourceConfig, func() []string { return []string{empty} }},
+		{SourceDefault, func() []string { return []string{other, empty} }},
+	})
+	if status.Available || status.StartExePath != "" || status.Source != "" {
+		t.Errorf("discoverSandboxie = %+v, want nothing found", status)
+	}
+	if want := []string{filepath.Join(empty, "Start.exe"), filepath.Join(other, "Start.exe")}; !slices.Equal(status.Tried, want) {
+		t.Errorf("Tried = %q, want %q", status.Tried, want)
+	}
+}
+
+func TestDiscoveryChainFromPath(t *testing.T) {
+	dir := makeInstall(t, filepath.Join(t.TempDir(), "bin"), true)
+	t.Setenv("PATH", dir)
+
+	var sources []string
+	for _, probe := range discoveryChain("") {
+		sources = append(sources, probe.source)
+	}
+	if want := []string{SourceConfig, SourceEnvironment, SourceRegistry, SourceService, SourcePath, SourceDefault}; !slices.Equal(sources, want) {
+		t.Errorf("discovery chain = %q, want %q", sources, want)
+	}
+
+	// The real registry and service probes would find an installed
+	// Sandboxie first, so the PATH probe is checked on its own
+	if got := pathStartExe(); !slices.Equal(got, []string{filepath.Join(dir, "Start.exe")}) {
+		t.Errorf("pathStartExe = %q, want Start.exe from PATH", got)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"golang.org/x/sys/windows/registry"
+)
+
+// Uninstall keys written by the Sandboxie-Plus and classic installers.
+// Sandboxie-Plus uses Inno Setup, which names its key <AppId>_is1.
+var uninstallKeys = []string{
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie-Plus_is1`,
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie-Plus`,
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie-Plus_is1`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie-Plus`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\Sandboxie`,
+}
+
+// registryInstallLocations reads install locations from the uninstall keys
+func registryInstallLocations() []string {
+	var locations []string
+	for _, path := range uninstallKeys {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		for _, name := range []string{"InstallLocation", "DisplayIcon", "UninstallString"} {
+			if value, _, err := key.GetStringValue(name); err == nil && value != "" {
+				locations = append(locations, value)
+			}
+		}
+		key.Close()
+	}
+	return locations
+}
+
+// serviceImagePaths reads the SbieSvc service executable path
+func serviceImagePaths() []string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\SbieSvc`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer key.Close()
+
+	value, _, err := key.GetStringValue("ImagePath")
+	if err != nil || value == "" {
+		return nil
+	}
+	return []string{value}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// VirtualFavorites lists the pinned programs
+const VirtualFavorites = virtualFolderPrefix + "favorites"
+
+// Favorite is a pinned program. Favorites are listed in slice order.
+type Favorite struct {
+	Path    string `json:"path"`
+	Name    string `json:"name,omitempty"`    // Display name, empty to use the file name
+	Sandbox string `json:"sandbox,omitempty"` // Sandbox override, empty to use bindings / the selected box
+}
+
+// findFavorite returns the index of the favorite for path, or -1
+func findFavorite(favorites []Favorite, path string) int {
+	for i, f := range favorites {
+		if normalizeBindingPath(f.Path) == normalizeBindingPath(path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// favoriteFiles builds the content of the Favorites folder. Favorites
+// whose file no longer exists are skipped but kept in the config, so
+// they come back when e.g. a network drive reconnects.
+func favoriteFiles(fm *FileManager, favorites []Favorite) []FileInfo {
+	files := []FileInfo{}
+	for _, f := range favorites {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+
+		file := folderInfo(f.Path)
+		if !info.IsDir() {
+			var ok bool
+			if file, ok = fm.fileInfo(f.Path); !ok {
+				continue
+			}
+		}
+		if f.Name != "" {
+			file.Name = f.Name
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// AddFavorite pins a program (or folder) to the end of the Favorites folder
+func (cm *ConfigManager) AddFavorite(path string) error {
+	if cm.IsFavorite(path) {
+		return nil // Already pinned
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("file not found: %s", path)
+	}
+
+	return cm.Update(func(config *Config) error {
+		if findFavorite(config.Favorites, path) >= 0 {
+			return nil // Pinned meanwhile
+		}
+		config.Favorites = append(config.Favorites, Favorite{Path: path})
+		return nil
+	})
+}
+
+// RemoveFavorite unpins a program
+func (cm *ConfigManager) RemoveFavorite(path string) error {
+	return cm.Update(func(config *Config) error {
+		i := findFavorite(config.Favorites, path)
+		if i < 0 {
+			return nil
+		}
+
+		config.Favorites = append(config.Favorites[:i], config.Favorites[i+1:]...)
+		return nil
+	})
+}
+
+// MoveFavorite moves a favorite to a new position in the list
+func (cm *ConfigManager) MoveFavorite(path string, index int) error {
+	return cm.Update(func(config *Config) error {
+		i := findFavorite(config.Favorites, path)
+		if i < 0 {
+			return fmt.Errorf("not a favorite: %s", path)
+		}
+		if index < 0 {
+			index = 0
+		}
+		if index >= len(config.Favorites) {
+			index = len(config.Favorites) - 1
+		}
+
+		fav := config.Favorites[i]
+		favorites := append(config.Favorites[:i:i], config.Favorites[i+1:]...)
+		favorites = append(favorites[:index], append([]Favorite{fav}, favorites[index:]...)...)
+		config.Favorites = favorites
+		return nil
+	})
+}
+
+// UpdateFavorite sets the display name and sandbox of a favorite
+func (cm *ConfigManager) UpdateFavorite(path string, name string, sandbox string) error {
+	return cm.Update(func(config *Config) error {
+		i := findFavorite(config.Favorites, path)
+		if i < 0 {
+			return fmt.Errorf("not a favorite: %s", path)
+		}
+
+		config.Favorites[i].Name = name
+		config.Favorites[i].Sandbox = sandbox
+		return nil
+	})
+}
+
+// GetFavorites returns the pinned programs in order
+func (cm *ConfigManager) GetFavorites() []Favorite {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return slices.Clone(cm.config.Favorites)
+}
+
+// IsFavorite reports whether path is pinned
+func (cm *ConfigManager) IsFavorite(path string) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return findFavorite(cm.config.Favorites, path) >= 0
+}
@@ -5,18 +5,45 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 // FileManager handles file operations
-type FileManager struct{}
+type FileManager struct {
+	registry atomic.Pointer[fileTypeRegistry]
+}
 
-// NewFileManager creates a new file manager
+// NewFileManager creates a new file manager that lists the built-in types
 func NewFileManager() *FileManager {
-	return &FileManager{}
+	fm := &FileManager{}
+	fm.SetFileTypes(FileTypeSettings{})
+	return fm
+}
+
+// SetFileTypes changes which files are listed and how they are launched
+func (fm *FileManager) SetFileTypes(settings FileTypeSettings) {
+	fm.registry.Store(newFileTypeRegistry(settings))
+}
+
+// fileTypes returns the current file type registry
+func (fm *FileManager) fileTypes() *fileTypeRegistry {
+	return fm.registry.Load()
 }
 
-// GetDirectoryContents returns all folders and executable files in the given directory
+// GetDirectoryContents returns all folders and launchable files in the given directory
 func (fm *FileManager) GetDirectoryContents(dirPath string) ([]FileInfo, error) {
+	return fm.readDirectory(dirPath, true)
+}
+
+// GetExecutableFiles returns all launchable files in the given directory (for backward compatibility)
+func (fm *FileManager) GetExecutableFiles(dirPath string) ([]FileInfo, error) {
+	return fm.readDirectory(dirPath, false)
+}
+
+// readDirectory lists the folders (if includeFolders) and launchable files
+// in dirPath, skipping names matched by a hide rule. Folders come first,
+// both sorted by name (case-insensitive).
+func (fm *FileManager) readDirectory(dirPath string, includeFolders bool) ([]FileInfo, error) {
 	var folders []FileInfo
 	var files []FileInfo
 
@@ -25,52 +52,26 @@ func (fm *FileManager) GetDirectoryContents(dirPath string) ([]FileInfo, error)
 		return nil, err
 	}
 
+	registry := fm.fileTypes()
 	for _, entry := range entries {
 		name := entry.Name()
+		if registry.hidden(name) {
+			continue
+		}
 		fullPath := filepath.Join(dirPath, name)
 
 		if entry.IsDir() {
-			// Add folder
-			folders = append(folders, FileInfo{
-				Name:  name,
-				Path:  fullPath,
-				Type:  "folder",
-				Icon:  "",
-				IsDir: true,
-			})
-		} else {
-			// Check if it's an executable file
-			ext := strings.ToLower(filepath.Ext(name))
-			var fileType string
-			switch ext {
-			case ".exe":
-				fileType = "exe"
-			case ".bat":
-				fileType = "bat"
-			case ".cmd":
-				fileType = "cmd"
-			case ".lnk":
-				fileType = "lnk"
-			default:
-				continue // Skip non-executable files
+			if includeFolders {
+				folders = append(folders, folderInfo(fullPath))
 			}
-
-			files = append(files, FileInfo{
-				Name:  name,
-				Path:  fullPath,
-				Type:  fileType,
-				Icon:  "", // 延迟加载：不立即提取图标
-				IsDir: false,
-			})
+		} else if file, ok := fm.fileInfo(fullPath); ok {
+			files = append(files, file)
 		}
 	}
 
-	// Sort folders by name (case-insensitive)
 	sort.Slice(folders, func(i, j int) bool {
 		return strings.ToLower(folders[i].Name) < strings.ToLower(folders[j].Name)
 	})
-
-	// Sort files by name (case-insensitive)
 	sort.Slice(files, func(i, j int) bool {
 		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
 	})
@@ -79,52 +80,79 @@ func (fm *FileManager) GetDirectoryContents(dirPath string) ([]FileInfo, error)
 	return append(folders, files...), nil
 }
 
-// GetExecutableFiles returns all executable files in the given directory (for backward compatibility)
-func (fm *FileManager) GetExecutableFiles(dirPath string) ([]FileInfo, error) {
-	var files []FileInfo
+// folderInfo builds the entry for a folder
+func folderInfo(path string) FileInfo {
+	return FileInfo{
+		Name:  filepath.Base(path),
+		Path:  path,
+		Type:  "folder",
+		IsDir: true,
+	}
+}
 
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return nil, err
+// fileInfo builds the entry for a file, or returns false if its type
+// isn't listed. Hide rules are not applied here.
+func (fm *FileManager) fileInfo(path string) (FileInfo, bool) {
+	name := filepath.Base(path)
+	fileType, ok := fm.fileTypes().fileType(name)
+	if !ok {
+		return FileInfo{}, false
 	}
+	return FileInfo{
+		Name:     name,
+		Path:     path,
+		Type:     fileType,
+		Icon:     "", // 延迟加载：不立即提取图标
+		IsDir:    false,
+		Shortcut: readShortcutInfo(path, fileType),
+		URL:      readInternetShortcutURL(path, fileType),
+	}, true
+}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+// isHidden reports whether a hide rule matches the file or folder name
+func (fm *FileManager) isHidden(name string) bool {
+	return fm.fileTypes().hidden(name)
+}
 
-		name := entry.Name()
-		ext := strings.ToLower(filepath.Ext(name))
-
-		var fileType string
-		switch ext {
-		case ".exe":
-			fileType = "exe"
-		case ".bat":
-			fileType = "bat"
-		case ".cmd":
-			fileType = "cmd"
-		case ".lnk":
-			fileType = "lnk"
-		default:
-			continue // Skip non-executable files
+// launchTarget applies the launch template of the file's type. An
+// Internet Shortcut without a template launches its URL, so the box's
+// default browser opens it.
+func (fm *FileManager) launchTarget(filePath string, opts LaunchOptions) (string, LaunchOptions, error) {
+	registry := fm.fileTypes()
+	if isInternetShortcut(filePath) && registry.launchTemplate(filePath) == "" {
+		shortcut, err := ReadInternetShortcut(filePath)
+		if err != nil {
+			return "", opts, err
 		}
-
-		files = append(files, FileInfo{
-			Name:  name,
-			Path:  filepath.Join(dirPath, name),
-			Type:  fileType,
-			Icon:  "", // 延迟加载：不立即提取图标
-			IsDir: false,
-		})
+		return shortcut.URL, opts, nil
 	}
+	return applyLaunchTemplate(registry, filePath, opts)
+}
 
-	// Sort files by name (case-insensitive)
-	sort.Slice(files, func(i, j int) bool {
-		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
-	})
+// readShortcutInfo parses a .lnk file, returning nil for other file types
+// or shortcuts that can't be read
+func readShortcutInfo(path string, fileType string) *ShellLink {
+	if fileType != "lnk" {
+		return nil
+	}
+	link, err := ReadShellLink(path)
+	if err != nil {
+		return nil
+	}
+	return link
+}
 
-	return files, nil
+// readInternetShortcutURL returns the URL of a .url file, or "" for
+// other file types or shortcuts that can't be read
+func readInternetShortcutURL(path string, fileType string) string {
+	if fileType != "url" {
+		return ""
+	}
+	shortcut, err := ReadInternetShortcut(path)
+	if err != nil {
+		return ""
+	}
+	return shortcut.URL
 }
 
 // ValidateDirectory checks if the directory exists and is accessible
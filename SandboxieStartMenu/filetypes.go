@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileType is a launchable file extension and how to launch it
+type FileType struct {
+	Extension string `json:"extension"` // Including the dot, e.g. ".ps1"
+	Launch    string `json:"launch"`    // Launch template, e.g. "powershell -File {path}"; empty lets Start.exe open the file
+}
+
+// FileTypeSettings is the part of the config that decides which files are
+// listed and how they are launched
+type FileTypeSettings struct {
+	FileTypes    []FileType `json:"fileTypes"`
+	HideRules    []string   `json:"hideRules"`    // Glob patterns of names to hide, e.g. "Uninstall*"
+	ShowAllFiles bool       `json:"showAllFiles"` // Also list files of unregistered types
+}
+
+// Placeholders in launch templates
+const (
+	launchPathPlaceholder = "{path}" // Full path of the file
+	launchDirPlaceholder  = "{dir}"  // Folder containing the file
+	launchNamePlaceholder = "{name}" // File name with extension
+)
+
+// otherFileType is the type of files listed only because ShowAllFiles is set
+const otherFileType = "file"
+
+// builtinFileTypes are always registered; their templates can be changed
+var builtinFileTypes = []FileType{
+	{Extension: ".exe"},
+	{Extension: ".bat"},
+	{Extension: ".cmd"},
+	{Extension: ".lnk"},
+}
+
+// defaultFileTypes are registered in new configs and by the version 4
+// migration, but can be removed. They have no launch template, so Start.exe
+// opens them with the box's default handler.
+var defaultFileTypes = []FileType{
+	{Extension: ".url"},
+	{Extension: ".pdf"},
+	{Extension: ".doc"},
+	{Extension: ".docx"},
+	{Extension: ".xls"},
+	{Extension: ".xlsx"},
+	{Extension: ".ppt"},
+	{Extension: ".pptx"},
+	{Extension: ".rtf"},
+}
+
+// normalizeExtension lowercases an extension and adds the leading dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// fileTypeName returns the type reported to the frontend for an extension
+func fileTypeName(ext string) string {
+	return strings.TrimPrefix(normalizeExtension(ext), ".")
+}
+
+// findFileType returns the index of the type for ext, or -1
+func findFileType(types []FileType, ext string) int {
+	ext = normalizeExtension(ext)
+	for i, t := range types {
+		if normalizeExtension(t.Extension) == ext {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureFileTypes registers the built-in types missing from config
+func (cm *ConfigManager) ensureFileTypes(config *Config) {
+	for _, builtin := range builtinFileTypes {
+		if findFileType(config.FileTypes, builtin.Extension) < 0 {
+			config.FileTypes = append(config.FileTypes, builtin)
+		}
+	}
+	if config.HideRules == nil {
+		config.HideRules = []string{}
+	}
+}
+
+// validateFileTypes checks extensions, launch templates and hide rules
+func validateFileTypes(types []FileType, hideRules []string) error {
+	for i, t := range types {
+		ext := normalizeExtension(t.Extension)
+		if len(ext) < 2 || strings.ContainsAny(ext, `\/*?`) {
+			return fmt.Errorf("invalid file type extension: %q", t.Extension)
+		}
+		if findFileType(types, ext) != i {
+			return fmt.Errorf("duplicate file type: %s", ext)
+		}
+		if t.Launch != "" && len(splitCommandLine(t.Launch)) == 0 {
+			return fmt.Errorf("file type %s: launch template is empty", ext)
+		}
+	}
+	for _, rule := range hideRules {
+		if strings.TrimSpace(rule) == "" {
+			return fmt.Errorf("hide rule is empty")
+		}
+		if _, err := filepath.Match(strings.ToLower(rule), ""); err != nil {
+			return fmt.Errorf("invalid hide rule %q: %v", rule, err)
+		}
+	}
+	return nil
+}
+
+// fileTypeRegistry answers which files are listed and how they launch.
+// It is immutable; settings changes build a new one.
+type fileTypeRegistry struct {
+	types     map[string]FileType // Normalized extension -> type
+	hideRules []string            // Lowercase glob patterns
+	showAll   bool
+}
+
+// newFileTypeRegistry builds a registry from the config settings
+func newFileTypeRegistry(settings FileTypeSettings) *fileTypeRegistry {
+	r := &fileTypeRegistry{types: make(map[string]FileType), showAll: settings.ShowAllFiles}
+	for _, t := range builtinFileTypes {
+		r.types[t.Extension] = t
+	}
+	for _, t := range settings.FileTypes {
+		t.Extension = normalizeExtension(t.Extension)
+		r.types[t.Extension] = t
+	}
+	for _, rule := range settings.HideRules {
+		r.hideRules = append(r.hideRules, strings.ToLower(strings.TrimSpace(rule)))
+	}
+	return r
+}
+
+// fileType returns the type of a file name and whether it is launchable.
+// With ShowAllFiles every file is, unregistered ones as otherFileType.
+func (r *fileTypeRegistry) fileType(name string) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if _, ok := r.types[ext]; ok {
+		return fileTypeName(ext), true
+	}
+	if r.showAll {
+		return otherFileType, true
+	}
+	return "", false
+}
+
+// hidden reports whether a hide rule matches the name (case-insensitive)
+func (r *fileTypeRegistry) hidden(name string) bool {
+	name = strings.ToLower(name)
+	for _, rule := range r.hideRules {
+		if ok, _ := filepath.Match(rule, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// launchTemplate returns the launch template for a file, "" to open it
+// directly
+func (r *fileTypeRegistry) launchTemplate(path string) string {
+	return r.types[strings.ToLower(filepath.Ext(path))].Launch
+}
+
+// expandLaunchTemplate turns a launch template into the program and
+// arguments for filePath. The template is split into arguments before the
+// placeholders are replaced, so paths with spaces stay one argument.
+// Programs given by name are looked up on PATH.
+func expandLaunchTemplate(template string, filePath string) (string, []string, error) {
+	parts := splitCommandLine(template)
+	if len(parts) == 0 {
+		return "", nil, fmt.Errorf("launch template is empty")
+	}
+
+	replacer := strings.NewReplacer(
+		launchPathPlaceholder, filePath,
+		launchDirPlaceholder, filepath.Dir(filePath),
+		launchNamePlaceholder, filepath.Base(filePath),
+	)
+	args := make([]string, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		args = append(args, replacer.Replace(part))
+	}
+
+	program := replacer.Replace(parts[0])
+	if !strings.ContainsAny(program, `\/`) {
+		if found, err := exec.LookPath(program); err == nil {
+			program = found
+		}
+	}
+	return program, args, nil
+}
+
+// applyLaunchTemplate rewrites a launch of filePath according to its file
+// type. Files without a template are returned unchanged.
+func applyLaunchTemplate(registry *fileTypeRegistry, filePath string, opts LaunchOptions) (string, LaunchOptions, error) {
+	template := registry.launchTemplate(filePath)
+	if template == "" {
+		return filePath, opts, nil
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return "", opts, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	program, args, err := expandLaunchTemplate(template, filePath)
+	if err != nil {
+		return "", opts, fmt.Errorf("%s: %v", filepath.Ext(filePath), err)
+	}
+	resolved := opts
+	resolved.Args = append(args, opts.Args...)
+	if resolved.WorkingDir == "" {
+		resolved.WorkingDir = filepath.Dir(filePath)
+	}
+	return program, resolved, nil
+}
+
+// GetFileTypeSettings returns the file type registry settings
+func (cm *ConfigManager) GetFileTypeSettings() FileTypeSettings {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return FileTypeSettings{
+		FileTypes:    slices.Clone(cm.config.FileTypes),
+		HideRules:    slices.Clone(cm.config.HideRules),
+		ShowAllFiles: cm.config.ShowAllFiles,
+	}
+}
+
+// SetFileTypeSettings replaces the file type registry settings
+func (cm *ConfigManager) SetFileTypeSettings(settings FileTypeSettings) error {
+	types := make([]FileType, 0, len(settings.FileTypes))
+	for _, t := range settings.FileTypes {
+		types = append(types, FileType{Extension: normalizeExtension(t.Extension), Launch: strings.TrimSpace(t.Launch)})
+	}
+	hideRules := []string{}
+	for _, rule := range settings.HideRules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			hideRules = append(hideRules, rule)
+		}
+	}
+	if err := validateFileTypes(types, hideRules); err != nil {
+		return err
+	}
+
+	return cm.Update(func(config *Config) error {
+		config.FileTypes = types
+		config.HideRules = hideRules
+		config.ShowAllFiles = settings.ShowAllFiles
+		cm.ensureFileTypes(config)
+		return nil
+	})
+}
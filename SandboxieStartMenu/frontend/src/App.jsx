@@ -15,8 +15,19 @@ import {
   OpenFolder,
   GoBack,
   CanGoBack,
-  OpenSandboxieManager
+  OpenSandboxieManager,
+  AddFavorite,
+  RemoveFavorite,
+  TerminateBox,
+  RequestDeleteConfirmation,
+  TerminateAndDelete,
+  DismissConfigWarnings,
+  SwitchWorkspace,
+  CreateWorkspace,
+  DeleteWorkspace,
+  SetFileTypeSettings
 } from '../wailsjs/go/main/App'
+import { EventsOn } from '../wailsjs/runtime/runtime'
 import Sidebar from './components/Sidebar'
 import MainContent from './components/MainContent'
 import Toast from './components/Toast'
@@ -71,6 +82,49 @@ function App() {
     return () => mediaQuery.removeEventListener('change', handleChange)
   }, [])
 
+  // Refresh the file list when the backend index sees folder changes
+  useEffect(() => {
+    const unsubscribe = EventsOn('files:changed', async () => {
+      try {
+        const state = await GetAppState()
+        setAppState(state)
+      } catch (err) {
+        console.error('Error refreshing after file change:', err)
+      }
+    })
+    return unsubscribe
+  }, [])
+
+  // Config was recovered from a backup, written by a newer version or
+  // edited externally while the app was saving
+  useEffect(() => {
+    const warnings = appState?.configWarnings
+    if (warnings && warnings.length > 0) {
+      showToast(warnings.join('\n'), 'error')
+      DismissConfigWarnings().catch(err => console.error('Error dismissing config warnings:', err))
+    }
+  }, [appState?.configWarnings])
+
+  // Reload when config.json is edited outside the app
+  useEffect(() => {
+    const offChanged = EventsOn('config:changed', async () => {
+      try {
+        const state = await GetAppState()
+        setAppState(state)
+        showToast('配置文件已重新加载', 'success')
+      } catch (err) {
+        console.error('Error refreshing after config change:', err)
+      }
+    })
+    const offInvalid = EventsOn('config:invalid', (message) => {
+      showToast(`配置文件无效，未重新加载: ${message}`, 'error')
+    })
+    return () => {
+      offChanged()
+      offInvalid()
+    }
+  }, [])
+
   // Update document class for dark mode
   useEffect(() => {
     if (isDark) {
@@ -138,6 +192,19 @@ function App() {
     }
   }, [])
 
+  const handleSaveFileTypes = useCallback(async (settings) => {
+    try {
+      const newState = await SetFileTypeSettings(settings)
+      setAppState(newState)
+      showToast('文件类型已保存', 'success')
+      return true
+    } catch (err) {
+      console.error('Error saving file types:', err)
+      showToast(`保存文件类型失败: ${err.message || err}`, 'error')
+      return false
+    }
+  }, [])
+
   const handleAddFolder = useCallback(async () => {
     try {
       const folderPath = await OpenFolderDialog()
@@ -162,6 +229,52 @@ function App() {
     }
   }, [])
 
+  const handleSwitchWorkspace = useCallback(async (name) => {
+    try {
+      const newState = await SwitchWorkspace(name)
+      setAppState(newState)
+    } catch (err) {
+      console.error('Error switching workspace:', err)
+      showToast(`切换工作区失败: ${err.message || err}`, 'error')
+    }
+  }, [])
+
+  const handleCreateWorkspace = useCallback(async () => {
+    const name = window.prompt('新工作区名称')
+    if (!name || !name.trim()) {
+      return
+    }
+
+    try {
+      const copyCurrent = window.confirm('是否复制当前工作区的文件夹、沙盒和绑定？')
+      await CreateWorkspace(name.trim(), copyCurrent)
+      const newState = await SwitchWorkspace(name.trim())
+      setAppState(newState)
+      showToast(`工作区 "${name.trim()}" 已创建`, 'success')
+    } catch (err) {
+      console.error('Error creating workspace:', err)
+      showToast(`创建工作区失败: ${err.message || err}`, 'error')
+    }
+  }, [])
+
+  const handleDeleteWorkspace = useCallback(async (name) => {
+    const other = (appState?.workspaces || []).find(w => w !== name)
+    if (!other || !window.confirm(`是否删除工作区 "${name}"？`)) {
+      return
+    }
+
+    try {
+      // The active workspace can't be deleted, so switch away first
+      await SwitchWorkspace(other)
+      const newState = await DeleteWorkspace(name)
+      setAppState(newState)
+      showToast(`工作区 "${name}" 已删除`, 'success')
+    } catch (err) {
+      console.error('Error deleting workspace:', err)
+      showToast(`删除工作区失败: ${err.message || err}`, 'error')
+    }
+  }, [appState])
+
   const handleAddSandbox = useCallback(async (sandboxName) => {
     if (!sandboxName.trim()) {
       showToast('请输入沙盒名称', 'error')
@@ -203,11 +316,41 @@ function App() {
     }
   }, [])
 
+  const handleTerminateSandbox = useCallback(async (sandbox) => {
+    try {
+      const result = await TerminateBox(sandbox)
+      showToast(result.success ? `沙盒 "${sandbox}" 中的程序已终止` : `终止失败: ${result.message}`, result.success ? 'success' : 'error')
+    } catch (err) {
+      console.error('Error terminating sandbox:', err)
+      showToast(`终止失败: ${err.message || err}`, 'error')
+    }
+  }, [])
+
+  const handleEmptySandbox = useCallback(async (sandbox) => {
+    if (!window.confirm(`是否终止沙盒 "${sandbox}" 中的所有程序并删除其内容？此操作无法撤销。`)) {
+      return
+    }
+
+    try {
+      const confirmation = await RequestDeleteConfirmation(sandbox)
+      const results = await TerminateAndDelete(sandbox, confirmation.token)
+      const failed = results.find(r => !r.success)
+      if (failed) {
+        showToast(`清空沙盒失败: ${failed.message}`, 'error')
+      } else {
+        showToast(`沙盒 "${sandbox}" 已清空`, 'success')
+      }
+    } catch (err) {
+      console.error('Error emptying sandbox:', err)
+      showToast(`清空沙盒失败: ${err.message || err}`, 'error')
+    }
+  }, [])
+
   const handleLaunchFile = useCallback(async (filePath) => {
     try {
       const response = await LaunchProgram(filePath)
       if (response.success) {
-        showToast(`程序启动成功 (PID: ${response.pid})`, 'success')
+        showToast('程序启动成功', 'success')
       } else {
         showToast(`启动程序失败: ${response.message}`, 'error')
       }
@@ -247,6 +390,17 @@ function App() {
     }
   }, [])
 
+  const handleToggleFavorite = useCallback(async (file) => {
+    try {
+      const newState = file.favorite ? await RemoveFavorite(file.path) : await AddFavorite(file.path)
+      setAppState(newState)
+      showToast(file.favorite ? `已取消收藏: ${file.name}` : `已收藏: ${file.name}`, 'success')
+    } catch (err) {
+      console.error('Error toggling favorite:', err)
+      showToast(`收藏操作失败: ${err.message || err}`, 'error')
+    }
+  }, [])
+
   const handleGoBack = useCallback(async () => {
     try {
       const newState = await GoBack()
@@ -288,8 +442,14 @@ function App() {
         onChangeSandbox={handleChangeSandbox}
         onAddSandbox={handleAddSandbox}
         onRemoveSandbox={handleRemoveSandbox}
+        onTerminateSandbox={handleTerminateSandbox}
+        onEmptySandbox={handleEmptySandbox}
         onOpenConfigFile={handleOpenConfigFile}
         onOpenSandboxieManager={handleOpenSandboxieManager}
+        onSwitchWorkspace={handleSwitchWorkspace}
+        onCreateWorkspace={handleCreateWorkspace}
+        onDeleteWorkspace={handleDeleteWorkspace}
+        onSaveFileTypes={handleSaveFileTypes}
         isCollapsed={sidebarCollapsed}
         onToggle={toggleSidebar}
       />
@@ -297,6 +457,7 @@ function App() {
         appState={appState}
         onLaunchFile={handleLaunchFile}
         onOpenFolder={handleOpenFolder}
+        onToggleFavorite={handleToggleFavorite}
         onGoBack={handleGoBack}
         canGoBack={canGoBack}
       />
@@ -1,6 +1,6 @@
 import React from 'react'
 
-function FileItem({ file, icon, onLaunch, onOpenFolder }) {
+function FileItem({ file, icon, onLaunch, onOpenFolder, onToggleFavorite }) {
   const getDefaultIcon = () => {
     if (file.isDir) {
       return '📁'
@@ -13,7 +13,25 @@ function FileItem({ file, icon, onLaunch, onOpenFolder }) {
         return '🔗'
       case 'bat':
       case 'cmd':
+      case 'ps1':
+      case 'py':
         return '📝'
+      case 'msi':
+        return '📦'
+      case 'url':
+        return '🌐'
+      case 'pdf':
+        return '📕'
+      case 'doc':
+      case 'docx':
+      case 'rtf':
+        return '📘'
+      case 'xls':
+      case 'xlsx':
+        return '📗'
+      case 'ppt':
+      case 'pptx':
+        return '📙'
       default:
         return '📄'
     }
@@ -37,12 +55,33 @@ function FileItem({ file, icon, onLaunch, onOpenFolder }) {
       {/* Content Area - Right */}
       <div className="flex-1 flex flex-col min-w-0 justify-between">
         <div>
-          <h3 className="font-semibold text-gray-900 dark:text-white truncate text-sm" title={file.name}>
-            {file.name}
-          </h3>
-          <p className="text-xs text-gray-500 dark:text-gray-400 truncate" title={file.path}>
-            {file.path}
+          <div className="flex items-center gap-1">
+            <h3 className="flex-1 font-semibold text-gray-900 dark:text-white truncate text-sm" title={file.name}>
+              {file.name}
+            </h3>
+            {onToggleFavorite && (
+              <button
+                onClick={() => onToggleFavorite(file)}
+                className="text-sm text-gray-400 hover:text-yellow-500 transition-colors"
+                title={file.favorite ? '取消收藏' : '收藏'}
+              >
+                {file.favorite ? '★' : '☆'}
+              </button>
+            )}
+          </div>
+          <p className="text-xs text-gray-500 dark:text-gray-400 truncate" title={file.shortcut?.description || file.url || file.path}>
+            {file.shortcut?.target || file.url || file.path}
           </p>
+          {file.shortcut?.broken && (
+            <p className="text-xs text-red-600 dark:text-red-400 truncate" title={file.shortcut.target}>
+              ⚠️ 快捷方式目标不存在
+            </p>
+          )}
+          {file.sandbox && (
+            <p className="text-xs text-indigo-600 dark:text-indigo-400 truncate" title={`绑定沙盒: ${file.sandbox}`}>
+              📦 {file.sandbox}
+            </p>
+          )}
         </div>
 
         {/* Action Button */}
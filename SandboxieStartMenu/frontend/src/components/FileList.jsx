@@ -1,33 +1,54 @@
 import React, { useState, useEffect } from 'react'
-import { GetFileIcon } from '../../wailsjs/go/main/App'
+import { GetFileIcons } from '../../wailsjs/go/main/App'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
 import FileItem from './FileItem'
 
-function FileList({ files, onLaunchFile, onOpenFolder }) {
+// Icons are shown at 40 CSS pixels; ask for enough device pixels to stay
+// sharp on scaled displays (the backend picks the next size of 16/32/48/256)
+const iconSize = () => Math.ceil(40 * (window.devicePixelRatio || 1))
+
+function FileList({ files, onLaunchFile, onOpenFolder, onToggleFavorite }) {
   const [fileIcons, setFileIcons] = useState({})
-  const [loadingIcons, setLoadingIcons] = useState(true)
 
+  // The backend prefetches icons of the current folder and streams them
+  // in batches; show each one as soon as it arrives
   useEffect(() => {
-    const loadIcons = async () => {
-      const icons = {}
-      for (const file of files) {
-        try {
-          const iconData = await GetFileIcon(file.path)
-          if (iconData && iconData !== '') {
-            icons[file.path] = iconData
+    const unsubscribe = EventsOn('icons:loaded', (results) => {
+      setFileIcons(prev => {
+        const icons = { ...prev }
+        for (const r of results || []) {
+          // Skip prefetches started before we asked for a different size
+          if (r.size >= iconSize() || !icons[r.path]) {
+            icons[r.path] = r.icon
           }
-        } catch (err) {
-          console.error('Error loading icon for', file.path, ':', err)
         }
-      }
-      setFileIcons(icons)
-      setLoadingIcons(false)
+        return icons
+      })
+    })
+    return unsubscribe
+  }, [])
+
+  useEffect(() => {
+    if (!files || files.length === 0) {
+      return
     }
 
-    if (files && files.length > 0) {
-      loadIcons()
-    } else {
-      setLoadingIcons(false)
+    let cancelled = false
+    const loadIcons = async () => {
+      try {
+        // One round trip for the whole list; icons already streamed are
+        // served from the cache
+        const icons = await GetFileIcons(files.map(f => f.path), iconSize())
+        if (!cancelled) {
+          setFileIcons(prev => ({ ...prev, ...icons }))
+        }
+      } catch (err) {
+        console.error('Error loading icons:', err)
+      }
     }
+
+    loadIcons()
+    return () => { cancelled = true }
   }, [files])
 
   if (!files || files.length === 0) {
@@ -51,6 +72,7 @@ function FileList({ files, onLaunchFile, onOpenFolder }) {
           icon={fileIcons[file.path]}
           onLaunch={onLaunchFile}
           onOpenFolder={onOpenFolder}
+          onToggleFavorite={onToggleFavorite}
         />
       ))}
     </div>
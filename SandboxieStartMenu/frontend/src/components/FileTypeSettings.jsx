@@ -0,0 +1,117 @@
+import React, { useState } from 'react'
+import { GetFileTypeSettings } from '../../wailsjs/go/main/App'
+
+// Launch templates may use {path}, {dir} and {name}
+function FileTypeSettings({ onSave }) {
+  const [settings, setSettings] = useState(null)
+  const [hideRules, setHideRules] = useState('')
+
+  const open = async () => {
+    try {
+      const current = await GetFileTypeSettings()
+      setSettings(current)
+      setHideRules((current.hideRules || []).join('\n'))
+    } catch (err) {
+      console.error('Error loading file types:', err)
+    }
+  }
+
+  if (!settings) {
+    return (
+      <button
+        onClick={open}
+        className="w-full px-4 py-2 bg-gray-200 hover:bg-gray-300 dark:bg-gray-700 dark:hover:bg-gray-600 text-gray-800 dark:text-gray-200 font-medium rounded-lg transition-colors duration-200"
+      >
+        编辑文件类型
+      </button>
+    )
+  }
+
+  const updateType = (index, field, value) => {
+    const fileTypes = settings.fileTypes.map((t, i) => (i === index ? { ...t, [field]: value } : t))
+    setSettings({ ...settings, fileTypes })
+  }
+
+  const save = async () => {
+    const rules = hideRules.split('\n').map(r => r.trim()).filter(r => r !== '')
+    const saved = await onSave({ ...settings, hideRules: rules })
+    if (saved) {
+      setSettings(null)
+    }
+  }
+
+  const inputClass = 'px-2 py-1 border border-gray-300 dark:border-gray-600 rounded bg-white dark:bg-gray-700 text-sm text-gray-900 dark:text-white focus:outline-none focus:ring-2 focus:ring-blue-500'
+
+  return (
+    <div className="space-y-3">
+      <div className="space-y-2">
+        {settings.fileTypes.map((t, i) => (
+          <div key={i} className="flex gap-2">
+            <input
+              value={t.extension}
+              onChange={(e) => updateType(i, 'extension', e.target.value)}
+              className={`${inputClass} w-20`}
+              placeholder=".ps1"
+            />
+            <input
+              value={t.launch}
+              onChange={(e) => updateType(i, 'launch', e.target.value)}
+              className={`${inputClass} flex-1 min-w-0`}
+              placeholder="直接打开"
+              title="启动模板，可使用 {path}、{dir}、{name}"
+            />
+            <button
+              onClick={() => setSettings({ ...settings, fileTypes: settings.fileTypes.filter((_, j) => j !== i) })}
+              className="px-2 text-gray-400 hover:text-red-600 transition-colors"
+              title="移除文件类型"
+            >
+              ×
+            </button>
+          </div>
+        ))}
+        <button
+          onClick={() => setSettings({ ...settings, fileTypes: [...settings.fileTypes, { extension: '', launch: '' }] })}
+          className="text-sm text-blue-600 dark:text-blue-400 hover:underline"
+        >
+          + 添加文件类型
+        </button>
+      </div>
+
+      <div>
+        <label className="block text-sm text-gray-600 dark:text-gray-300 mb-1">隐藏规则（每行一个，如 Uninstall*）</label>
+        <textarea
+          value={hideRules}
+          onChange={(e) => setHideRules(e.target.value)}
+          rows={3}
+          className={`${inputClass} w-full font-mono`}
+        />
+      </div>
+
+      <label className="flex items-center gap-2 text-sm text-gray-700 dark:text-gray-300">
+        <input
+          type="checkbox"
+          checked={settings.showAllFiles}
+          onChange={(e) => setSettings({ ...settings, showAllFiles: e.target.checked })}
+        />
+        显示所有文件
+      </label>
+
+      <div className="flex gap-2">
+        <button
+          onClick={save}
+          className="flex-1 px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white font-medium rounded-lg transition-colors duration-200"
+        >
+          保存
+        </button>
+        <button
+          onClick={() => setSettings(null)}
+          className="flex-1 px-4 py-2 bg-gray-200 hover:bg-gray-300 dark:bg-gray-700 dark:hover:bg-gray-600 text-gray-800 dark:text-gray-200 font-medium rounded-lg transition-colors duration-200"
+        >
+          取消
+        </button>
+      </div>
+    </div>
+  )
+}
+
+export default FileTypeSettings
@@ -1,17 +1,33 @@
 import React from 'react'
 
-function FolderList({ folders, currentFolder, onSelectFolder, onRemoveFolder }) {
-  if (!folders || folders.length === 0) {
-    return (
-      <div className="p-4 bg-gray-50 dark:bg-gray-700 rounded-lg border border-gray-200 dark:border-gray-600 text-center">
-        <p className="text-sm text-gray-500 dark:text-gray-400">尚未添加文件夹</p>
-      </div>
-    )
-  }
+// A virtual folder stays highlighted while browsing its subfolders
+const isInVirtualFolder = (id, current) => current === id || (current || '').startsWith(id + '\\')
+
+function FolderList({ folders, virtualFolders = [], currentFolder, onSelectFolder, onRemoveFolder }) {
+  const virtualList = virtualFolders.map((folder) => (
+    <button
+      key={folder.id}
+      onClick={() => onSelectFolder(folder.id)}
+      className={`w-full text-left flex items-center gap-2 p-3 rounded-lg border transition-all duration-200 ${
+        isInVirtualFolder(folder.id, currentFolder)
+          ? 'bg-blue-50 dark:bg-blue-900/30 border-blue-300 dark:border-blue-600 shadow-sm'
+          : 'bg-white dark:bg-gray-700 border-gray-200 dark:border-gray-600 hover:border-gray-300 dark:hover:border-gray-500'
+      }`}
+    >
+      <span className="text-lg">{folder.icon || '⭐'}</span>
+      <span className="text-sm font-medium text-gray-900 dark:text-white truncate">{folder.name}</span>
+    </button>
+  ))
 
   return (
     <div className="space-y-2 max-h-60 overflow-y-auto scrollbar-thin scrollbar-thumb-gray-300 scrollbar-track-gray-100 dark:scrollbar-thumb-gray-600 dark:scrollbar-track-gray-800">
-      {folders.map((folder) => (
+      {virtualList}
+      {(!folders || folders.length === 0) && (
+        <div className="p-4 bg-gray-50 dark:bg-gray-700 rounded-lg border border-gray-200 dark:border-gray-600 text-center">
+          <p className="text-sm text-gray-500 dark:text-gray-400">尚未添加文件夹</p>
+        </div>
+      )}
+      {(folders || []).map((folder) => (
         <div
           key={folder}
           className={`flex items-center justify-between p-3 rounded-lg border transition-all duration-200 ${
@@ -1,7 +1,38 @@
-import React from 'react'
+import React, { useState, useEffect } from 'react'
+import { Search } from '../../wailsjs/go/main/App'
 import FileList from './FileList'
 
-function MainContent({ appState, onLaunchFile, onOpenFolder, onGoBack, canGoBack }) {
+// folderTitle shows virtual folders by name, keeping the subfolder path
+// of merged folders such as the Start Menu
+const folderTitle = (folder, virtualFolders) => {
+  const virtual = virtualFolders.find(f => folder === f.id || folder.startsWith(f.id + '\\'))
+  return virtual ? virtual.name + folder.slice(virtual.id.length) : folder
+}
+
+function MainContent({ appState, onLaunchFile, onOpenFolder, onToggleFavorite, onGoBack, canGoBack }) {
+  const [query, setQuery] = useState('')
+  const [searchResults, setSearchResults] = useState(null)
+
+  // Debounce typing; the backend cancels any search still running
+  useEffect(() => {
+    if (!query.trim()) {
+      setSearchResults(null)
+      return
+    }
+
+    const timer = setTimeout(async () => {
+      try {
+        const results = await Search(query)
+        setSearchResults((results || []).map(r => r.file))
+      } catch (err) {
+        // A newer query replaced this one
+        console.debug('Search cancelled or failed:', err)
+      }
+    }, 200)
+
+    return () => clearTimeout(timer)
+  }, [query])
+
   return (
     <main className="flex-1 flex flex-col overflow-hidden">
       {/* Header */}
@@ -11,10 +42,17 @@ function MainContent({ appState, onLaunchFile, onOpenFolder, onGoBack, canGoBack
             <h2 className="text-3xl font-bold">程序启动器</h2>
             <p className="text-blue-100 mt-1">
               {appState.currentFolder
-                ? `文件夹: ${appState.currentFolder}`
+                ? `文件夹: ${folderTitle(appState.currentFolder, appState.virtualFolders || [])}`
                 : '选择文件夹以查看程序'}
             </p>
           </div>
+          <input
+            type="search"
+            value={query}
+            onChange={(e) => setQuery(e.target.value)}
+            placeholder="🔍 搜索程序..."
+            className="ml-auto mr-4 px-3 py-2 w-64 rounded-lg text-gray-900 dark:text-white dark:bg-gray-800 placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-300"
+          />
           {canGoBack && (
             <button
               onClick={onGoBack}
@@ -29,7 +67,14 @@ function MainContent({ appState, onLaunchFile, onOpenFolder, onGoBack, canGoBack
 
       {/* Content Area */}
       <div className="flex-1 overflow-auto p-6 bg-gray-50 dark:bg-gray-900">
-        {!appState.currentFolder ? (
+        {searchResults ? (
+          <FileList
+            files={searchResults}
+            onLaunchFile={onLaunchFile}
+            onOpenFolder={onOpenFolder}
+            onToggleFavorite={onToggleFavorite}
+          />
+        ) : !appState.currentFolder ? (
           <div className="flex items-center justify-center h-full">
             <div className="text-center">
               <div className="text-6xl mb-4">📁</div>
@@ -42,6 +87,7 @@ function MainContent({ appState, onLaunchFile, onOpenFolder, onGoBack, canGoBack
             files={appState.files || []}
             onLaunchFile={onLaunchFile}
             onOpenFolder={onOpenFolder}
+            onToggleFavorite={onToggleFavorite}
           />
         )}
       </div>
@@ -1,6 +1,6 @@
 import React, { useState } from 'react'
 
-function SandboxManager({ sandboxes, onAddSandbox, onRemoveSandbox }) {
+function SandboxManager({ sandboxes, onAddSandbox, onRemoveSandbox, onTerminateSandbox, onEmptySandbox }) {
   const [newSandboxName, setNewSandboxName] = useState('')
 
   const handleAddClick = () => {
@@ -28,9 +28,27 @@ function SandboxManager({ sandboxes, onAddSandbox, onRemoveSandbox }) {
               key={sandbox}
               className="flex items-center justify-between p-2 bg-gray-50 dark:bg-gray-700 rounded border border-gray-200 dark:border-gray-600 hover:bg-gray-100 dark:hover:bg-gray-600 transition-colors"
             >
-              <span className="text-sm font-medium text-gray-900 dark:text-white">
+              <span className="flex-1 text-sm font-medium text-gray-900 dark:text-white">
                 {sandbox === '__ask__' ? `${sandbox} (询问)` : sandbox}
               </span>
+              {sandbox !== '__ask__' && (
+                <>
+                  <button
+                    onClick={() => onTerminateSandbox(sandbox)}
+                    className="p-1 rounded text-gray-400 hover:text-orange-600 hover:bg-orange-50 transition-colors"
+                    title="终止沙盒中的所有程序"
+                  >
+                    <span className="text-sm">⏹</span>
+                  </button>
+                  <button
+                    onClick={() => onEmptySandbox(sandbox)}
+                    className="p-1 rounded text-gray-400 hover:text-red-600 hover:bg-red-50 transition-colors"
+                    title="终止程序并删除沙盒内容"
+                  >
+                    <span className="text-sm">🗑️</span>
+                  </button>
+                </>
+              )}
               <button
                 onClick={() => onRemoveSandbox(sandbox)}
                 disabled={sandbox === '__ask__'}
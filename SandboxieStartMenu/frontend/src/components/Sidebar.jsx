@@ -2,6 +2,8 @@ import React, { useState } from 'react'
 import FolderList from './FolderList'
 import SandboxSelector from './SandboxSelector'
 import SandboxManager from './SandboxManager'
+import WorkspaceSelector from './WorkspaceSelector'
+import FileTypeSettings from './FileTypeSettings'
 
 function Sidebar({
   appState,
@@ -11,8 +13,14 @@ function Sidebar({
   onChangeSandbox,
   onAddSandbox,
   onRemoveSandbox,
+  onTerminateSandbox,
+  onEmptySandbox,
   onOpenConfigFile,
   onOpenSandboxieManager,
+  onSwitchWorkspace,
+  onCreateWorkspace,
+  onDeleteWorkspace,
+  onSaveFileTypes,
   isCollapsed = false,
   onToggle,
 }) {
@@ -72,6 +80,21 @@ function Sidebar({
           )}
         </div>
 
+        {/* Workspace Selector */}
+        <div className={`mb-8 ${isCollapsed ? 'hidden' : ''}`}>
+          <h2 className="text-lg font-semibold text-gray-900 dark:text-white mb-4 flex items-center gap-2">
+            <span className="text-lg">🗂️</span>
+            工作区
+          </h2>
+          <WorkspaceSelector
+            workspaces={appState.workspaces || []}
+            activeWorkspace={appState.activeWorkspace}
+            onSwitchWorkspace={onSwitchWorkspace}
+            onCreateWorkspace={onCreateWorkspace}
+            onDeleteWorkspace={onDeleteWorkspace}
+          />
+        </div>
+
         {/* Folders Section */}
         <div className={`mb-8 ${isCollapsed ? 'hidden' : ''}`}>
           <h2 className="text-lg font-semibold text-gray-900 dark:text-white mb-4 flex items-center gap-2">
@@ -80,6 +103,7 @@ function Sidebar({
           </h2>
           <FolderList
             folders={appState.folderPaths || []}
+            virtualFolders={appState.virtualFolders || []}
             currentFolder={appState.currentFolder}
             onSelectFolder={onSelectFolder}
             onRemoveFolder={onRemoveFolder}
@@ -116,9 +140,20 @@ function Sidebar({
             sandboxes={appState.availableSandboxes || []}
             onAddSandbox={onAddSandbox}
             onRemoveSandbox={onRemoveSandbox}
+            onTerminateSandbox={onTerminateSandbox}
+            onEmptySandbox={onEmptySandbox}
           />
         </div>
 
+        {/* File Types */}
+        <div className={`mt-8 ${isCollapsed ? 'hidden' : ''}`}>
+          <h2 className="text-lg font-semibold text-gray-900 dark:text-white mb-4 flex items-center gap-2">
+            <span className="text-lg">🧩</span>
+            文件类型
+          </h2>
+          <FileTypeSettings onSave={onSaveFileTypes} />
+        </div>
+
         {/* Configuration Button */}
         <div className={`mt-8 ${isCollapsed ? 'hidden' : ''}`}>
           <button
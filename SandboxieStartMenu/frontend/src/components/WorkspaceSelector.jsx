@@ -0,0 +1,36 @@
+import React from 'react'
+
+function WorkspaceSelector({ workspaces, activeWorkspace, onSwitchWorkspace, onCreateWorkspace, onDeleteWorkspace }) {
+  return (
+    <div className="flex gap-2">
+      <select
+        value={activeWorkspace || ''}
+        onChange={(e) => onSwitchWorkspace(e.target.value)}
+        className="flex-1 px-4 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-white font-medium focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent transition-all"
+      >
+        {(workspaces || []).map((workspace) => (
+          <option key={workspace} value={workspace}>
+            {workspace}
+          </option>
+        ))}
+      </select>
+      <button
+        onClick={onCreateWorkspace}
+        className="px-3 py-2 bg-blue-600 hover:bg-blue-700 text-white font-medium rounded-lg transition-colors text-sm"
+        title="新建工作区"
+      >
+        +
+      </button>
+      <button
+        onClick={() => onDeleteWorkspace(activeWorkspace)}
+        disabled={!workspaces || workspaces.length <= 1}
+        className="px-3 py-2 rounded-lg text-gray-400 hover:text-red-600 hover:bg-red-50 disabled:text-gray-300 disabled:hover:bg-transparent disabled:cursor-not-allowed transition-colors"
+        title="删除当前工作区"
+      >
+        ×
+      </button>
+    </div>
+  )
+}
+
+export default WorkspaceSelector
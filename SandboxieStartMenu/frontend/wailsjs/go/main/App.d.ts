@@ -4,20 +4,62 @@ import {main} from '../models';
 
 export function AddAvailableSandbox(arg1:string):Promise<main.AppState>;
 
+export function AddFavorite(arg1:string):Promise<main.AppState>;
+
 export function CanGoBack():Promise<boolean>;
 
+export function ClearLaunchHistory():Promise<main.AppState>;
+
+export function CreateWorkspace(arg1:string,arg2:boolean):Promise<main.AppState>;
+
+export function DeleteBoxContents(arg1:string,arg2:string):Promise<main.BoxOperationResult>;
+
+export function DeleteWorkspace(arg1:string):Promise<main.AppState>;
+
+export function DismissConfigWarnings():Promise<void>;
+
+export function ExportConfig(arg1:string,arg2:Array<string>):Promise<void>;
+
 export function GetAppState():Promise<main.AppState>;
 
 export function GetAvailableSandboxes():Promise<Array<string>>;
 
+export function GetFavorites():Promise<Array<main.Favorite>>;
+
 export function GetFileIcon(arg1:string):Promise<string>;
 
+export function GetFileIcons(arg1:Array<string>,arg2:number):Promise<Record<string, string>>;
+
+export function GetFileTypeSettings():Promise<main.FileTypeSettings>;
+
+export function GetLaunchHistory(arg1:number):Promise<Array<main.LaunchRecord>>;
+
+export function GetLaunchProfile(arg1:string):Promise<main.LaunchOptions>;
+
+export function GetProgramHistory(arg1:string):Promise<Array<main.LaunchRecord>>;
+
+export function GetRunningPrograms(arg1:string):Promise<Array<main.RunningProgram>>;
+
+export function GetSandboxBindings():Promise<Array<main.SandboxBinding>>;
+
+export function GetSandboxes():Promise<Array<main.SandboxInfo>>;
+
+export function GetSandboxieStatus():Promise<main.SandboxieStatus>;
+
+export function GetShortcutInfo(arg1:string):Promise<main.ShellLink>;
+
 export function GoBack():Promise<main.AppState>;
 
+export function ImportConfig(arg1:string,arg2:string):Promise<Array<main.ImportChange>>;
+
 export function IsSandboxieAvailable():Promise<boolean>;
 
 export function LaunchProgram(arg1:string):Promise<main.LaunchResponse>;
 
+export function LaunchProgramWithOptions(arg1:string,arg2:main.LaunchOptions):Promise<main.LaunchResponse>;
+
+export function MoveFavorite(arg1:string,arg2:number):Promise<main.AppState>;
+
 export function OpenConfigFile():Promise<void>;
 
 export function OpenFolder(arg1:string):Promise<main.AppState>;
@@ -26,12 +68,44 @@ export function OpenFolderDialog():Promise<string>;
 
 export function OpenSandboxieManager():Promise<void>;
 
+export function PreviewImportConfig(arg1:string,arg2:string):Promise<Array<main.ImportChange>>;
+
+export function RedetectSandboxie():Promise<main.SandboxieStatus>;
+
 export function RemoveAvailableSandbox(arg1:string):Promise<main.AppState>;
 
+export function RemoveFavorite(arg1:string):Promise<main.AppState>;
+
 export function RemoveFolder(arg1:string):Promise<main.AppState>;
 
+export function RemoveLaunchProfile(arg1:string):Promise<void>;
+
+export function RemoveSandboxBinding(arg1:string):Promise<main.AppState>;
+
+export function RenameWorkspace(arg1:string,arg2:string):Promise<main.AppState>;
+
+export function RequestDeleteConfirmation(arg1:string):Promise<main.DeleteConfirmation>;
+
+export function Search(arg1:string):Promise<Array<main.SearchResult>>;
+
 export function SelectFolder(arg1:string):Promise<main.AppState>;
 
 export function SetCurrentFolder(arg1:string):Promise<main.AppState>;
 
+export function SetFileTypeSettings(arg1:main.FileTypeSettings):Promise<main.AppState>;
+
+export function SetLaunchProfile(arg1:string,arg2:main.LaunchOptions):Promise<void>;
+
+export function SetSandboxBinding(arg1:string,arg2:string,arg3:string):Promise<main.AppState>;
+
+export function SetSandboxiePath(arg1:string):Promise<main.SandboxieStatus>;
+
 export function SetSelectedSandbox(arg1:string):Promise<main.AppState>;
+
+export function SwitchWorkspace(arg1:string):Promise<main.AppState>;
+
+export function TerminateAndDelete(arg1:string,arg2:string):Promise<Array<main.BoxOperationResult>>;
+
+export function TerminateBox(arg1:string):Promise<main.BoxOperationResult>;
+
+export function UpdateFavorite(arg1:string,arg2:string,arg3:string):Promise<main.AppState>;
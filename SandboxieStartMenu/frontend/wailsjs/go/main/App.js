@@ -6,10 +6,38 @@ export function AddAvailableSandbox(arg1) {
   return window['go']['main']['App']['AddAvailableSandbox'](arg1);
 }
 
+export function AddFavorite(arg1) {
+  return window['go']['main']['App']['AddFavorite'](arg1);
+}
+
 export function CanGoBack() {
   return window['go']['main']['App']['CanGoBack']();
 }
 
+export function ClearLaunchHistory() {
+  return window['go']['main']['App']['ClearLaunchHistory']();
+}
+
+export function CreateWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['CreateWorkspace'](arg1, arg2);
+}
+
+export function DeleteBoxContents(arg1, arg2) {
+  return window['go']['main']['App']['DeleteBoxContents'](arg1, arg2);
+}
+
+export function DeleteWorkspace(arg1) {
+  return window['go']['main']['App']['DeleteWorkspace'](arg1);
+}
+
+export function DismissConfigWarnings() {
+  return window['go']['main']['App']['DismissConfigWarnings']();
+}
+
+export function ExportConfig(arg1, arg2) {
+  return window['go']['main']['App']['ExportConfig'](arg1, arg2);
+}
+
 export function GetAppState() {
   return window['go']['main']['App']['GetAppState']();
 }
@@ -18,14 +46,62 @@ export function GetAvailableSandboxes() {
   return window['go']['main']['App']['GetAvailableSandboxes']();
 }
 
+export function GetFavorites() {
+  return window['go']['main']['App']['GetFavorites']();
+}
+
 export function GetFileIcon(arg1) {
   return window['go']['main']['App']['GetFileIcon'](arg1);
 }
 
+export function GetFileIcons(arg1, arg2) {
+  return window['go']['main']['App']['GetFileIcons'](arg1, arg2);
+}
+
+export function GetFileTypeSettings() {
+  return window['go']['main']['App']['GetFileTypeSettings']();
+}
+
+export function GetLaunchHistory(arg1) {
+  return window['go']['main']['App']['GetLaunchHistory'](arg1);
+}
+
+export function GetLaunchProfile(arg1) {
+  return window['go']['main']['App']['GetLaunchProfile'](arg1);
+}
+
+export function GetProgramHistory(arg1) {
+  return window['go']['main']['App']['GetProgramHistory'](arg1);
+}
+
+export function GetRunningPrograms(arg1) {
+  return window['go']['main']['App']['GetRunningPrograms'](arg1);
+}
+
+export function GetSandboxBindings() {
+  return window['go']['main']['App']['GetSandboxBindings']();
+}
+
+export function GetSandboxes() {
+  return window['go']['main']['App']['GetSandboxes']();
+}
+
+export function GetSandboxieStatus() {
+  return window['go']['main']['App']['GetSandboxieStatus']();
+}
+
+export function GetShortcutInfo(arg1) {
+  return window['go']['main']['App']['GetShortcutInfo'](arg1);
+}
+
 export function GoBack() {
   return window['go']['main']['App']['GoBack']();
 }
 
+export function ImportConfig(arg1, arg2) {
+  return window['go']['main']['App']['ImportConfig'](arg1, arg2);
+}
+
 export function IsSandboxieAvailable() {
   return window['go']['main']['App']['IsSandboxieAvailable']();
 }
@@ -34,6 +110,14 @@ export function LaunchProgram(arg1) {
   return window['go']['main']['App']['LaunchProgram'](arg1);
 }
 
+export function LaunchProgramWithOptions(arg1, arg2) {
+  return window['go']['main']['App']['LaunchProgramWithOptions'](arg1, arg2);
+}
+
+export function MoveFavorite(arg1, arg2) {
+  return window['go']['main']['App']['MoveFavorite'](arg1, arg2);
+}
+
 export function OpenConfigFile() {
   return window['go']['main']['App']['OpenConfigFile']();
 }
@@ -50,14 +134,46 @@ export function OpenSandboxieManager() {
   return window['go']['main']['App']['OpenSandboxieManager']();
 }
 
+export function PreviewImportConfig(arg1, arg2) {
+  return window['go']['main']['App']['PreviewImportConfig'](arg1, arg2);
+}
+
+export function RedetectSandboxie() {
+  return window['go']['main']['App']['RedetectSandboxie']();
+}
+
 export function RemoveAvailableSandbox(arg1) {
   return window['go']['main']['App']['RemoveAvailableSandbox'](arg1);
 }
 
+export function RemoveFavorite(arg1) {
+  return window['go']['main']['App']['RemoveFavorite'](arg1);
+}
+
 export function RemoveFolder(arg1) {
   return window['go']['main']['App']['RemoveFolder'](arg1);
 }
 
+export function RemoveLaunchProfile(arg1) {
+  return window['go']['main']['App']['RemoveLaunchProfile'](arg1);
+}
+
+export function RemoveSandboxBinding(arg1) {
+  return window['go']['main']['App']['RemoveSandboxBinding'](arg1);
+}
+
+export function RenameWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['RenameWorkspace'](arg1, arg2);
+}
+
+export function RequestDeleteConfirmation(arg1) {
+  return window['go']['main']['App']['RequestDeleteConfirmation'](arg1);
+}
+
+export function Search(arg1) {
+  return window['go']['main']['App']['Search'](arg1);
+}
+
 export function SelectFolder(arg1) {
   return window['go']['main']['App']['SelectFolder'](arg1);
 }
@@ -66,6 +182,38 @@ export function SetCurrentFolder(arg1) {
   return window['go']['main']['App']['SetCurrentFolder'](arg1);
 }
 
+export function SetFileTypeSettings(arg1) {
+  return window['go']['main']['App']['SetFileTypeSettings'](arg1);
+}
+
+export function SetLaunchProfile(arg1, arg2) {
+  return window['go']['main']['App']['SetLaunchProfile'](arg1, arg2);
+}
+
+export function SetSandboxBinding(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetSandboxBinding'](arg1, arg2, arg3);
+}
+
+export function SetSandboxiePath(arg1) {
+  return window['go']['main']['App']['SetSandboxiePath'](arg1);
+}
+
 export function SetSelectedSandbox(arg1) {
   return window['go']['main']['App']['SetSelectedSandbox'](arg1);
 }
+
+export function SwitchWorkspace(arg1) {
+  return window['go']['main']['App']['SwitchWorkspace'](arg1);
+}
+
+export function TerminateAndDelete(arg1, arg2) {
+  return window['go']['main']['App']['TerminateAndDelete'](arg1, arg2);
+}
+
+export function TerminateBox(arg1) {
+  return window['go']['main']['App']['TerminateBox'](arg1);
+}
+
+export function UpdateFavorite(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateFavorite'](arg1, arg2, arg3);
+}
@@ -1,11 +1,45 @@
 export namespace main {
 	
+	export class ShellLink {
+	    target: string;
+	    arguments: string;
+	    workingDir: string;
+	    iconLocation: string;
+	    iconIndex: number;
+	    description: string;
+	    relativePath: string;
+	    showCommand: number;
+	    advertised: boolean;
+	    broken: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShellLink(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = source["target"];
+	        this.arguments = source["arguments"];
+	        this.workingDir = source["workingDir"];
+	        this.iconLocation = source["iconLocation"];
+	        this.iconIndex = source["iconIndex"];
+	        this.description = source["description"];
+	        this.relativePath = source["relativePath"];
+	        this.showCommand = source["showCommand"];
+	        this.advertised = source["advertised"];
+	        this.broken = source["broken"];
+	    }
+	}
 	export class FileInfo {
 	    name: string;
 	    path: string;
 	    type: string;
 	    icon?: string;
 	    isDir: boolean;
+	    sandbox?: string;
+	    shortcut?: ShellLink;
+	    url?: string;
+	    favorite?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new FileInfo(source);
@@ -18,6 +52,81 @@ export namespace main {
 	        this.type = source["type"];
 	        this.icon = source["icon"];
 	        this.isDir = source["isDir"];
+	        this.sandbox = source["sandbox"];
+	        this.shortcut = this.convertValues(source["shortcut"], ShellLink);
+	        this.url = source["url"];
+	        this.favorite = source["favorite"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SandboxInfo {
+	    name: string;
+	    enabled: boolean;
+	    fileRootPath: string;
+	    configLevel: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SandboxInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.fileRootPath = source["fileRootPath"];
+	        this.configLevel = source["configLevel"];
+	    }
+	}
+	export class VirtualFolder {
+	    id: string;
+	    name: string;
+	    icon: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VirtualFolder(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.icon = source["icon"];
+	    }
+	}
+	export class SandboxieStatus {
+	    available: boolean;
+	    startExePath: string;
+	    source: string;
+	    tried: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SandboxieStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.startExePath = source["startExePath"];
+	        this.source = source["source"];
+	        this.tried = source["tried"];
 	    }
 	}
 	export class AppState {
@@ -26,6 +135,12 @@ export namespace main {
 	    files: FileInfo[];
 	    selectedSandbox: string;
 	    availableSandboxes: string[];
+	    sandboxes: SandboxInfo[];
+	    virtualFolders: VirtualFolder[];
+	    sandboxie: SandboxieStatus;
+	    configWarnings?: string[];
+	    activeWorkspace: string;
+	    workspaces: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new AppState(source);
@@ -38,6 +153,12 @@ export namespace main {
 	        this.files = this.convertValues(source["files"], FileInfo);
 	        this.selectedSandbox = source["selectedSandbox"];
 	        this.availableSandboxes = source["availableSandboxes"];
+	        this.sandboxes = this.convertValues(source["sandboxes"], SandboxInfo);
+	        this.virtualFolders = this.convertValues(source["virtualFolders"], VirtualFolder);
+	        this.sandboxie = this.convertValues(source["sandboxie"], SandboxieStatus);
+	        this.configWarnings = source["configWarnings"];
+	        this.activeWorkspace = source["activeWorkspace"];
+	        this.workspaces = source["workspaces"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -59,10 +180,236 @@ export namespace main {
 		}
 	}
 	
-	export class LaunchResponse {
+	export class BoxOperationResult {
+	    box: string;
+	    operation: string;
 	    success: boolean;
 	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BoxOperationResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.box = source["box"];
+	        this.operation = source["operation"];
+	        this.success = source["success"];
+	        this.message = source["message"];
+	    }
+	}
+	export class Favorite {
+	    path: string;
+	    name?: string;
+	    sandbox?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Favorite(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.sandbox = source["sandbox"];
+	    }
+	}
+	export class FileType {
+	    extension: string;
+	    launch: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileType(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.extension = source["extension"];
+	        this.launch = source["launch"];
+	    }
+	}
+	export class FileTypeSettings {
+	    fileTypes: FileType[];
+	    hideRules: string[];
+	    showAllFiles: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileTypeSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fileTypes = this.convertValues(source["fileTypes"], FileType);
+	        this.hideRules = source["hideRules"];
+	        this.showAllFiles = source["showAllFiles"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LaunchRecord {
+	    path: string;
+	    sandbox: string;
+	    args: string[];
+	    timestamp: any;
 	    pid: number;
+	    success: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.sandbox = source["sandbox"];
+	        this.args = source["args"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.pid = source["pid"];
+	        this.success = source["success"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LaunchOptions {
+	    args: string[];
+	    workingDir: string;
+	    env: Record<string, string>;
+	    hideWindow: boolean;
+	    wait: boolean;
+	    elevate: boolean;
+	    silent: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.args = source["args"];
+	        this.workingDir = source["workingDir"];
+	        this.env = source["env"];
+	        this.hideWindow = source["hideWindow"];
+	        this.wait = source["wait"];
+	        this.elevate = source["elevate"];
+	        this.silent = source["silent"];
+	    }
+	}
+	export class RunningProgram {
+	    pid: number;
+	    box: string;
+	    path: string;
+	    name: string;
+	    launchedAs: string;
+	    startedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RunningProgram(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.box = source["box"];
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.launchedAs = source["launchedAs"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SandboxBinding {
+	    pattern: string;
+	    matchType: string;
+	    sandbox: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SandboxBinding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pattern = source["pattern"];
+	        this.matchType = source["matchType"];
+	        this.sandbox = source["sandbox"];
+	    }
+	}
+	export class ImportChange {
+	    section: string;
+	    action: string;
+	    item: string;
+	    from?: string;
+	    to?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.section = source["section"];
+	        this.action = source["action"];
+	        this.item = source["item"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class LaunchResponse {
+	    success: boolean;
+	    message: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new LaunchResponse(source);
@@ -72,9 +419,77 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.success = source["success"];
 	        this.message = source["message"];
-	        this.pid = source["pid"];
 	    }
 	}
+	export class DeleteConfirmation {
+	    box: string;
+	    token: string;
+	    expiresAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteConfirmation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.box = source["box"];
+	        this.token = source["token"];
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SearchResult {
+	    file: FileInfo;
+	    root: string;
+	    score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = this.convertValues(source["file"], FileInfo);
+	        this.root = source["root"];
+	        this.score = source["score"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
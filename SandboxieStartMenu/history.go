@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxHistoryRecords = 1000 // Oldest launches are dropped beyond this
+
+// LaunchRecord is one entry of the launch history
+type LaunchRecord struct {
+	Path      string    `json:"path"`
+	Sandbox   string    `json:"sandbox"`
+	Args      []string  `json:"args"`
+	Timestamp time.Time `json:"timestamp"`
+	PID       int       `json:"pid"` // Program's PID once the process tracker has seen it, 0 until then
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// HistoryManager records launches and persists them next to the config
+type HistoryManager struct {
+	mu      sync.Mutex
+	path    string
+	records []LaunchRecord // Oldest first
+}
+
+// NewHistoryManager creates a history manager backed by history.json
+func NewHistoryManager() *HistoryManager {
+	hm := &HistoryManager{
+		path: filepath.Join(getConfigDir(), "history.json"),
+	}
+	hm.Load()
+	return hm
+}
+
+// Load loads the history from disk. A missing file means no history.
+func (hm *HistoryManager) Load() error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	data, err := os.ReadFile(hm.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			hm.records = nil
+			return nil
+		}
+		return err
+	}
+
+	var records []LaunchRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	hm.records = records
+	return nil
+}
+
+// saveLocked writes the history to disk; hm.mu must be held
+func (hm *HistoryManager) saveLocked() error {
+	data, err := json.MarshalIndent(hm.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(hm.path, data, 0644)
+}
+
+// Record appends a launch to the history
+func (hm *HistoryManager) Record(record LaunchRecord) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	hm.records = append(hm.records, record)
+	if len(hm.records) > maxHistoryRecords {
+		hm.records = hm.records[len(hm.records)-maxHistoryRecords:]
+	}
+	return hm.saveLocked()
+}
+
+// SetPID stores the PID of the program started by the launch of path at
+// timestamp
+func (hm *HistoryManager) SetPID(path string, timestamp time.Time, pid int) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := len(hm.records) - 1; i >= 0; i-- {
+		r := &hm.records[i]
+		if r.Timestamp.Equal(timestamp) && strings.EqualFold(r.Path, path) {
+			r.PID = pid
+			return hm.saveLocked()
+		}
+	}
+	return nil
+}
+
+// Clear removes all history
+func (hm *HistoryManager) Clear() error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	hm.records = nil
+	return hm.saveLocked()
+}
+
+// History returns up to limit launches, newest first. limit <= 0 returns all.
+func (hm *HistoryManager) History(limit int) []LaunchRecord {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	records := []LaunchRecord{}
+	for i := len(hm.records) - 1; i >= 0; i-- {
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+		records = append(records, hm.records[i])
+	}
+	return records
+}
+
+// HistoryFor returns the launches of one program, newest first
+func (hm *HistoryManager) HistoryFor(path string) []LaunchRecord {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	records := []LaunchRecord{}
+	for i := len(hm.records) - 1; i >= 0; i-- {
+		if strings.EqualFold(hm.records[i].Path, path) {
+			records = append(records, hm.records[i])
+		}
+	}
+	return records
+}
+
+// Recent returns the most recently launched programs, each listed once
+func (hm *HistoryManager) Recent(limit int) []string {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	seen := make(map[string]bool)
+	paths := []string{}
+	for i := len(hm.records) - 1; i >= 0 && (limit <= 0 || len(paths) < limit); i-- {
+		r := hm.records[i]
+		key := strings.ToLower(r.Path)
+		if !r.Success || seen[key] {
+			continue
+		}
+		seen[key] = true
+		paths = append(paths, r.Path)
+	}
+	return paths
+}
+
+// MostUsed returns programs ranked by frecency: every successful launch
+// counts, with recent launches weighted more than old ones
+func (hm *HistoryManager) MostUsed(limit int, now time.Time) []string {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	type ranked struct {
+		path  string
+		score int
+		last  time.Time
+	}
+	byPath := make(map[string]*ranked)
+	for _, r := range hm.records {
+		if !r.Success {
+			continue
+		}
+		key := strings.ToLower(r.Path)
+		entry, ok := byPath[key]
+		if !ok {
+			entry = &ranked{}
+			byPath[key] = entry
+		}
+		entry.path = r.Path
+		entry.score += frecencyWeight(now.Sub(r.Timestamp))
+		if r.Timestamp.After(entry.last) {
+			entry.last = r.Timestamp
+		}
+	}
+
+	list := make([]*ranked, 0, len(byPath))
+	for _, entry := range byPath {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].last.After(list[j].last)
+	})
+
+	paths := []string{}
+	for _, entry := range list {
+		if limit > 0 && len(paths) >= limit {
+			break
+		}
+		paths = append(paths, entry.path)
+	}
+	return paths
+}
+
+// frecencyWeight returns the weight of a launch that happened age ago
+func frecencyWeight(age time.Duration) int {
+	day := 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	}
+	return 10
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistorySetPID(t *testing.T) {
+	t.Setenv("APPDATA", t.TempDir())
+	hm := NewHistoryManager()
+
+	launched := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	hm.Record(LaunchRecord{Path: `C:\Menu\App.lnk`, Timestamp: launched, Success: true})
+	hm.Record(LaunchRecord{Path: `C:\Menu\App.lnk`, Timestamp: launched.Add(time.Minute), Success: true})
+
+	// The PID goes to the launch that started the program, and is saved
+	if err := hm.SetPID(`c:\menu\app.lnk`, launched, 4242); err != nil {
+		t.Fatalf("SetPID: %v", err)
+	}
+	hm.SetPID(`C:\Menu\Other.lnk`, launched, 1)
+
+	reloaded := NewHistoryManager()
+	records := reloaded.History(0)
+	if len(records) != 2 {
+		t.Fatalf("History = %d records, want 2", len(records))
+	}
+	if records[0].PID != 0 || records[1].PID != 4242 {
+		t.Errorf("PIDs = %d, %d, want 0, 4242", records[0].PID, records[1].PID)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "image"
+
+// extractFileIconImage reads the icon straight from the file, as there is
+// no shell to ask outside Windows
+func extractFileIconImage(filePath string, size int) (image.Image, error) {
+	return ReadFileIcon(filePath, size)
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/lxn/win"
+	"golang.org/x/sys/windows"
+)
+
+// 定义 SHFILEINFO 结构体
+type SHFILEINFO struct {
+	HIcon         windows.Handle
+	IIcon         int32
+	DwAttributes  uint32
+	SzDisplayName [windows.MAX_PATH]uint16
+	SzTypeName    [80]uint16
+}
+
+const (
+	SHGFI_ICON         = 0x00000100 // 获取图标句柄
+	SHGFI_SYSICONINDEX = 0x00004000 // 获取系统图像列表中的索引
+	SHGFI_LARGEICON    = 0x00000000 // 获取大图标 (32x32)
+	SHGFI_SMALLICON    = 0x00000001 // 获取小图标 (16x16)
+
+	// SHGetImageList 的图像列表
+	SHIL_LARGE      = 0x0 // 32x32
+	SHIL_SMALL      = 0x1 // 16x16
+	SHIL_EXTRALARGE = 0x2 // 48x48
+	SHIL_JUMBO      = 0x4 // 256x256
+
+	ILD_TRANSPARENT = 0x00000001
+)
+
+var (
+	shell32             = windows.NewLazySystemDLL("shell32.dll")
+	shGetFileInfo       = shell32.NewProc("SHGetFileInfoW")
+	shGetImageList      = shell32.NewProc("SHGetImageList")
+	user32              = windows.NewLazySystemDLL("user32.dll")
+	destroyIcon         = user32.NewProc("DestroyIcon")
+	privateExtractIcons = user32.NewProc("PrivateExtractIconsW")
+
+	// IImageList 接口 ID
+	iidIImageList = windows.GUID{Data1: 0x46EB5926, Data2: 0x582E, Data3: 0x4017, Data4: [8]byte{0x9F, 0xDF, 0xE8, 0x99, 0x8D, 0xAA, 0x09, 0x50}}
+)
+
+// iImageList 是 COM IImageList 接口
+type iImageList struct {
+	vtbl *iImageListVtbl
+}
+
+// iImageListVtbl 是 IImageList 的虚函数表（只用到 Release 和 GetIcon）
+type iImageListVtbl struct {
+	QueryInterface  uintptr
+	AddRef          uintptr
+	Release         uintptr
+	Add             uintptr
+	ReplaceIcon     uintptr
+	SetOverlayImage uintptr
+	Replace         uintptr
+	AddMasked       uintptr
+	Draw            uintptr
+	Remove          uintptr
+	GetIcon         uintptr
+}
+
+// peIconExtensions 是可以直接读取图标资源的文件类型
+var peIconExtensions = map[string]bool{".exe": true, ".dll": true, ".ico": true, ".cpl": true}
+
+// extractFileIconImage 按质量从高到低尝试各种提取方式，返回最接近 size 的图标
+func extractFileIconImage(filePath string, size int) (image.Image, error) {
+	// 大尺寸优先从程序资源中读取原始图标，避免系统列表放大小图标
+	if size > IconSizeLarge && peIconExtensions[strings.ToLower(filepath.Ext(filePath))] {
+		if hIcon, err := extractResourceIcon(filePath, size); err == nil {
+			defer destroyIcon.Call(uintptr(hIcon))
+			if img, err := hIconToImage(win.HICON(hIcon)); err == nil {
+				return img, nil
+			}
+		}
+	}
+
+	// 系统图像列表（包含快捷方式箭头等叠加图标）
+	if hIcon, err := getImageListIcon(filePath, size); err == nil {
+		defer destroyIcon.Call(uintptr(hIcon))
+		if img, err := hIconToImage(win.HICON(hIcon)); err == nil {
+			return img, nil
+		}
+	}
+
+	// SHGetFileInfo 的 32x32 / 16x16 图标
+	if hIcon, err := getFileIconHandle(filePath); err == nil {
+		defer destroyIcon.Call(uintptr(hIcon))
+		if img, err := hIconToImage(win.HICON(hIcon)); err == nil {
+			return img, nil
+		}
+	}
+
+	// 最后不经过 shell 直接解析文件中的图标资源
+	return ReadFileIcon(filePath, size)
+}
+
+// imageListForSize 返回能提供 size 图标的最小系统图像列表
+func imageListForSize(size int) int {
+	switch {
+	case size <= IconSizeSmall:
+		return SHIL_SMALL
+	case size <= IconSizeLarge:
+		return SHIL_LARGE
+	case size <= IconSizeExtraLarge:
+		return SHIL_EXTRALARGE
+	default:
+		return SHIL_JUMBO
+	}
+}
+
+// getImageListIcon 从系统图像列表中获取文件图标
+func getImageListIcon(path string, size int) (windows.Handle, error) {
+	var shfi SHFILEINFO
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// 获取文件在系统图像列表中的索引
+	ret, _, _ := shGetFileInfo.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		0,
+		uintptr(unsafe.Pointer(&shfi)),
+		uintptr(unsafe.Sizeof(shfi)),
+		SHGFI_SYSICONINDEX,
+	)
+	if ret == 0 {
+		return 0, fmt.Errorf("failed to get icon index for: %s", path)
+	}
+
+	var list *iImageList
+	hr, _, _ := shGetImageList.Call(
+		uintptr(imageListForSize(size)),
+		uintptr(unsafe.Pointer(&iidIImageList)),
+		uintptr(unsafe.Pointer(&list)),
+	)
+	if hr != 0 || list == nil {
+		return 0, fmt.Errorf("SHGetImageList failed: 0x%x", hr)
+	}
+	defer syscall.SyscallN(list.vtbl.Release, uintptr(unsafe.Pointer(list)))
+
+	var hIcon windows.Handle
+	hr, _, _ = syscall.SyscallN(list.vtbl.GetIcon,
+		uintptr(unsafe.Pointer(list)),
+		uintptr(shfi.IIcon),
+		ILD_TRANSPARENT,
+		uintptr(unsafe.Pointer(&hIcon)),
+	)
+	if hr != 0 || hIcon == 0 {
+		return 0, fmt.Errorf("IImageList::GetIcon failed: 0x%x", hr)
+	}
+	return hIcon, nil
+}
+
+// extractResourceIcon 从 PE 文件或 .ico 文件中提取最接近 size 的图标
+func extractResourceIcon(path string, size int) (windows.Handle, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var hIcon windows.Handle
+	var iconID uint32
+	ret, _, _ := privateExtractIcons.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		0,
+		uintptr(size),
+		uintptr(size),
+		uintptr(unsafe.Pointer(&hIcon)),
+		uintptr(unsafe.Pointer(&iconID)),
+		1,
+		0,
+	)
+	if ret == 0 || ret == 0xFFFFFFFF || hIcon == 0 {
+		return 0, fmt.Errorf("no icon resource in: %s", path)
+	}
+	return hIcon, nil
+}
+
+// getFileIconHandle 获取文件的图标句柄
+func getFileIconHandle(path string) (windows.Handle, error) {
+	var shfi SHFILEINFO
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// 调用 SHGetFileInfo
+	ret, _, _ := shGetFileInfo.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		0,
+		uintptr(unsafe.Pointer(&shfi)),
+		uintptr(unsafe.Sizeof(shfi)),
+		SHGFI_ICON|SHGFI_LARGEICON,
+	)
+
+	if ret == 0 {
+		// 尝试小图标
+		ret, _, _ = shGetFileInfo.Call(
+			uintptr(unsafe.Pointer(pathPtr)),
+			0,
+			uintptr(unsafe.Pointer(&shfi)),
+			uintptr(unsafe.Sizeof(shfi)),
+			SHGFI_ICON|SHGFI_SMALLICON,
+		)
+
+		if ret == 0 {
+			return 0, fmt.Errorf("failed to get icon for: %s", path)
+		}
+	}
+
+	return shfi.HIcon, nil
+}
+
+// hIconToImage 将Windows的HICON句柄转换为Go的image.Image
+func hIconToImage(hIcon win.HICON) (image.Image, error) {
+	var iconInfo win.ICONINFO
+	if !win.GetIconInfo(hIcon, &iconInfo) {
+		return nil, fmt.Errorf("GetIconInfo failed")
+	}
+	defer win.DeleteObject(win.HGDIOBJ(iconInfo.HbmColor))
+	defer win.DeleteObject(win.HGDIOBJ(iconInfo.HbmMask))
+	if iconInfo.HbmColor == 0 {
+		// 单色图标
+		return nil, fmt.Errorf("monochrome icons are not supported")
+	}
+
+	var bm win.BITMAP
+	win.GetObject(win.HGDIOBJ(iconInfo.HbmColor), unsafe.Sizeof(bm), unsafe.Pointer(&bm))
+
+	width := int(bm.BmWidth)
+	height := int(bm.BmHeight)
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid icon size: %dx%d", width, height)
+	}
+
+	hdc := win.GetDC(0)
+	defer win.ReleaseDC(0, hdc)
+
+	// 创建位图信息头
+	var bi win.BITMAPINFOHEADER
+	bi.BiSize = uint32(unsafe.Sizeof(bi))
+	bi.BiWidth = bm.BmWidth
+	bi.BiHeight = -bm.BmHeight // 负值表示自上而下
+	bi.BiPlanes = 1
+	bi.BiBitCount = 32
+	bi.BiCompression = win.BI_RGB
+
+	// 分配像素缓冲区
+	pixels := make([]byte, width*height*4)
+	win.GetDIBits(hdc, iconInfo.HbmColor, 0, uint32(height), (*byte)(unsafe.Pointer(&pixels[0])), (*win.BITMAPINFO)(unsafe.Pointer(&bi)), win.DIB_RGB_COLORS)
+
+	// 没有 Alpha 通道的旧图标使用 AND 掩码表示透明
+	mask := make([]byte, width*height*4)
+	if win.GetDIBits(hdc, iconInfo.HbmMask, 0, uint32(height), (*byte)(unsafe.Pointer(&mask[0])), (*win.BITMAPINFO)(unsafe.Pointer(&bi)), win.DIB_RGB_COLORS) == 0 {
+		mask = nil
+	}
+
+	// 转换为Go的图像
+	return imageFromBGRA(pixels, mask, width, height), nil
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	iconMemoryLimit = 16 << 20 // Bytes of data URIs kept in memory
+	iconDiskLimit   = 64 << 20 // Bytes of PNGs kept in the disk cache
+	iconFileSuffix  = ".icon"
+	defaultIconSize = IconSizeLarge
+)
+
+// iconFileMagic starts every disk cache entry, followed by the source
+// file's stamp (three little endian int64s) and the PNG data
+var iconFileMagic = []byte("SSMICON2")
+
+// iconHeaderSize is the size of the disk entry header
+var iconHeaderSize = len(iconFileMagic) + 24
+
+// iconStamp identifies one version of a source file and of the files its
+// icon comes from
+type iconStamp struct {
+	modTime int64
+	size    int64
+	deps    int64 // Hash of the shortcut target and icon file versions
+}
+
+// newIconStamp returns the stamp of path, whose os.Stat is info
+func newIconStamp(path string, info os.FileInfo) iconStamp {
+	return iconStamp{
+		modTime: info.ModTime().UnixNano(),
+		size:    info.Size(),
+		deps:    iconDependencyHash(iconDependencies(path)),
+	}
+}
+
+// iconDependencies returns the other files a shortcut's icon is read
+// from, so a changed target or icon file invalidates the cached icon
+func iconDependencies(path string) []string {
+	if strings.EqualFold(filepath.Ext(path), ".lnk") {
+		link, err := ReadShellLink(path)
+		if err != nil {
+			return nil
+		}
+		return []string{link.Target, link.IconLocation}
+	}
+	if isInternetShortcut(path) {
+		shortcut, err := ReadInternetShortcut(path)
+		if err != nil {
+			return nil
+		}
+		return []string{shortcut.IconFile}
+	}
+	return nil
+}
+
+// iconDependencyHash hashes the paths, mtimes and sizes of files; missing
+// files count too, so their appearance is noticed
+func iconDependencyHash(paths []string) int64 {
+	if len(paths) == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	for _, path := range paths {
+		var modTime, size int64 = -1, -1
+		if info, err := os.Stat(path); err == nil {
+			modTime, size = info.ModTime().UnixNano(), info.Size()
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", strings.ToLower(path), modTime, size)
+	}
+	return int64(h.Sum64())
+}
+
+// iconMemEntry is one data URI in the memory tier
+type iconMemEntry struct {
+	key     string
+	dataURI string
+}
+
+// iconCall is an extraction in progress that other callers can wait for
+type iconCall struct {
+	done    chan struct{}
+	dataURI string
+}
+
+// IconCache caches extracted icons in memory (LRU) and on disk. Entries
+// are keyed by path and icon size and carry the mtime and size of the
+// source file and, for shortcuts, of their target and icon file, so an
+// updated program gets a fresh icon. c.mu only guards the in-memory state;
+// disk I/O runs without it.
+type IconCache struct {
+	mu          sync.Mutex
+	dir         string
+	memoryLimit int
+	diskLimit   int64
+
+	lru        *list.List               // Front is most recently used
+	entries    map[string]*list.Element // Memory key -> element
+	memoryUsed int
+	diskUsed   int64
+	inflight   map[string]*iconCall // Memory key -> running extraction
+	pruning    bool                 // A disk prune is running
+	pruneAgain bool                 // Another prune was asked for meanwhile
+}
+
+// NewIconCache creates an icon cache stored in dir. Stale and excess disk
+// entries are cleaned up in the background.
+func NewIconCache(dir string, memoryLimit int, diskLimit int64) *IconCache {
+	c := &IconCache{
+		dir:         dir,
+		memoryLimit: memoryLimit,
+		diskLimit:   diskLimit,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		inflight:    make(map[string]*iconCall),
+	}
+	os.MkdirAll(dir, 0755)
+
+	go c.pruneDisk()
+	return c
+}
+
+// pngDataURI returns PNG data as a data URI for the frontend
+func pngDataURI(pngData []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)
+}
+
+// iconDiskName returns the disk cache file name for a path and icon size
+func iconDiskName(path string, iconSize int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", strings.ToLower(filepath.Clean(path)), iconSize)))
+	return hex.EncodeToString(sum[:16]) + iconFileSuffix
+}
+
+// iconMemoryKey returns the memory tier key for one version of a file
+func iconMemoryKey(path string, iconSize int, stamp iconStamp) string {
+	return fmt.Sprintf("%s|%d|%d|%d|%x", strings.ToLower(filepath.Clean(path)), iconSize, stamp.modTime, stamp.size, stamp.deps)
+}
+
+// encodeIconEntry builds a disk cache entry
+func encodeIconEntry(stamp iconStamp, pngData []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(iconFileMagic)
+	binary.Write(&buf, binary.LittleEndian, stamp.modTime)
+	binary.Write(&buf, binary.LittleEndian, stamp.size)
+	binary.Write(&buf, binary.LittleEndian, stamp.deps)
+	buf.Write(pngData)
+	return buf.Bytes()
+}
+
+// decodeIconEntry splits a disk cache entry into its stamp and PNG data
+func decodeIconEntry(data []byte) (iconStamp, []byte, bool) {
+	if len(data) <= iconHeaderSize || !bytes.Equal(data[:len(iconFileMagic)], iconFileMagic) {
+		return iconStamp{}, nil, false
+	}
+	header := data[len(iconFileMagic):iconHeaderSize]
+	stamp := iconStamp{
+		modTime: int64(binary.LittleEndian.Uint64(header[0:8])),
+		size:    int64(binary.LittleEndian.Uint64(header[8:16])),
+		deps:    int64(binary.LittleEndian.Uint64(header[16:24])),
+	}
+	return stamp, data[iconHeaderSize:], true
+}
+
+// Icon returns the icon of path as a data URI, calling extract on a cache
+// miss. It returns an empty string when the icon can't be extracted.
+func (c *IconCache) Icon(path string, iconSize int, extract func(path string, size int) ([]byte, error)) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Nothing to key the cache on; let the shell try anyway
+		pngData, err := extract(path, iconSize)
+		if err != nil {
+			return ""
+		}
+		return pngDataURI(pngData)
+	}
+	stamp := newIconStamp(path, info)
+	memKey := iconMemoryKey(path, iconSize, stamp)
+
+	c.mu.Lock()
+	if dataURI, ok := c.getMemoryLocked(memKey); ok {
+		c.mu.Unlock()
+		return dataURI
+	}
+	if call, ok := c.inflight[memKey]; ok {
+		// Someone else (usually the prefetcher) is loading this icon
+		c.mu.Unlock()
+		<-call.done
+		return call.dataURI
+	}
+	call := &iconCall{done: make(chan struct{})}
+	c.inflight[memKey] = call
+	c.mu.Unlock()
+
+	// Read or extract without holding the lock; icons of other files stay
+	// available
+	pngData, ok := c.readDisk(path, iconSize, stamp)
+	if !ok {
+		var err error
+		if pngData, err = extract(path, iconSize); err == nil {
+			c.writeDisk(path, iconSize, stamp, pngData)
+			ok = true
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, memKey)
+	if ok {
+		call.dataURI = pngDataURI(pngData)
+		c.putMemoryLocked(memKey, call.dataURI)
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.dataURI
+}
+
+// getMemoryLocked looks up the memory tier; c.mu must be held
+func (c *IconCache) getMemoryLocked(key string) (string, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*iconMemEntry).dataURI, true
+}
+
+// putMemoryLocked adds a data URI to the memory tier, evicting the least
+// recently used entries over the limit; c.mu must be held
+func (c *IconCache) putMemoryLocked(key string, dataURI string) {
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&iconMemEntry{key: key, dataURI: dataURI})
+	c.memoryUsed += len(dataURI)
+
+	for c.memoryUsed > c.memoryLimit && c.lru.Len() > 1 {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*iconMemEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.memoryUsed -= len(entry.dataURI)
+	}
+}
+
+// readDisk loads a disk entry if it matches the source file's stamp.
+// Stale entries are deleted.
+func (c *IconCache) readDisk(path string, iconSize int, stamp iconStamp) ([]byte, bool) {
+	diskPath := filepath.Join(c.dir, iconDiskName(path, iconSize))
+	data, err := os.ReadFile(diskPath)
+	if err != nil {
+		return nil, false
+	}
+
+	cached, pngData, ok := decodeIconEntry(data)
+	if !ok || cached != stamp {
+		// The program was updated (or the entry is corrupt)
+		if os.Remove(diskPath) == nil {
+			c.addDiskUsed(-int64(len(data)))
+		}
+		return nil, false
+	}
+
+	// The file time doubles as the last use for eviction
+	now := time.Now()
+	os.Chtimes(diskPath, now, now)
+	return pngData, true
+}
+
+// writeDisk stores an entry on disk, pruning the disk tier when it grows
+// over its limit
+func (c *IconCache) writeDisk(path string, iconSize int, stamp iconStamp, pngData []byte) {
+	diskPath := filepath.Join(c.dir, iconDiskName(path, iconSize))
+	var replaced int64
+	if info, err := os.Stat(diskPath); err == nil {
+		replaced = info.Size()
+	}
+
+	data := encodeIconEntry(stamp, pngData)
+	if err := writeFileAtomic(diskPath, data, 0644); err != nil {
+		return
+	}
+	if c.addDiskUsed(int64(len(data))-replaced) > c.diskLimit {
+		c.pruneDisk()
+	}
+}
+
+// addDiskUsed adjusts the disk tier's size and returns the new size
+func (c *IconCache) addDiskUsed(delta int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diskUsed += delta
+	return c.diskUsed
+}
+
+// pruneDisk removes unreadable entries and, when the cache is over its
+// limit, the least recently used ones down to 80% of the limit. Only one
+// prune runs at a time; asking during a prune makes it scan again.
+func (c *IconCache) pruneDisk() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pruning {
+		c.pruneAgain = true
+		return
+	}
+	c.pruning = true
+	for {
+		c.pruneAgain = false
+		c.mu.Unlock()
+		total := c.scanDisk()
+		c.mu.Lock()
+		if total >= 0 {
+			c.diskUsed = total
+		}
+		if !c.pruneAgain {
+			break
+		}
+	}
+	c.pruning = false
+}
+
+// scanDisk does the file work of pruneDisk and returns the size left, or
+// -1 if the folder can't be read
+func (c *IconCache) scanDisk() int64 {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return -1
+	}
+
+	type diskEntry struct {
+		path   string
+		size   int64
+		usedAt time.Time
+	}
+	var files []diskEntry
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), iconFileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, entry.Name())
+		if info.Size() <= int64(iconHeaderSize) {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, diskEntry{path: path, size: info.Size(), usedAt: info.ModTime()})
+		total += info.Size()
+	}
+
+	if total > c.diskLimit {
+		sort.Slice(files, func(i, j int) bool { return files[i].usedAt.Before(files[j].usedAt) })
+		target := c.diskLimit * 8 / 10
+		for _, f := range files {
+			if total <= target {
+				break
+			}
+			if os.Remove(f.path) == nil {
+				total -= f.size
+			}
+		}
+	}
+	return total
+}
+
+// Invalidate drops all cached icons of path
+func (c *IconCache) Invalidate(path string) {
+	c.mu.Lock()
+	prefix := strings.ToLower(filepath.Clean(path)) + "|"
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.memoryUsed -= len(elem.Value.(*iconMemEntry).dataURI)
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, size := range iconSizes {
+		diskPath := filepath.Join(c.dir, iconDiskName(path, size))
+		if info, err := os.Stat(diskPath); err == nil && os.Remove(diskPath) == nil {
+			c.addDiskUsed(-info.Size())
+		}
+	}
+}
+
+// Clear empties both cache tiers
+func (c *IconCache) Clear() error {
+	c.mu.Lock()
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.memoryUsed = 0
+	c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), iconFileSuffix) {
+			os.Remove(filepath.Join(c.dir, entry.Name()))
+		}
+	}
+	c.mu.Lock()
+	c.diskUsed = 0
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingExtractor returns fake PNG data and counts its calls
+type countingExtractor struct {
+	calls atomic.Int32
+}
+
+func (e *countingExtractor) extract(path string, size int) ([]byte, error) {
+	e.calls.Add(1)
+	return []byte("png:" + filepath.Base(path)), nil
+}
+
+func writeTestFile(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIconCacheShortcutDependencies(t *testing.T) {
+	dir := t.TempDir()
+	iconFile := filepath.Join(dir, "site.ico")
+	shortcut := filepath.Join(dir, "Site.url")
+	writeTestFile(t, iconFile, "icon v1")
+	writeTestFile(t, shortcut, "[InternetShortcut]\r\nURL=https://example.com/\r\nIconFile="+iconFile+"\r\nIconIndex=0\r\n")
+
+	cache := NewIconCache(filepath.Join(dir, "cache"), iconMemoryLimit, iconDiskLimit)
+	var e countingExtractor
+	first := cache.Icon(shortcut, 32, e.extract)
+	if first == "" || cache.Icon(shortcut, 32, e.extract) != first || e.calls.Load() != 1 {
+		t.Fatalf("second lookup extracted again: %d calls", e.calls.Load())
+	}
+
+	// The shortcut itself is unchanged, but its icon file is not
+	writeTestFile(t, iconFile, "icon v2, larger")
+	cache.Icon(shortcut, 32, e.extract)
+	if e.calls.Load() != 2 {
+		t.Fatalf("changed icon file not noticed: %d calls", e.calls.Load())
+	}
+
+	// A new cache over the same folder reads the disk tier
+	reopened := NewIconCache(filepath.Join(dir, "cache"), iconMemoryLimit, iconDiskLimit)
+	if got := reopened.Icon(shortcut, 32, e.extract); got != first || e.calls.Load() != 2 {
+		t.Fatalf("disk tier missed: %d calls", e.calls.Load())
+	}
+
+	os.Remove(iconFile)
+	reopened.Icon(shortcut, 32, e.extract)
+	if e.calls.Load() != 3 {
+		t.Fatalf("removed icon file not noticed: %d calls", e.calls.Load())
+	}
+}
+
+func TestIconCacheSourceChange(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "app.exe")
+	writeTestFile(t, exe, "v1")
+
+	cache := NewIconCache(filepath.Join(dir, "cache"), iconMemoryLimit, iconDiskLimit)
+	var e countingExtractor
+	cache.Icon(exe, 32, e.extract)
+	cache.Icon(exe, 16, e.extract)
+	cache.Icon(exe, 32, e.extract)
+	if e.calls.Load() != 2 {
+		t.Fatalf("calls = %d, want one per size", e.calls.Load())
+	}
+
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(exe, later, later)
+	cache.Icon(exe, 32, e.extract)
+	if e.calls.Load() != 3 {
+		t.Fatalf("updated file not noticed: %d calls", e.calls.Load())
+	}
+
+	cache.Invalidate(exe)
+	cache.Icon(exe, 32, e.extract)
+	if e.calls.Load() != 4 {
+		t.Fatalf("Invalidate kept the icon: %d calls", e.calls.Load())
+	}
+}
+
+func TestIconCacheConcurrentLookups(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "app.exe")
+	writeTestFile(t, exe, "v1")
+	cache := NewIconCache(filepath.Join(dir, "cache"), iconMemoryLimit, iconDiskLimit)
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	extract := func(path string, size int) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("png"), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = cache.Icon(exe, 48, extract)
+		}(i)
+	}
+	// Other icons stay available during the extraction
+	var e countingExtractor
+	other := filepath.Join(dir, "other.exe")
+	writeTestFile(t, other, "other")
+	if cache.Icon(other, 48, e.extract) == "" {
+		t.Error("other icon blocked")
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("extracted %d times, want 1", calls.Load())
+	}
+	for _, r := range results {
+		if r != pngDataURI([]byte("png")) {
+			t.Errorf("result = %q", r)
+		}
+	}
+}
+
+func TestIconCacheDiskLimit(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	const limit = 4096
+	cache := NewIconCache(cacheDir, iconMemoryLimit, limit)
+
+	big := func(path string, size int) ([]byte, error) {
+		return make([]byte, 1000), nil
+	}
+	for i := 0; i < 20; i++ {
+		exe := filepath.Join(dir, string(rune('a'+i))+".exe")
+		writeTestFile(t, exe, "x")
+		cache.Icon(exe, 32, big)
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil {
+			total += info.Size()
+		}
+	}
+	if total > limit {
+		t.Errorf("disk tier holds %d bytes, limit %d", total, limit)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// Supported icon sizes, matching the shell image lists
+const (
+	IconSizeSmall      = 16  // SHIL_SMALL
+	IconSizeLarge      = 32  // SHIL_LARGE
+	IconSizeExtraLarge = 48  // SHIL_EXTRALARGE
+	IconSizeJumbo      = 256 // SHIL_JUMBO
+)
+
+// iconSizes lists the supported sizes, smallest first
+var iconSizes = []int{IconSizeSmall, IconSizeLarge, IconSizeExtraLarge, IconSizeJumbo}
+
+// extractFileIconPNG extracts the icon of a file at size (16/32/48/256)
+// and encodes it as PNG. Results are cached by IconCache; on failure the
+// frontend shows a Unicode icon.
+func extractFileIconPNG(filePath string, size int) ([]byte, error) {
+	img, err := extractFileIconImage(filePath, size)
+	if err != nil {
+		return nil, err
+	}
+	return encodeIconPNG(fitIconImage(img, size))
+}
+
+// normalizeIconSize returns the smallest supported size that is at least
+// size, so icons are scaled down rather than up
+func normalizeIconSize(size int) int {
+	if size <= 0 {
+		return IconSizeLarge
+	}
+	for _, s := range iconSizes {
+		if size <= s {
+			return s
+		}
+	}
+	return IconSizeJumbo
+}
+
+// imageFromBGRA converts top-down 32-bit BGRA pixels (as returned by
+// GetDIBits) to an image. Icons without an alpha channel have all alpha
+// bytes zero; their transparency comes from the AND mask instead, where a
+// non-zero pixel is transparent. mask may be nil.
+func imageFromBGRA(pixels []byte, mask []byte, width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	hasAlpha := false
+	for i := 3; i < width*height*4 && i < len(pixels); i += 4 {
+		if pixels[i] != 0 {
+			hasAlpha = true
+			break
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 4
+			if i+3 >= len(pixels) {
+				return img
+			}
+			a := pixels[i+3]
+			if !hasAlpha {
+				a = 255
+				if mask != nil && i < len(mask) && mask[i] != 0 {
+					a = 0
+				}
+			}
+			// BGRA to RGBA
+			img.SetNRGBA(x, y, color.NRGBA{R: pixels[i+2], G: pixels[i+1], B: pixels[i], A: a})
+		}
+	}
+	return img
+}
+
+// opaqueBounds returns the smallest rectangle containing every visible pixel
+func opaqueBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	result := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				result = result.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return result
+}
+
+// trimIconPadding undoes a quirk of the jumbo image list: programs without
+// a 256px icon get their largest icon drawn in the top-left corner of a
+// 256px canvas. The icon is cropped to the standard size that holds it.
+func trimIconPadding(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Dx() != IconSizeJumbo || b.Dy() != IconSizeJumbo {
+		return img
+	}
+
+	content := opaqueBounds(img)
+	if content.Empty() {
+		return img
+	}
+	content = content.Sub(b.Min)
+	for _, s := range iconSizes[:len(iconSizes)-1] {
+		if content.Max.X <= s && content.Max.Y <= s {
+			cropped := image.NewNRGBA(image.Rect(0, 0, s, s))
+			draw.Draw(cropped, cropped.Bounds(), img, b.Min, draw.Src)
+			return cropped
+		}
+	}
+	return img
+}
+
+// scaleIcon resizes img to size x size. Shrinking averages all covered
+// source pixels; enlarging interpolates bilinearly. Colors are weighted by
+// alpha so transparent pixels don't darken the edges.
+func scaleIcon(img image.Image, size int) *image.NRGBA {
+	src := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if sw == 0 || sh == 0 || size <= 0 {
+		return dst
+	}
+	if sw == size && sh == size {
+		return src
+	}
+
+	if sw >= size && sh >= size {
+		scaleDownArea(src, dst)
+	} else {
+		scaleUpBilinear(src, dst)
+	}
+	return dst
+}
+
+// scaleDownArea shrinks src into dst by averaging the source area each
+// destination pixel covers
+func scaleDownArea(src, dst *image.NRGBA) {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+	fx := float64(sw) / float64(dw)
+	fy := float64(sh) / float64(dh)
+
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := float64(dy)*fy, float64(dy+1)*fy
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := float64(dx)*fx, float64(dx+1)*fx
+
+			var r, g, b, a, weight float64
+			for sy := int(y0); sy < sh && float64(sy) < y1; sy++ {
+				wy := overlap(float64(sy), float64(sy+1), y0, y1)
+				for sx := int(x0); sx < sw && float64(sx) < x1; sx++ {
+					w := wy * overlap(float64(sx), float64(sx+1), x0, x1)
+					c := src.NRGBAAt(sx, sy)
+					pa := float64(c.A) * w
+					r += float64(c.R) * pa
+					g += float64(c.G) * pa
+					b += float64(c.B) * pa
+					a += pa
+					weight += w
+				}
+			}
+			dst.SetNRGBA(dx, dy, averagedColor(r, g, b, a, weight))
+		}
+	}
+}
+
+// scaleUpBilinear enlarges src into dst with bilinear interpolation
+func scaleUpBilinear(src, dst *image.NRGBA) {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+
+	for dy := 0; dy < dh; dy++ {
+		sy := clampFloat((float64(dy)+0.5)*float64(sh)/float64(dh)-0.5, 0, float64(sh-1))
+		y0 := int(sy)
+		y1 := min(y0+1, sh-1)
+		ty := sy - float64(y0)
+		for dx := 0; dx < dw; dx++ {
+			sx := clampFloat((float64(dx)+0.5)*float64(sw)/float64(dw)-0.5, 0, float64(sw-1))
+			x0 := int(sx)
+			x1 := min(x0+1, sw-1)
+			tx := sx - float64(x0)
+
+			var r, g, b, a, weight float64
+			for _, p := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - tx) * (1 - ty)},
+				{x1, y0, tx * (1 - ty)},
+				{x0, y1, (1 - tx) * ty},
+				{x1, y1, tx * ty},
+			} {
+				c := src.NRGBAAt(p.x, p.y)
+				pa := float64(c.A) * p.w
+				r += float64(c.R) * pa
+				g += float64(c.G) * pa
+				b += float64(c.B) * pa
+				a += pa
+				weight += p.w
+			}
+			dst.SetNRGBA(dx, dy, averagedColor(r, g, b, a, weight))
+		}
+	}
+}
+
+// averagedColor turns alpha-weighted color sums back into a color
+func averagedColor(r, g, b, a, weight float64) color.NRGBA {
+	if a == 0 || weight == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(clampFloat(r/a+0.5, 0, 255)),
+		G: uint8(clampFloat(g/a+0.5, 0, 255)),
+		B: uint8(clampFloat(b/a+0.5, 0, 255)),
+		A: uint8(clampFloat(a/weight+0.5, 0, 255)),
+	}
+}
+
+// overlap returns the length of the intersection of [a0,a1) and [b0,b1)
+func overlap(a0, a1, b0, b1 float64) float64 {
+	lo, hi := a0, a1
+	if b0 > lo {
+		lo = b0
+	}
+	if b1 < hi {
+		hi = b1
+	}
+	if hi <= lo {
+		return 0
+	}
+	return hi - lo
+}
+
+// clampFloat limits v to [lo, hi]
+func clampFloat(v, lo, hi float64) float64 {
+	return max(lo, min(v, hi))
+}
+
+// fitIconImage turns an extracted icon into a size x size image: padding
+// from the jumbo image list is removed, non-square icons are centered and
+// the result is scaled to size
+func fitIconImage(img image.Image, size int) image.Image {
+	img = trimIconPadding(img)
+
+	b := img.Bounds()
+	if b.Dx() != b.Dy() {
+		side := b.Dx()
+		if b.Dy() > side {
+			side = b.Dy()
+		}
+		square := image.NewNRGBA(image.Rect(0, 0, side, side))
+		offset := image.Pt((side-b.Dx())/2, (side-b.Dy())/2)
+		draw.Draw(square, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+		img = square
+	}
+
+	if img.Bounds().Dx() == size {
+		return img
+	}
+	return scaleIcon(img, size)
+}
+
+// encodeIconPNG encodes an icon as PNG
+func encodeIconPNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
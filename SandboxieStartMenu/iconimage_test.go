@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestNormalizeIconSize(t *testing.T) {
+	tests := []struct{ size, want int }{
+		{-1, 32}, {0, 32}, {1, 16}, {16, 16}, {17, 32}, {32, 32},
+		{33, 48}, {48, 48}, {49, 256}, {256, 256}, {1024, 256},
+	}
+	for _, tt := range tests {
+		if got := normalizeIconSize(tt.size); got != tt.want {
+			t.Errorf("normalizeIconSize(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+// fillRect returns a size x size image with only rect painted in c
+func fillRect(size int, rect image.Rectangle, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestTrimIconPadding(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		content image.Rectangle
+		want    int
+	}{
+		{"16px icon in the corner", 256, image.Rect(0, 0, 16, 16), 16},
+		{"short 32px icon", 256, image.Rect(2, 4, 30, 20), 32},
+		{"48px icon", 256, image.Rect(0, 0, 47, 48), 48},
+		{"content past 48px is a real jumbo icon", 256, image.Rect(0, 0, 49, 10), 256},
+		{"centered content is a real jumbo icon", 256, image.Rect(100, 100, 150, 150), 256},
+		{"fully transparent", 256, image.Rectangle{}, 256},
+		{"not jumbo", 48, image.Rect(0, 0, 16, 16), 48},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := trimIconPadding(fillRect(tt.size, tt.content, red))
+			if got := img.Bounds(); got.Dx() != tt.want || got.Dy() != tt.want {
+				t.Fatalf("bounds = %v, want %dx%d", got, tt.want, tt.want)
+			}
+			if !tt.content.Empty() {
+				checkPixel(t, img, tt.content.Min.X, tt.content.Min.Y, red)
+			}
+		})
+	}
+}
+
+func TestScaleIconDownAverages(t *testing.T) {
+	// Each 2x2 block of the 4x4 source becomes one pixel
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	block := func(x, y int, colors ...color.NRGBA) {
+		src.SetNRGBA(x, y, colors[0])
+		src.SetNRGBA(x+1, y, colors[1])
+		src.SetNRGBA(x, y+1, colors[2])
+		src.SetNRGBA(x+1, y+1, colors[3])
+	}
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.NRGBA{A: 255}
+	// Opaque: plain average
+	block(0, 0, white, black, white, black)
+	// Half transparent: the transparent pixels' black must not darken
+	// the red, only lower the alpha
+	block(2, 0, red, red, transparent, color.NRGBA{A: 0})
+	// Partial alpha: colors weighted by alpha
+	block(0, 2, color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 85}, transparent, transparent)
+	// Fully transparent
+	block(2, 2, transparent, transparent, transparent, transparent)
+
+	dst := scaleIcon(src, 2)
+	tests := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{0, 0, color.NRGBA{R: 128, G: 128, B: 128, A: 255}},
+		{1, 0, color.NRGBA{R: 255, A: 128}},
+		{0, 1, color.NRGBA{R: 191, B: 64, A: 85}},
+		{1, 1, color.NRGBA{}},
+	}
+	for _, tt := range tests {
+		if got := dst.NRGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("pixel (%d,%d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestScaleIconFractional(t *testing.T) {
+	// 3 -> 2 covers one and a half source pixels per destination pixel
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	for y := 0; y < 3; y++ {
+		src.SetNRGBA(0, y, red)
+		src.SetNRGBA(1, y, green)
+		src.SetNRGBA(2, y, color.NRGBA{B: 255, A: 255})
+	}
+	dst := scaleIcon(src, 2)
+	checkPixel(t, dst, 0, 0, color.NRGBA{R: 170, G: 85, A: 255})
+	checkPixel(t, dst, 1, 1, color.NRGBA{G: 85, B: 170, A: 255})
+
+	// Same size is a copy, enlarging keeps solid colors solid
+	if same := scaleIcon(src, 3); !bytes.Equal(same.Pix, src.Pix) {
+		t.Error("scaling to the same size changed the image")
+	}
+	solid := fillRect(2, image.Rect(0, 0, 2, 2), green)
+	up := scaleIcon(solid, 5)
+	for _, p := range []image.Point{{0, 0}, {2, 2}, {4, 4}} {
+		checkPixel(t, up, p.X, p.Y, green)
+	}
+	if empty := scaleIcon(image.NewNRGBA(image.Rectangle{}), 16); empty.Bounds().Dx() != 16 {
+		t.Error("empty source not scaled to an empty icon")
+	}
+}
+
+func TestImageFromBGRA(t *testing.T) {
+	bgra := func(pixels ...color.NRGBA) []byte {
+		var data []byte
+		for _, c := range pixels {
+			data = append(data, c.B, c.G, c.R, c.A)
+		}
+		return data
+	}
+	opaqueMask := []byte{0, 0, 0, 0}
+	transparentMask := []byte{255, 255, 255, 0}
+	mask := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name   string
+		pixels []byte
+		mask   []byte
+		want   []color.NRGBA
+	}{
+		{
+			name:   "no alpha channel uses the AND mask",
+			pixels: bgra(color.NRGBA{R: 255}, color.NRGBA{G: 255}, color.NRGBA{B: 255}, color.NRGBA{R: 1, G: 2, B: 3}),
+			mask:   mask(transparentMask, opaqueMask, opaqueMask, transparentMask),
+			want:   []color.NRGBA{transparent, green, {B: 255, A: 255}, transparent},
+		},
+		{
+			name:   "no alpha channel and no mask is opaque",
+			pixels: bgra(color.NRGBA{R: 255}, color.NRGBA{G: 255}, color.NRGBA{}, color.NRGBA{}),
+			want:   []color.NRGBA{red, green, {A: 255}, {A: 255}},
+		},
+		{
+			name:   "alpha channel ignores the AND mask",
+			pixels: bgra(color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 128}, color.NRGBA{}, color.NRGBA{B: 9, A: 1}),
+			mask:   mask(transparentMask, transparentMask, opaqueMask, opaqueMask),
+			want:   []color.NRGBA{red, {G: 255, A: 128}, transparent, {B: 9, A: 1}},
+		},
+		{
+			name:   "short mask",
+			pixels: bgra(color.NRGBA{R: 255}, color.NRGBA{R: 255}, color.NRGBA{R: 255}, color.NRGBA{R: 255}),
+			mask:   transparentMask,
+			want:   []color.NRGBA{transparent, red, red, red},
+		},
+		{
+			name:   "short pixel data",
+			pixels: bgra(color.NRGBA{R: 255}, color.NRGBA{R: 255})[:7],
+			want:   []color.NRGBA{red, transparent, transparent, transparent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := imageFromBGRA(tt.pixels, tt.mask, 2, 2)
+			for i, want := range tt.want {
+				checkPixel(t, img, i%2, i/2, want)
+			}
+		})
+	}
+}
+
+func TestFitIconImage(t *testing.T) {
+	// A jumbo canvas holding a 32px icon is cropped before scaling
+	jumbo := fillRect(256, image.Rect(0, 0, 32, 32), red)
+	if img := fitIconImage(jumbo, 32); img.Bounds().Dx() != 32 {
+		t.Errorf("padded jumbo icon fit to %v", img.Bounds())
+	} else {
+		checkPixel(t, img, 31, 31, red)
+	}
+
+	// Non-square icons are centered
+	wide := fillRect(4, image.Rect(0, 0, 4, 2), red).SubImage(image.Rect(0, 0, 4, 2))
+	img := fitIconImage(wide, 4)
+	checkPixel(t, img, 0, 0, transparent)
+	checkPixel(t, img, 0, 1, red)
+	checkPixel(t, img, 3, 2, red)
+	checkPixel(t, img, 3, 3, transparent)
+
+	data, err := encodeIconPNG(img)
+	if err != nil {
+		t.Fatalf("encodeIconPNG: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode PNG: %v", err)
+	}
+	checkPixel(t, decoded, 1, 1, red)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	iconWorkers       = 4                      // Icons extracted in parallel
+	iconBatchSize     = 16                     // Results per event
+	iconBatchInterval = 100 * time.Millisecond // Longest wait before a partial batch is sent
+)
+
+// IconResult is one extracted icon; Icon is empty when extraction failed
+type IconResult struct {
+	Path string `json:"path"`
+	Icon string `json:"icon"`
+	Size int    `json:"size"`
+}
+
+// loadIcons extracts icons for paths with a bounded worker pool, calling
+// onResult from the workers as each one completes. It stops early when
+// ctx is cancelled.
+func loadIcons(ctx context.Context, paths []string, load func(path string) string, onResult func(IconResult)) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	workers := iconWorkers
+	if len(paths) < workers {
+		workers = len(paths)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				onResult(IconResult{Path: path, Icon: load(path)})
+			}
+		}()
+	}
+
+feed:
+	for _, path := range paths {
+		select {
+		case jobs <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// IconPrefetcher extracts the icons of the current folder in the
+// background and streams them in batches as they complete
+type IconPrefetcher struct {
+	mu      sync.Mutex
+	load    func(path string, size int) string
+	emit    func(results []IconResult)
+	cancel  context.CancelFunc
+	current string // Size and paths of the running or last completed prefetch
+	wg      sync.WaitGroup
+	emitMu  sync.Mutex // Held while a batch is emitted, so cancelling can wait for it
+}
+
+// NewIconPrefetcher creates a prefetcher. load extracts (or looks up) one
+// icon, emit receives each batch of results.
+func NewIconPrefetcher(load func(path string, size int) string, emit func(results []IconResult)) *IconPrefetcher {
+	return &IconPrefetcher{load: load, emit: emit}
+}
+
+// Prefetch starts extracting icons of the given size for paths,
+// cancelling any previous prefetch. Asking again for the same paths and
+// size does nothing.
+func (p *IconPrefetcher) Prefetch(paths []string, size int) {
+	key := fmt.Sprintf("%d\n%s", size, strings.ToLower(strings.Join(paths, "\n")))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key == p.current && p.cancel != nil {
+		return
+	}
+	p.cancelLocked()
+	p.current = key
+	if len(paths) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.wg.Add(1)
+	go p.run(ctx, append([]string{}, paths...), size)
+}
+
+// run extracts one set of icons, sending a batch when it is full or the
+// batch interval passes
+func (p *IconPrefetcher) run(ctx context.Context, paths []string, size int) {
+	defer p.wg.Done()
+
+	results := make(chan IconResult)
+	go func() {
+		load := func(path string) string {
+			return p.load(path, size)
+		}
+		loadIcons(ctx, paths, load, func(r IconResult) {
+			r.Size = size
+			select {
+			case results <- r:
+			case <-ctx.Done():
+			}
+		})
+		close(results)
+	}()
+
+	var batch []IconResult
+	flush := func() {
+		p.emitMu.Lock()
+		if len(batch) > 0 && ctx.Err() == nil {
+			p.emit(batch)
+		}
+		p.emitMu.Unlock()
+		batch = nil
+	}
+
+	ticker := time.NewTicker(iconBatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				flush()
+				return
+			}
+			if r.Icon == "" {
+				continue
+			}
+			batch = append(batch, r)
+			if len(batch) >= iconBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// cancelLocked cancels the running prefetch and waits for a batch being
+// emitted, so none of its results arrive afterwards; p.mu must be held
+func (p *IconPrefetcher) cancelLocked() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.cancel = nil
+	p.emitMu.Lock()
+	p.emitMu.Unlock()
+}
+
+// Stop cancels the running prefetch and waits for it to finish
+func (p *IconPrefetcher) Stop() {
+	p.mu.Lock()
+	p.cancelLocked()
+	p.current = ""
+	p.mu.Unlock()
+	p.wg.Wait()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIconLoader hands out icons named after the path. Paths starting
+// with "slow" wait for release, those starting with "none" have no icon.
+type fakeIconLoader struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	active    int
+	maxActive int
+	gate      chan struct{}
+}
+
+func newFakeIconLoader() *fakeIconLoader {
+	return &fakeIconLoader{calls: make(map[string]int), gate: make(chan struct{})}
+}
+
+func (f *fakeIconLoader) load(path string, size int) string {
+	f.mu.Lock()
+	f.calls[path]++
+	f.active++
+	f.maxActive = max(f.maxActive, f.active)
+	f.mu.Unlock()
+
+	if strings.HasPrefix(path, "slow") {
+		<-f.gate
+	} else {
+		time.Sleep(time.Millisecond)
+	}
+
+	f.mu.Lock()
+	f.active--
+	f.mu.Unlock()
+	if strings.HasPrefix(path, "none") {
+		return ""
+	}
+	return fmt.Sprintf("icon:%s:%d", path, size)
+}
+
+func (f *fakeIconLoader) release() { close(f.gate) }
+
+func (f *fakeIconLoader) stats() (calls map[string]int, active int, maxActive int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls = make(map[string]int, len(f.calls))
+	for path, n := range f.calls {
+		calls[path] = n
+	}
+	return calls, f.active, f.maxActive
+}
+
+// iconBatches records the emitted batches
+type iconBatches struct {
+	mu      sync.Mutex
+	batches [][]IconResult
+}
+
+func (b *iconBatches) emit(results []IconResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, results)
+}
+
+func (b *iconBatches) results() []IconResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var all []IconResult
+	for _, batch := range b.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func testIconPaths(prefix string, n int) []string {
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s%02d", prefix, i)
+	}
+	return paths
+}
+
+func TestIconPrefetchBatches(t *testing.T) {
+	loader := newFakeIconLoader()
+	var out iconBatches
+	p := NewIconPrefetcher(loader.load, out.emit)
+	defer p.Stop()
+
+	paths := append(testIconPaths("app", 40), testIconPaths("none", 5)...)
+	p.Prefetch(paths, 32)
+	waitFor(t, "40 icons", func() bool { return len(out.results()) >= 40 })
+
+	calls, _, maxActive := loader.stats()
+	if maxActive > iconWorkers {
+		t.Errorf("%d icons loaded at once, want at most %d", maxActive, iconWorkers)
+	}
+	if len(calls) != len(paths) {
+		t.Errorf("%d paths loaded, want %d", len(calls), len(paths))
+	}
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	seen := make(map[string]bool)
+	for i, batch := range out.batches {
+		if len(batch) == 0 || len(batch) > iconBatchSize {
+			t.Errorf("batch %d has %d results, want 1 to %d", i, len(batch), iconBatchSize)
+		}
+		for _, r := range batch {
+			if r.Icon == "" || strings.HasPrefix(r.Path, "none") {
+				t.Errorf("result without icon emitted: %+v", r)
+			}
+			if r.Size != 32 || r.Icon != fmt.Sprintf("icon:%s:32", r.Path) || seen[r.Path] {
+				t.Errorf("unexpected result %+v", r)
+			}
+			seen[r.Path] = true
+		}
+	}
+	if len(out.batches[0]) != iconBatchSize {
+		t.Errorf("first batch has %d results, want a full batch of %d", len(out.batches[0]), iconBatchSize)
+	}
+}
+
+func TestIconPrefetchSendsPartialBatch(t *testing.T) {
+	loader := newFakeIconLoader()
+	var out iconBatches
+	p := NewIconPrefetcher(loader.load, out.emit)
+
+	// The fast icon is sent on the interval, without waiting for the slow one
+	start := time.Now()
+	p.Prefetch([]string{"fast", "slow"}, 16)
+	waitFor(t, "the fast icon", func() bool { return len(out.results()) == 1 })
+	if elapsed := time.Since(start); elapsed < iconBatchInterval/2 {
+		t.Errorf("partial batch sent after %v, want about %v", elapsed, iconBatchInterval)
+	}
+	if got := out.results()[0].Path; got != "fast" {
+		t.Errorf("first result = %q, want fast", got)
+	}
+
+	loader.release()
+	waitFor(t, "the slow icon", func() bool { return len(out.results()) == 2 })
+	p.Stop()
+}
+
+func TestIconPrefetchSkipsRepeatedRequest(t *testing.T) {
+	loader := newFakeIconLoader()
+	var out iconBatches
+	p := NewIconPrefetcher(loader.load, out.emit)
+	defer p.Stop()
+	defer loader.release()
+
+	p.Prefetch([]string{"slowA", "slowB"}, 32)
+	waitFor(t, "the loads to start", func() bool { _, active, _ := loader.stats(); return active == 2 })
+
+	p.Prefetch([]string{"SLOWA", "slowb"}, 32)
+	time.Sleep(10 * time.Millisecond)
+	if calls, _, _ := loader.stats(); len(calls) != 2 || calls["slowA"] != 1 || calls["slowB"] != 1 {
+		t.Errorf("repeated request loaded again: %v", calls)
+	}
+
+	// Another size is a new request
+	p.Prefetch([]string{"slowA", "slowB"}, 64)
+	waitFor(t, "the new size to load", func() bool { calls, _, _ := loader.stats(); return calls["slowA"] == 2 })
+}
+
+func TestIconPrefetchCancelledBatchNotSent(t *testing.T) {
+	loader := newFakeIconLoader()
+	var out iconBatches
+	p := NewIconPrefetcher(loader.load, out.emit)
+	defer p.Stop()
+
+	old := append([]string{"old"}, testIconPaths("slowOld", 3)...)
+	p.Prefetch(old, 32)
+	waitFor(t, "the first old batch", func() bool { return len(out.results()) == 1 })
+
+	// The old loads finish only after the new request replaced them
+	p.Prefetch(testIconPaths("new", 3), 32)
+	sent := len(out.results())
+	loader.release()
+	waitFor(t, "the new icons", func() bool { return len(out.results()) == sent+3 })
+	time.Sleep(2 * iconBatchInterval)
+
+	for _, r := range out.results()[sent:] {
+		if !strings.HasPrefix(r.Path, "new") {
+			t.Errorf("result of the cancelled request sent: %+v", r)
+		}
+	}
+}
+
+func TestIconPrefetchStopWaits(t *testing.T) {
+	loader := newFakeIconLoader()
+	var out iconBatches
+	p := NewIconPrefetcher(loader.load, out.emit)
+
+	p.Prefetch(testIconPaths("slow", 10), 32)
+	waitFor(t, "the loads to start", func() bool { _, active, _ := loader.stats(); return active == iconWorkers })
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while icons were still loading")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	loader.release()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return after the loads finished")
+	}
+
+	// Nothing runs or is sent once Stop has returned
+	calls, active, _ := loader.stats()
+	if active != 0 || len(calls) != iconWorkers {
+		t.Errorf("after Stop: %d loading, %d paths loaded, want 0 and %d", active, len(calls), iconWorkers)
+	}
+	if got := out.results(); len(got) != 0 {
+		t.Errorf("stopped prefetch sent %+v", got)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	indexDebounce       = 500 * time.Millisecond // Quiet time before rescanning a changed root
+	indexRescanInterval = 5 * time.Minute        // Full rescan in case notifications were missed
+	indexPollInterval   = 30 * time.Second       // Rescan interval for roots that can't be watched
+)
+
+// changeWatcher reports which watched root folders changed
+type changeWatcher interface {
+	Watch(root string) error
+	Unwatch(root string)
+	Events() <-chan string
+	Close() error
+}
+
+// indexedRoot is the cached content of one configured folder
+type indexedRoot struct {
+	dirs      map[string][]FileInfo // Folder listings, keyed by normalized path
+	files     []FileInfo            // All launchable files below the root
+	scanned   bool
+	watched   bool
+	scannedAt time.Time
+	timer     *time.Timer // Pending debounced rescan
+}
+
+// FileIndex keeps an in-memory copy of every configured folder and
+// rescans folders when they change on disk
+type FileIndex struct {
+	mu          sync.RWMutex
+	fileManager *FileManager
+	watcher     changeWatcher
+	roots       map[string]*indexedRoot
+	onChange    func(roots []string)
+	rescans     chan string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewFileIndex creates a file index. onChange is called with the roots
+// whose content changed after a rescan. A nil watcher disables change
+// notifications; roots are then polled.
+func NewFileIndex(fileManager *FileManager, watcher changeWatcher, onChange func(roots []string)) *FileIndex {
+	ctx, cancel := context.WithCancel(context.Background())
+	idx := &FileIndex{
+		fileManager: fileManager,
+		watcher:     watcher,
+		roots:       make(map[string]*indexedRoot),
+		onChange:    onChange,
+		rescans:     make(chan string),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	idx.wg.Add(1)
+	go idx.run()
+	return idx
+}
+
+// SetRoots sets the folders to index, scanning new ones in the background
+// and dropping removed ones
+func (idx *FileIndex) SetRoots(roots []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	wanted := make(map[string]bool)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		wanted[root] = true
+		if _, ok := idx.roots[root]; ok {
+			continue
+		}
+
+		r := &indexedRoot{dirs: make(map[string][]FileInfo)}
+		if idx.watcher != nil {
+			r.watched = idx.watcher.Watch(root) == nil
+		}
+		idx.roots[root] = r
+		idx.scheduleLocked(root, 0)
+	}
+
+	for root, r := range idx.roots {
+		if wanted[root] {
+			continue
+		}
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		if idx.watcher != nil && r.watched {
+			idx.watcher.Unwatch(root)
+		}
+		delete(idx.roots, root)
+	}
+}
+
+// scheduleLocked (re)starts the debounce timer for a root rescan
+func (idx *FileIndex) scheduleLocked(root string, delay time.Duration) {
+	r, ok := idx.roots[root]
+	if !ok {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(delay, func() {
+		// Scans run one at a time on the run goroutine
+		select {
+		case idx.rescans <- root:
+		case <-idx.ctx.Done():
+		}
+	})
+}
+
+// run handles watcher events and periodic rescans
+func (idx *FileIndex) run() {
+	defer idx.wg.Done()
+
+	var events <-chan string
+	if idx.watcher != nil {
+		events = idx.watcher.Events()
+	}
+
+	poll := time.NewTicker(indexPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-idx.ctx.Done():
+			return
+		case root := <-idx.rescans:
+			idx.Rescan(root)
+		case root := <-events:
+			idx.mu.Lock()
+			idx.scheduleLocked(root, indexDebounce)
+			idx.mu.Unlock()
+		case <-poll.C:
+			idx.mu.Lock()
+			for root, r := range idx.roots {
+				// Watched roots only need the occasional safety rescan
+				if !r.watched || time.Since(r.scannedAt) >= indexRescanInterval {
+					idx.scheduleLocked(root, 0)
+				}
+			}
+			idx.mu.Unlock()
+		}
+	}
+}
+
+// Rescan rebuilds the index of one root now and reports whether it changed
+func (idx *FileIndex) Rescan(root string) bool {
+	if idx.ctx.Err() != nil {
+		return false
+	}
+
+	dirs := make(map[string][]FileInfo)
+	var files []FileInfo
+	scanDir := func(dir string) []FileInfo {
+		entries, err := idx.fileManager.GetDirectoryContents(dir)
+		if err != nil {
+			return nil
+		}
+		dirs[normalizeIndexPath(dir)] = entries
+		for _, e := range entries {
+			if !e.IsDir {
+				files = append(files, e)
+			}
+		}
+		return entries
+	}
+
+	// Breadth-first so the depth limit matches Search
+	level := []string{root}
+	for depth := 0; depth <= maxSearchDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, dir := range level {
+			if idx.ctx.Err() != nil {
+				return false
+			}
+			for _, e := range scanDir(dir) {
+				if e.IsDir {
+					next = append(next, e.Path)
+				}
+			}
+		}
+		level = next
+	}
+
+	idx.mu.Lock()
+	r, ok := idx.roots[root]
+	if !ok {
+		// Root was removed while scanning
+		idx.mu.Unlock()
+		return false
+	}
+	changed := r.scanned && !sameIndexedFiles(r.dirs, dirs)
+	r.dirs = dirs
+	r.files = files
+	r.scanned = true
+	r.scannedAt = time.Now()
+	idx.mu.Unlock()
+
+	if changed && idx.onChange != nil {
+		idx.onChange([]string{root})
+	}
+	return changed
+}
+
+// Invalidate drops every cached listing and rescans all roots, e.g. after
+// the file type settings changed. Until a root is rescanned its folders
+// are read from disk.
+func (idx *FileIndex) Invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for root, r := range idx.roots {
+		r.scanned = false
+		r.dirs = make(map[string][]FileInfo)
+		r.files = nil
+		idx.scheduleLocked(root, 0)
+	}
+}
+
+// Directory returns the cached listing of dir if it lies in an indexed root
+func (idx *FileIndex) Directory(dir string) ([]FileInfo, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	key := normalizeIndexPath(dir)
+	for _, r := range idx.roots {
+		if !r.scanned {
+			continue
+		}
+		if entries, ok := r.dirs[key]; ok {
+			return append([]FileInfo(nil), entries...), true
+		}
+	}
+	return nil, false
+}
+
+// Files returns every indexed launchable file below root, or false if
+// root hasn't been scanned yet
+func (idx *FileIndex) Files(root string) ([]FileInfo, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	r, ok := idx.roots[filepath.Clean(root)]
+	if !ok || !r.scanned {
+		return nil, false
+	}
+	return append([]FileInfo(nil), r.files...), true
+}
+
+// Close stops watching and waits for running scans
+func (idx *FileIndex) Close() {
+	idx.mu.Lock()
+	for _, r := range idx.roots {
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+	}
+	idx.mu.Unlock()
+
+	idx.cancel()
+	if idx.watcher != nil {
+		idx.watcher.Close()
+	}
+	idx.wg.Wait()
+}
+
+// normalizeIndexPath makes folder paths comparable
+func normalizeIndexPath(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// sameIndexedFiles compares two indexes by file paths and shortcut targets
+func sameIndexedFiles(a, b map[string][]FileInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for dir, entries := range a {
+		other, ok := b[dir]
+		if !ok || len(other) != len(entries) {
+			return false
+		}
+		for i := range entries {
+			if entries[i].Path != other[i].Path || shortcutTarget(entries[i]) != shortcutTarget(other[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// shortcutTarget returns the target of a shortcut entry, "" for other files
+func shortcutTarget(f FileInfo) string {
+	if f.Shortcut == nil {
+		return ""
+	}
+	return f.Shortcut.Target
+}
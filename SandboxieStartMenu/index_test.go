@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// makeTree creates the files (and their folders) below dir
+func makeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, path, "MZ")
+	}
+}
+
+// fileNames returns the names of the entries
+func fileNames(files []FileInfo) []string {
+	names := []string{}
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// newTestFileIndex creates an index without a watcher over roots and
+// waits for their first scan
+func newTestFileIndex(t *testing.T, fm *FileManager, onChange func(roots []string), roots ...string) *FileIndex {
+	t.Helper()
+	idx := NewFileIndex(fm, nil, onChange)
+	t.Cleanup(idx.Close)
+	idx.SetRoots(roots)
+	for _, root := range roots {
+		waitFor(t, "the scan of "+root, func() bool { _, ok := idx.Files(root); return ok })
+	}
+	return idx
+}
+
+func TestFileIndexListings(t *testing.T) {
+	root := t.TempDir()
+	deep := strings.Repeat("d/", maxSearchDepth)
+	makeTree(t, root, "a.exe", "notes.txt", "Tools/c.bat", "Tools/Old/b.cmd", deep+"x.exe", deep+"d/y.exe")
+
+	idx := newTestFileIndex(t, NewFileManager(), nil, root)
+
+	// Breadth-first down to the search depth, unlisted types left out
+	files, _ := idx.Files(root)
+	if want := []string{"a.exe", "c.bat", "b.cmd", "x.exe"}; !slices.Equal(fileNames(files), want) {
+		t.Errorf("Files = %q, want %q", fileNames(files), want)
+	}
+
+	entries, ok := idx.Directory(root)
+	if want := []string{"d", "Tools", "a.exe"}; !ok || !slices.Equal(fileNames(entries), want) {
+		t.Errorf("Directory(root) = %q, %v, want %q", fileNames(entries), ok, want)
+	}
+	// Folder paths compare without case and trailing separator
+	entries, ok = idx.Directory(strings.ToUpper(filepath.Join(root, "Tools")) + string(filepath.Separator))
+	if want := []string{"Old", "c.bat"}; !ok || !slices.Equal(fileNames(entries), want) {
+		t.Errorf("Directory(Tools) = %q, %v, want %q", fileNames(entries), ok, want)
+	}
+	for _, dir := range []string{filepath.Dir(root), filepath.Join(root, filepath.FromSlash(deep), "d")} {
+		if _, ok := idx.Directory(dir); ok {
+			t.Errorf("Directory(%s) served from the index", dir)
+		}
+	}
+
+	// Results are copies
+	entries[0].Name = "changed"
+	if again, _ := idx.Directory(filepath.Join(root, "Tools")); again[0].Name != "Old" {
+		t.Error("Directory returned the cached slice")
+	}
+}
+
+func TestFileIndexRescanDetectsChanges(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a.exe", "Tools/b.exe")
+
+	var mu sync.Mutex
+	var changes [][]string
+	idx := newTestFileIndex(t, NewFileManager(), func(roots []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, roots)
+	}, root)
+
+	tests := []struct {
+		name    string
+		edit    func()
+		changed bool
+		files   []string
+	}{
+		{"nothing changed", func() {}, false, []string{"a.exe", "b.exe"}},
+		{"content changed", func() { writeTestFile(t, filepath.Join(root, "a.exe"), "MZ v2") }, false, []string{"a.exe", "b.exe"}},
+		{"unlisted file added", func() { makeTree(t, root, "readme.txt") }, false, []string{"a.exe", "b.exe"}},
+		{"file added below", func() { makeTree(t, root, "Tools/New/c.exe") }, true, []string{"a.exe", "b.exe", "c.exe"}},
+		{"file removed", func() { os.Remove(filepath.Join(root, "a.exe")) }, true, []string{"b.exe", "c.exe"}},
+		{"file renamed", func() {
+			os.Rename(filepath.Join(root, "Tools", "b.exe"), filepath.Join(root, "Tools", "B2.exe"))
+		}, true, []string{"B2.exe", "c.exe"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			changes = nil
+			mu.Unlock()
+
+			tt.edit()
+			if got := idx.Rescan(root); got != tt.changed {
+				t.Errorf("Rescan = %v, want %v", got, tt.changed)
+			}
+			files, _ := idx.Files(root)
+			if !slices.Equal(fileNames(files), tt.files) {
+				t.Errorf("Files = %q, want %q", fileNames(files), tt.files)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.changed && (len(changes) != 1 || !slices.Equal(changes[0], []string{root})) {
+				t.Errorf("onChange calls = %q, want one with the root", changes)
+			}
+			if !tt.changed && len(changes) != 0 {
+				t.Errorf("onChange called with %q for an unchanged root", changes)
+			}
+		})
+	}
+}
+
+func TestFileIndexSetRoots(t *testing.T) {
+	base := t.TempDir()
+	kept := filepath.Join(base, "kept")
+	dropped := filepath.Join(base, "dropped")
+	makeTree(t, kept, "a.exe")
+	makeTree(t, dropped, "b.exe")
+
+	idx := newTestFileIndex(t, NewFileManager(), nil, kept, dropped)
+
+	// An unclean spelling of an indexed root is the same root
+	makeTree(t, kept, "new.exe")
+	idx.SetRoots([]string{kept + string(filepath.Separator) + "."})
+	files, ok := idx.Files(kept)
+	if !ok || !slices.Equal(fileNames(files), []string{"a.exe"}) {
+		t.Errorf("Files(kept) = %q, %v, want the existing scan kept", fileNames(files), ok)
+	}
+
+	if _, ok := idx.Files(dropped); ok {
+		t.Error("Files of a removed root still served")
+	}
+	if _, ok := idx.Directory(dropped); ok {
+		t.Error("Directory of a removed root still served")
+	}
+	if idx.Rescan(dropped) {
+		t.Error("Rescan of a removed root reported a change")
+	}
+	if _, ok := idx.Files(dropped); ok {
+		t.Error("Rescan added the removed root back")
+	}
+}
+
+func TestFileIndexInvalidate(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a.exe", "notes.txt")
+
+	fm := NewFileManager()
+	idx := newTestFileIndex(t, fm, nil, root)
+	if files, _ := idx.Files(root); !slices.Equal(fileNames(files), []string{"a.exe"}) {
+		t.Fatalf("Files = %q", fileNames(files))
+	}
+
+	// New file types only show once the index is rebuilt
+	fm.SetFileTypes(FileTypeSettings{ShowAllFiles: true})
+	idx.Invalidate()
+	waitFor(t, "the rescan", func() bool {
+		files, ok := idx.Files(root)
+		return ok && slices.Equal(fileNames(files), []string{"a.exe", "notes.txt"})
+	})
+	if entries, ok := idx.Directory(root); !ok || !slices.Equal(fileNames(entries), []string{"a.exe", "notes.txt"}) {
+		t.Errorf("Directory = %q, %v after the rescan", fileNames(entries), ok)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package main
+
+// acquireSingleInstance asks a running instance to show its window. The
+// IPC socket doubles as the instance lock: running is true if one answered.
+func acquireSingleInstance() (release func(), running bool) {
+	if _, err := SendIPCRequest(ipcName, IPCRequest{Show: true}); err == nil {
+		return nil, true
+	}
+	return func() {}, false
+}
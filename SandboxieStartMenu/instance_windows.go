@@ -0,0 +1,43 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/lxn/win"
+)
+
+// acquireSingleInstance claims the instance mutex. If another instance
+// holds it, that instance's window is brought up and running is true;
+// otherwise release must be called on exit.
+func acquireSingleInstance() (release func(), running bool) {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	createMutex := kernel32.NewProc("CreateMutexW")
+
+	mutexName, _ := syscall.UTF16PtrFromString("Local\\SandboxieStartMenuInstance")
+	mutex, _, err := createMutex.Call(0, 0, uintptr(unsafe.Pointer(mutexName)))
+
+	// CreateMutexW opens the existing mutex when another instance is
+	// running and reports that in the last error; other errors are ignored
+	if errno, ok := err.(syscall.Errno); ok && errno == syscall.ERROR_ALREADY_EXISTS {
+		// Ask the running instance to show its window; it may be hidden
+		SendIPCRequest(ipcName, IPCRequest{Show: true})
+
+		// Focus it from here, as only the process the user just started
+		// may take the foreground
+		windowTitle, _ := syscall.UTF16PtrFromString("SandboxieStartMenu")
+		hwnd := win.FindWindow(nil, windowTitle)
+		if hwnd != 0 {
+			// Restore window if minimized
+			if win.IsIconic(hwnd) {
+				win.ShowWindow(hwnd, win.SW_RESTORE)
+			}
+			// Bring window to foreground
+			win.SetForegroundWindow(hwnd)
+			win.BringWindowToTop(hwnd)
+		}
+		syscall.CloseHandle(syscall.Handle(mutex))
+		return nil, true
+	}
+	return func() { syscall.CloseHandle(syscall.Handle(mutex)) }, false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InternetShortcut is the parsed content of a .url file
+type InternetShortcut struct {
+	URL       string `json:"url"`
+	IconFile  string `json:"iconFile,omitempty"`
+	IconIndex int    `json:"iconIndex,omitempty"`
+}
+
+// launchableURLSchemes are the URL schemes a .url file may open. Other
+// schemes (file:, javascript:, protocol handlers of installed apps) are
+// refused rather than handed to the shell.
+var launchableURLSchemes = []string{"http", "https", "ftp"}
+
+// isInternetShortcut reports whether path is a .url file
+func isInternetShortcut(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".url")
+}
+
+// isLaunchableURL reports whether target is a URL with a launchable
+// scheme. Drive paths such as C:\x are not URLs.
+func isLaunchableURL(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return slices.Contains(launchableURLSchemes, strings.ToLower(u.Scheme))
+}
+
+// ParseInternetShortcut parses the [InternetShortcut] section of a .url
+// file. Other sections, such as the browser's [DEFAULT] copy of the URL,
+// are ignored.
+func ParseInternetShortcut(data []byte) (*InternetShortcut, error) {
+	text, err := decodeIniText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	shortcut := &InternetShortcut{}
+	inSection := false
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.EqualFold(strings.TrimSpace(line[1:len(line)-1]), "InternetShortcut")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inSection || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "url":
+			shortcut.URL = value
+		case "iconfile":
+			shortcut.IconFile = value
+		case "iconindex":
+			shortcut.IconIndex, _ = strconv.Atoi(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if shortcut.URL == "" {
+		return nil, fmt.Errorf("no URL in [InternetShortcut]")
+	}
+	if !isLaunchableURL(shortcut.URL) {
+		return nil, fmt.Errorf("unsupported URL: %s", shortcut.URL)
+	}
+	return shortcut, nil
+}
+
+// ReadInternetShortcut reads and parses a .url file
+func ReadInternetShortcut(path string) (*InternetShortcut, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	shortcut, err := ParseInternetShortcut(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return shortcut, nil
+}
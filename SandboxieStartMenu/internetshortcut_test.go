@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInternetShortcut(t *testing.T) {
+	const browserFile = "[DEFAULT]\r\nBASEURL=https://example.com/old\r\nURL=https://example.com/default\r\n" +
+		"[InternetShortcut]\r\nURL=https://example.com/docs?q=1\r\nIconFile=C:\\Icons\\docs.ico\r\nIconIndex=2\r\n" +
+		"[{000214A0-0000-0000-C000-000000000046}]\r\nProp3=19,11\r\n"
+
+	tests := []struct {
+		name string
+		data []byte
+		want InternetShortcut
+	}{
+		{
+			name: "minimal",
+			data: []byte("[InternetShortcut]\nURL=http://example.com/\n"),
+			want: InternetShortcut{URL: "http://example.com/"},
+		},
+		{
+			name: "URL under [DEFAULT] is ignored",
+			data: []byte(browserFile),
+			want: InternetShortcut{URL: "https://example.com/docs?q=1", IconFile: `C:\Icons\docs.ico`, IconIndex: 2},
+		},
+		{
+			name: "keys and section name in other case, spaces and comments",
+			data: []byte("; saved by hand\n[internetshortcut]\n  url = FTP://files.example.com/pub \n"),
+			want: InternetShortcut{URL: "FTP://files.example.com/pub"},
+		},
+		{
+			name: "UTF-16LE with BOM",
+			data: append([]byte{0xFF, 0xFE}, utf16LE("[InternetShortcut]\r\nURL=https://例子.cn/文档\r\n")...),
+			want: InternetShortcut{URL: "https://例子.cn/文档"},
+		},
+		{
+			name: "UTF-16LE without BOM",
+			data: utf16LE("[InternetShortcut]\r\nURL=https://example.com/\r\n"),
+			want: InternetShortcut{URL: "https://example.com/"},
+		},
+		{
+			name: "UTF-8 BOM",
+			data: append([]byte{0xEF, 0xBB, 0xBF}, "[InternetShortcut]\nURL=https://example.com/\n"...),
+			want: InternetShortcut{URL: "https://example.com/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInternetShortcut(tt.data)
+			if err != nil {
+				t.Fatalf("ParseInternetShortcut: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseInternetShortcut = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInternetShortcutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty file", "", "no URL"},
+		{"no section", "URL=https://example.com/\n", "no URL"},
+		{"only [DEFAULT]", "[DEFAULT]\nURL=https://example.com/\n", "no URL"},
+		{"empty URL", "[InternetShortcut]\nURL=\n", "no URL"},
+		{"file scheme", "[InternetShortcut]\nURL=file:///C:/Windows/System32/cmd.exe\n", "unsupported URL"},
+		{"javascript scheme", "[InternetShortcut]\nURL=javascript:alert(1)\n", "unsupported URL"},
+		{"app protocol", "[InternetShortcut]\nURL=steam://run/440\n", "unsupported URL"},
+		{"drive path", "[InternetShortcut]\nURL=C:\\Windows\\notepad.exe\n", "unsupported URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseInternetShortcut([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseInternetShortcut error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLaunchableURL(t *testing.T) {
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"https://example.com/", true},
+		{"HTTP://EXAMPLE.COM", true},
+		{"ftp://files.example.com/pub", true},
+		{"file:///C:/Windows/notepad.exe", false},
+		{"file://server/share/app.exe", false},
+		{"javascript:alert(1)", false},
+		{"mailto:someone@example.com", false},
+		{"ms-settings:display", false},
+		{"steam://run/440", false},
+		{`C:\Windows\notepad.exe`, false},
+		{"C:/Windows/notepad.exe", false},
+		{`\\server\share\app.exe`, false},
+		{"https:///no-host", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isLaunchableURL(tt.target); got != tt.want {
+			t.Errorf("isLaunchableURL(%q) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
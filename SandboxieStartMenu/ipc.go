@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// A second instance hands its command line to the running (primary)
+// instance over a local IPC channel: a named pipe on Windows, a Unix
+// socket elsewhere. Each connection carries one JSON request and one JSON
+// response.
+const (
+	ipcName           = "SandboxieStartMenu"
+	ipcMaxRequestSize = 1 << 20          // Command lines are far smaller
+	ipcTimeout        = 30 * time.Second // Time to wait for the primary to answer
+)
+
+// ipcCommands are the CLI commands the primary runs for other instances.
+// Anything that changes the config or the sandboxes is refused, since any
+// program of the user, including one inside a sandbox, can reach the
+// channel.
+var ipcCommands = map[string]bool{
+	"launch": true,
+	"list":   true,
+	"search": true,
+}
+
+// errNoPrimary is returned when no instance is listening
+var errNoPrimary = errors.New("no running instance")
+
+// IPCRequest is sent by a second instance. Args is a CLI command line;
+// Show asks the primary to bring its window up.
+type IPCRequest struct {
+	Args []string `json:"args,omitempty"`
+	Show bool     `json:"show,omitempty"`
+}
+
+// IPCResponse is the primary's answer: what the command printed and its
+// exit code
+type IPCResponse struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// ipcListener accepts connections from other instances
+type ipcListener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// IPCServer answers requests from other instances until closed
+type IPCServer struct {
+	listener ipcListener
+	handler  func(IPCRequest) IPCResponse
+	wg       sync.WaitGroup
+}
+
+// NewIPCServer starts answering requests on the endpoint for name
+func NewIPCServer(name string, handler func(IPCRequest) IPCResponse) (*IPCServer, error) {
+	listener, err := listenIPC(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &IPCServer{listener: listener, handler: handler}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// serve accepts connections until the listener is closed
+func (s *IPCServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle answers the single request on conn
+func (s *IPCServer) handle(conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	var request IPCRequest
+	if err := json.NewDecoder(io.LimitReader(conn, ipcMaxRequestSize)).Decode(&request); err != nil {
+		json.NewEncoder(conn).Encode(IPCResponse{ExitCode: 2, Stderr: fmt.Sprintf("invalid request: %v\n", err)})
+		return
+	}
+	json.NewEncoder(conn).Encode(s.handler(request))
+}
+
+// Close stops accepting requests and waits for the ones in progress
+func (s *IPCServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// SendIPCRequest hands a request to the primary instance and returns its
+// answer. It returns errNoPrimary when no instance is listening, so the
+// caller can handle the request itself.
+func SendIPCRequest(name string, request IPCRequest) (*IPCResponse, error) {
+	conn, err := dialIPC(name)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		response *IPCResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer conn.Close()
+		if err := json.NewEncoder(conn).Encode(request); err != nil {
+			done <- result{err: fmt.Errorf("failed to send request: %v", err)}
+			return
+		}
+		var response IPCResponse
+		if err := json.NewDecoder(conn).Decode(&response); err != nil {
+			done <- result{err: fmt.Errorf("failed to read response: %v", err)}
+			return
+		}
+		done <- result{response: &response}
+	}()
+
+	// Blocking pipe I/O can't be interrupted, so a hung primary is left
+	// behind rather than waited for
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-time.After(ipcTimeout):
+		return nil, fmt.Errorf("running instance did not respond within %v", ipcTimeout)
+	}
+}
+
+// isForwardedCommand reports whether a command line is handed to the
+// primary instance
+func isForwardedCommand(args []string) bool {
+	name, _ := splitCLICommand(args)
+	return ipcCommands[name]
+}
+
+// handleCLIRequest runs a forwarded command line against a and captures
+// its output. Commands not in ipcCommands are refused as usage errors.
+func handleCLIRequest(a *App, args []string) IPCResponse {
+	if name, _ := splitCLICommand(args); !ipcCommands[name] {
+		if _, known := cliCommands[name]; known {
+			return IPCResponse{ExitCode: 2, Stderr: fmt.Sprintf("%s: not accepted from another instance\n", name)}
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	code := runCLI(a, args, &stdout, &stderr)
+	return IPCResponse{ExitCode: code, Stdout: stdout.String(), Stderr: stderr.String()}
+}
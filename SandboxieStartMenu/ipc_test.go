@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testIPCName returns an endpoint name no running instance uses
+func testIPCName(t *testing.T) string {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+	return "SandboxieStartMenuTest-" + strings.ReplaceAll(t.Name(), "/", "-")
+}
+
+func TestIPCRoundTrip(t *testing.T) {
+	name := testIPCName(t)
+	server, err := NewIPCServer(name, func(request IPCRequest) IPCResponse {
+		if request.Show {
+			return IPCResponse{Stdout: "shown"}
+		}
+		return IPCResponse{ExitCode: 3, Stdout: strings.Join(request.Args, "|"), Stderr: "err"}
+	})
+	if err != nil {
+		t.Fatalf("NewIPCServer: %v", err)
+	}
+	defer server.Close()
+
+	response, err := SendIPCRequest(name, IPCRequest{Args: []string{"list", `C:\Tools`, "中文"}})
+	if err != nil {
+		t.Fatalf("SendIPCRequest: %v", err)
+	}
+	want := IPCResponse{ExitCode: 3, Stdout: `list|C:\Tools|中文`, Stderr: "err"}
+	if *response != want {
+		t.Errorf("response = %+v, want %+v", *response, want)
+	}
+
+	response, err = SendIPCRequest(name, IPCRequest{Show: true})
+	if err != nil || response.Stdout != "shown" {
+		t.Errorf("show request = %+v, %v", response, err)
+	}
+
+	// Only one primary per name
+	if second, err := NewIPCServer(name, nil); err == nil {
+		second.Close()
+		t.Error("second server on the same name started")
+	}
+}
+
+func TestIPCNoPrimary(t *testing.T) {
+	name := testIPCName(t)
+	if _, err := SendIPCRequest(name, IPCRequest{Show: true}); !errors.Is(err, errNoPrimary) {
+		t.Fatalf("SendIPCRequest without a primary = %v, want errNoPrimary", err)
+	}
+
+	// Once the primary exits, callers fall back to handling requests
+	// themselves
+	server, err := NewIPCServer(name, func(IPCRequest) IPCResponse { return IPCResponse{} })
+	if err != nil {
+		t.Fatalf("NewIPCServer: %v", err)
+	}
+	if _, err := SendIPCRequest(name, IPCRequest{Show: true}); err != nil {
+		t.Fatalf("SendIPCRequest: %v", err)
+	}
+	server.Close()
+	if _, err := SendIPCRequest(name, IPCRequest{Show: true}); !errors.Is(err, errNoPrimary) {
+		t.Fatalf("SendIPCRequest after Close = %v, want errNoPrimary", err)
+	}
+}
+
+func TestIPCInvalidRequest(t *testing.T) {
+	name := testIPCName(t)
+	server, err := NewIPCServer(name, func(IPCRequest) IPCResponse {
+		t.Error("handler called for an invalid request")
+		return IPCResponse{}
+	})
+	if err != nil {
+		t.Fatalf("NewIPCServer: %v", err)
+	}
+	defer server.Close()
+
+	conn, err := dialIPC(name)
+	if err != nil {
+		t.Fatalf("dialIPC: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("not json\n"))
+	buf := make([]byte, 256)
+	n, _ := conn.Read(buf)
+	if !strings.Contains(string(buf[:n]), `"exitCode":2`) {
+		t.Errorf("response = %s, want exit code 2", buf[:n])
+	}
+}
+
+func TestHandleCLIRequestUsage(t *testing.T) {
+	response := handleCLIRequest(nil, []string{"no-such-command"})
+	if response.ExitCode != 2 || !strings.Contains(response.Stderr, "unknown command") {
+		t.Errorf("response = %+v", response)
+	}
+	response = handleCLIRequest(nil, []string{"--help"})
+	if response.ExitCode != 0 || !slices.Contains(strings.Split(response.Stdout, "\n"), "Commands:") {
+		t.Errorf("response = %+v", response)
+	}
+}
+
+func TestHandleCLIRequestRefusesCommands(t *testing.T) {
+	// The App is never reached, so nil is fine
+	for _, args := range [][]string{
+		{"config", "set", "sandboxiePath", `C:\evil`},
+		{"config", "get"},
+		{"delete", "--yes", "DefaultBox"},
+		{"terminate", "DefaultBox"},
+		{"list-boxes"},
+	} {
+		response := handleCLIRequest(nil, args)
+		if response.ExitCode != 2 || !strings.Contains(response.Stderr, "not accepted") {
+			t.Errorf("handleCLIRequest(%q) = %+v, want refusal", args, response)
+		}
+	}
+}
+
+func TestIsForwardedCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"launch", "app.exe"}, true},
+		{[]string{"--box", "Box", "--launch", "app.exe"}, true},
+		{[]string{"list"}, true},
+		{[]string{"search", "note"}, true},
+		{[]string{"list-boxes"}, false},
+		{[]string{"config", "set", "fileTypes", "{}"}, false},
+		{[]string{"terminate", "Box"}, false},
+		{[]string{"delete", "--yes", "Box"}, false},
+		{[]string{"no-such-command"}, false},
+	}
+	for _, tt := range tests {
+		if got := isForwardedCommand(tt.args); got != tt.want {
+			t.Errorf("isForwardedCommand(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
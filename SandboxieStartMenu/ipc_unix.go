@@ -0,0 +1,55 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// ipcSocketPath returns the Unix socket for name, one per user
+func ipcSocketPath(name string) string {
+	return filepath.Join(os.TempDir(), name+"-"+strconv.Itoa(os.Getuid())+".sock")
+}
+
+// socketListener adapts a Unix socket listener to ipcListener
+type socketListener struct {
+	net.Listener
+}
+
+func (l socketListener) Accept() (io.ReadWriteCloser, error) {
+	return l.Listener.Accept()
+}
+
+// listenIPC listens on the socket for name. A socket file left behind by
+// an instance that crashed is replaced; a live one is not.
+func listenIPC(name string) (ipcListener, error) {
+	path := ipcSocketPath(name)
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, errors.New("another instance is listening on " + path)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	os.Chmod(path, 0600)
+	return socketListener{listener}, nil
+}
+
+// dialIPC connects to the socket of the primary instance
+func dialIPC(name string) (io.ReadWriteCloser, error) {
+	conn, err := net.Dial("unix", ipcSocketPath(name))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, errNoPrimary
+	}
+	return conn, err
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestIPCReplacesStaleSocket(t *testing.T) {
+	name := testIPCName(t)
+	path := ipcSocketPath(name)
+
+	// A crashed instance leaves its socket file behind
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("stale socket missing: %v", err)
+	}
+
+	if _, err := SendIPCRequest(name, IPCRequest{Show: true}); !errors.Is(err, errNoPrimary) {
+		t.Fatalf("SendIPCRequest to a stale socket = %v, want errNoPrimary", err)
+	}
+
+	server, err := NewIPCServer(name, func(IPCRequest) IPCResponse { return IPCResponse{Stdout: "ok"} })
+	if err != nil {
+		t.Fatalf("NewIPCServer over a stale socket: %v", err)
+	}
+	defer server.Close()
+	response, err := SendIPCRequest(name, IPCRequest{Show: true})
+	if err != nil || response.Stdout != "ok" {
+		t.Fatalf("SendIPCRequest = %+v, %v", response, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		t.Errorf("socket permissions = %v, want owner only", perm)
+	}
+}
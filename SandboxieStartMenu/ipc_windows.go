@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	pipeBufferSize     = 4096
+	pipeConnectTimeout = 2 * time.Second // Time to wait while every pipe instance is busy
+)
+
+// errPipeClosed is returned by Accept after Close
+var errPipeClosed = errors.New("pipe listener closed")
+
+// currentUserSID returns the SID of the user running this process
+func currentUserSID() (string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %v", err)
+	}
+	return user.User.Sid.String(), nil
+}
+
+// ipcPipePath returns the named pipe for name. It includes the user's SID,
+// so instances of different users on the same machine don't meet.
+func ipcPipePath(name string) (string, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return "", err
+	}
+	return `\\.\pipe\` + name + "-" + sid, nil
+}
+
+// pipeSecurity returns security attributes whose DACL grants access to the
+// current user only. The default DACL also gives Everyone read access.
+func pipeSecurity() (*windows.SecurityAttributes, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, err
+	}
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + sid + ")")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build pipe security descriptor: %v", err)
+	}
+	sa := &windows.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	return sa, nil
+}
+
+// pipeListener hands out one pipe instance per client
+type pipeListener struct {
+	path     string
+	security *windows.SecurityAttributes // Applied to every pipe instance
+	mu       sync.Mutex
+	pending  windows.Handle // Instance waiting for a client, 0 if none
+	closed   bool
+}
+
+// listenIPC creates the first instance of the pipe for name. It fails if
+// the pipe already exists, so a pipe created by another program is never
+// served.
+func listenIPC(name string) (ipcListener, error) {
+	path, err := ipcPipePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	security, err := pipeSecurity()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &pipeListener{path: path, security: security}
+	l.pending, err = l.createInstance(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe %s: %v", path, err)
+	}
+	return l, nil
+}
+
+// createInstance creates a pipe instance for the next client
+func (l *pipeListener) createInstance(first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.path)
+	if err != nil {
+		return 0, err
+	}
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	return windows.CreateNamedPipe(name, flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, pipeBufferSize, pipeBufferSize, 0, l.security)
+}
+
+// Accept waits for the next client
+func (l *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errPipeClosed
+	}
+	h := l.pending
+	if h == 0 {
+		var err error
+		if h, err = l.createInstance(false); err != nil {
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.pending = h
+	}
+	l.mu.Unlock()
+
+	err := windows.ConnectNamedPipe(h, nil)
+
+	l.mu.Lock()
+	l.pending = 0
+	closed := l.closed
+	l.mu.Unlock()
+
+	if closed {
+		windows.CloseHandle(h)
+		return nil, errPipeClosed
+	}
+	// ERROR_PIPE_CONNECTED: the client connected before ConnectNamedPipe
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	return &pipeConn{handle: h, server: true}, nil
+}
+
+// Close stops Accept. A blocked ConnectNamedPipe can't be cancelled, so
+// it is woken by connecting to the pipe.
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	waiting := l.pending != 0
+	l.mu.Unlock()
+
+	if waiting {
+		if conn, err := dialPipe(l.path); err == nil {
+			conn.Close()
+		}
+	}
+	return nil
+}
+
+// pipeConn is one end of a connected pipe instance, using blocking I/O
+type pipeConn struct {
+	handle windows.Handle
+	server bool
+}
+
+func (c *pipeConn) Read(p []byte) (int, error) {
+	var n uint32
+	err := windows.ReadFile(c.handle, p, &n, nil)
+	if err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED {
+		return int(n), io.EOF // The other end closed the pipe
+	}
+	return int(n), err
+}
+
+func (c *pipeConn) Write(p []byte) (int, error) {
+	var n uint32
+	err := windows.WriteFile(c.handle, p, &n, nil)
+	return int(n), err
+}
+
+// Close closes the connection. The server waits for the client to read
+// everything written before disconnecting.
+func (c *pipeConn) Close() error {
+	if c.server {
+		windows.FlushFileBuffers(c.handle)
+		windows.DisconnectNamedPipe(c.handle)
+	}
+	return windows.CloseHandle(c.handle)
+}
+
+// dialIPC connects to the pipe of the primary instance
+func dialIPC(name string) (io.ReadWriteCloser, error) {
+	path, err := ipcPipePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return dialPipe(path)
+}
+
+// dialPipe connects to a named pipe, waiting briefly while all instances
+// are busy. The server may only identify us, not impersonate us.
+func dialPipe(path string) (io.ReadWriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(pipeConnectTimeout)
+	for {
+		h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil,
+			windows.OPEN_EXISTING, windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+		if err == nil {
+			return &pipeConn{handle: h}, nil
+		}
+		if err == windows.ERROR_FILE_NOT_FOUND {
+			return nil, errNoPrimary
+		}
+		if err != windows.ERROR_PIPE_BUSY || time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to %s: %v", path, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
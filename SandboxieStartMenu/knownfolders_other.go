@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+// startMenuFolders returns nothing; the Start Menu exists only on Windows
+func startMenuFolders() []string {
+	return nil
+}
+
+// desktopFolders returns nothing; the Windows desktops don't exist here
+func desktopFolders() []string {
+	return nil
+}
+
+// taskbarFolders returns nothing; the Windows taskbar doesn't exist here
+func taskbarFolders() []string {
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// knownFolder resolves a shell known folder, which follows folder
+// redirection (e.g. a Desktop moved to OneDrive). fallback is used when
+// the shell can't resolve it.
+func knownFolder(id *windows.KNOWNFOLDERID, fallback string) string {
+	if path, err := windows.KnownFolderPath(id, windows.KF_FLAG_DEFAULT); err == nil && path != "" {
+		return path
+	}
+	return fallback
+}
+
+// envFolder joins elem onto an environment variable, or returns "" when
+// the variable isn't set
+func envFolder(env string, elem ...string) string {
+	base := os.Getenv(env)
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{base}, elem...)...)
+}
+
+// existingFolders returns the distinct paths that are existing folders
+func existingFolders(paths ...string) []string {
+	var folders []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if path == "" || seen[normalizeBindingPath(path)] {
+			continue
+		}
+		seen[normalizeBindingPath(path)] = true
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			folders = append(folders, path)
+		}
+	}
+	return folders
+}
+
+// startMenuFolders returns the per-user and all-users Start Menu programs
+func startMenuFolders() []string {
+	return existingFolders(
+		knownFolder(windows.FOLDERID_Programs, envFolder("APPDATA", "Microsoft", "Windows", "Start Menu", "Programs")),
+		knownFolder(windows.FOLDERID_CommonPrograms, envFolder("PROGRAMDATA", "Microsoft", "Windows", "Start Menu", "Programs")),
+	)
+}
+
+// desktopFolders returns the user's (possibly redirected) desktop and the
+// public desktop
+func desktopFolders() []string {
+	return existingFolders(
+		knownFolder(windows.FOLDERID_Desktop, envFolder("USERPROFILE", "Desktop")),
+		knownFolder(windows.FOLDERID_PublicDesktop, envFolder("PUBLIC", "Desktop")),
+	)
+}
+
+// taskbarFolders returns the folder holding the taskbar pins
+func taskbarFolders() []string {
+	quickLaunch := knownFolder(windows.FOLDERID_QuickLaunch, envFolder("APPDATA", "Microsoft", "Internet Explorer", "Quick Launch"))
+	if quickLaunch == "" {
+		return nil
+	}
+	return existingFolders(filepath.Join(quickLaunch, "User Pinned", "TaskBar"))
+}
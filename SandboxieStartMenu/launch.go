@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LaunchOptions describes how a program is started inside a sandbox
+type LaunchOptions struct {
+	Args       []string          `json:"args"`       // Command-line arguments passed to the program
+	WorkingDir string            `json:"workingDir"` // Working directory, empty for the default
+	Env        map[string]string `json:"env"`        // Environment variable overrides
+	HideWindow bool              `json:"hideWindow"` // Start.exe /hide_window
+	Wait       bool              `json:"wait"`       // Start.exe /wait
+	Elevate    bool              `json:"elevate"`    // Start.exe /elevate
+	Silent     bool              `json:"silent"`     // Start.exe /silent
+}
+
+// LaunchProfile is a saved set of launch options for one program
+type LaunchProfile struct {
+	Path    string        `json:"path"`
+	Options LaunchOptions `json:"options"`
+}
+
+// clone returns a copy that shares no slices or maps with o
+func (o LaunchOptions) clone() LaunchOptions {
+	copied := o
+	copied.Args = slices.Clone(o.Args)
+	if o.Env != nil {
+		copied.Env = make(map[string]string, len(o.Env))
+		for k, v := range o.Env {
+			copied.Env[k] = v
+		}
+	}
+	return copied
+}
+
+// buildStartArgs builds the Start.exe command line for launching filePath
+func buildStartArgs(sandbox string, filePath string, opts LaunchOptions) []string {
+	args := []string{"/box:" + sandbox}
+
+	// Start.exe switches must come before the program path
+	if opts.HideWindow {
+		args = append(args, "/hide_window")
+	}
+	if opts.Wait {
+		args = append(args, "/wait")
+	}
+	if opts.Elevate {
+		args = append(args, "/elevate")
+	}
+	if opts.Silent {
+		args = append(args, "/silent")
+	}
+
+	args = append(args, filePath)
+	args = append(args, opts.Args...)
+	return args
+}
+
+// mergeEnv applies overrides to a KEY=VALUE environment list. Keys are
+// compared case-insensitively, as Windows does.
+func mergeEnv(base []string, overrides map[string]string) []string {
+	if len(overrides) == 0 {
+		return base
+	}
+
+	merged := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := lookupEnvOverride(overrides, key); overridden {
+			continue
+		}
+		merged = append(merged, kv)
+	}
+
+	// Sort for a stable environment block
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		merged = append(merged, k+"="+overrides[k])
+	}
+	return merged
+}
+
+// lookupEnvOverride finds key in overrides ignoring case
+func lookupEnvOverride(overrides map[string]string, key string) (string, bool) {
+	for k, v := range overrides {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// validateLaunchOptions checks that the options can be used for a launch
+func validateLaunchOptions(opts LaunchOptions) error {
+	if opts.WorkingDir != "" {
+		info, err := os.Stat(opts.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("working directory not found: %s", opts.WorkingDir)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("working directory is not a folder: %s", opts.WorkingDir)
+		}
+	}
+	for k := range opts.Env {
+		if k == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("invalid environment variable name: %q", k)
+		}
+	}
+	return nil
+}
+
+// resolveLaunchTarget turns a shortcut into its real target so it can be
+// launched directly with the shortcut's arguments and working directory.
+// Other files, and shortcuts we can't resolve (advertised, broken or
+// unreadable), are returned unchanged and left for Start.exe to open.
+func resolveLaunchTarget(filePath string, opts LaunchOptions) (string, LaunchOptions) {
+	if !strings.EqualFold(filepath.Ext(filePath), ".lnk") {
+		return filePath, opts
+	}
+
+	link, err := ReadShellLink(filePath)
+	if err != nil || link.Advertised || link.Broken || link.Target == "" {
+		return filePath, opts
+	}
+
+	resolved := opts
+	resolved.Args = append(splitCommandLine(link.Arguments), opts.Args...)
+	if resolved.WorkingDir == "" && link.WorkingDir != "" {
+		if info, err := os.Stat(link.WorkingDir); err == nil && info.IsDir() {
+			resolved.WorkingDir = link.WorkingDir
+		}
+	}
+	return link.Target, resolved
+}
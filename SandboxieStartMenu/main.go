@@ -2,10 +2,8 @@ package main
 
 import (
 	"embed"
-	"syscall"
-	"unsafe"
+	"os"
 
-	"github.com/lxn/win"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -15,39 +13,24 @@ import (
 var assets embed.FS
 
 func main() {
-	// Single instance check using mutex
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	createMutex := kernel32.NewProc("CreateMutexW")
-
-	mutexName, _ := syscall.UTF16PtrFromString("Local\\SandboxieStartMenuInstance")
-	mutex, _, err := createMutex.Call(0, 0, uintptr(unsafe.Pointer(mutexName)))
+	// Command-line mode runs without the window
+	if args := os.Args[1:]; isCLI(args) {
+		attachParentConsole()
+		os.Exit(runCommandLine(args, os.Stdout, os.Stderr))
+	}
 
-	if mutex == 0 {
-		// Check if mutex already exists (another instance is running)
-		if errno, ok := err.(syscall.Errno); ok && errno == syscall.ERROR_ALREADY_EXISTS {
-			// Find existing window
-			windowTitle, _ := syscall.UTF16PtrFromString("SandboxieStartMenu")
-			hwnd := win.FindWindow(nil, windowTitle)
-			if hwnd != 0 {
-				// Restore window if minimized
-				if win.IsIconic(hwnd) {
-					win.ShowWindow(hwnd, win.SW_RESTORE)
-				}
-				// Bring window to foreground
-				win.SetForegroundWindow(hwnd)
-				win.BringWindowToTop(hwnd)
-			}
-			return // Exit this instance
-		}
-		// If it's some other error, we'll continue anyway
+	// Only one window; a second start brings up the running one
+	release, running := acquireSingleInstance()
+	if running {
+		return // Exit this instance
 	}
-	defer syscall.CloseHandle(syscall.Handle(mutex))
+	defer release()
 
 	// Create an instance of the app structure
 	app := NewApp()
 
 	// Create application with options
-	err = wails.Run(&options.App{
+	err := wails.Run(&options.App{
 		Title:  "SandboxieStartMenu",
 		Width:  1024,
 		Height: 768,
@@ -56,6 +39,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Merged folders overlay several real folders as one browsable tree, like
+// Explorer shows the per-user and all-users Start Menu together. Paths
+// below a merged folder are its ID followed by the relative folder path,
+// e.g. "virtual:startmenu\Accessories".
+const (
+	VirtualStartMenu = virtualFolderPrefix + "startmenu"
+	VirtualDesktop   = virtualFolderPrefix + "desktop"
+	VirtualTaskbar   = virtualFolderPrefix + "taskbar"
+
+	mergedPathSeparator = `\`
+)
+
+// mergedFolders maps each merged folder to the function returning its
+// real folders. Earlier folders win when names collide, so per-user
+// entries hide the all-users ones of the same name.
+var mergedFolders = map[string]func() []string{
+	VirtualStartMenu: startMenuFolders,
+	VirtualDesktop:   desktopFolders,
+	VirtualTaskbar:   taskbarFolders,
+}
+
+// splitMergedPath splits a path below a merged folder into the folder ID
+// and the relative path ("" for the merged root). Forward slashes in the
+// relative path are turned into mergedPathSeparator.
+func splitMergedPath(path string) (id string, rel string, ok bool) {
+	for id := range mergedFolders {
+		if path == id {
+			return id, "", true
+		}
+		if strings.HasPrefix(path, id+mergedPathSeparator) {
+			rel = strings.ReplaceAll(path[len(id):], "/", mergedPathSeparator)
+			rel = strings.Trim(rel, mergedPathSeparator)
+			if slices.ContainsFunc(strings.Split(rel, mergedPathSeparator), isDotName) {
+				return "", "", false // Don't let a merged path escape its roots
+			}
+			return id, rel, true
+		}
+	}
+	return "", "", false
+}
+
+// isDotName reports whether a path element is "." or "..", including the
+// forms with trailing dots or spaces that Windows treats the same
+func isDotName(name string) bool {
+	return name != "" && strings.Trim(name, ". ") == ""
+}
+
+// isMergedPath reports whether path is a merged folder or inside one
+func isMergedPath(path string) bool {
+	_, _, ok := splitMergedPath(path)
+	return ok
+}
+
+// mergedParent returns the parent of a path below a merged folder. The
+// merged root itself has no parent.
+func mergedParent(path string) (string, bool) {
+	id, rel, ok := splitMergedPath(path)
+	if !ok || rel == "" {
+		return "", false
+	}
+	if i := strings.LastIndex(rel, mergedPathSeparator); i >= 0 {
+		return id + mergedPathSeparator + rel[:i], true
+	}
+	return id, true
+}
+
+// mergedDirs returns the real folders that make up a merged path
+func mergedDirs(path string) []string {
+	id, rel, ok := splitMergedPath(path)
+	if !ok {
+		return nil
+	}
+	var dirs []string
+	for _, root := range mergedFolders[id]() {
+		dir := filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(rel, mergedPathSeparator, "/")))
+		if inside, err := filepath.Rel(root, dir); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// mergedRoots returns the real root folders of every merged folder, for
+// indexing and search
+func mergedRoots() []string {
+	var roots []string
+	for _, id := range []string{VirtualStartMenu, VirtualDesktop, VirtualTaskbar} {
+		roots = append(roots, mergedFolders[id]()...)
+	}
+	return roots
+}
+
+// mergeListings overlays folder listings of the same merged path.
+// Subfolders are merged into one entry pointing at the merged path;
+// files keep their real path and the first listing wins for duplicate
+// names.
+func mergeListings(path string, listings [][]FileInfo) []FileInfo {
+	folders := []FileInfo{}
+	files := []FileInfo{}
+	seen := make(map[string]bool)
+
+	for _, listing := range listings {
+		for _, f := range listing {
+			key := strings.ToLower(f.Name)
+			if f.IsDir {
+				key += mergedPathSeparator
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if f.IsDir {
+				f.Path = path + mergedPathSeparator + f.Name
+				folders = append(folders, f)
+			} else {
+				files = append(files, f)
+			}
+		}
+	}
+
+	sort.Slice(folders, func(i, j int) bool {
+		return strings.ToLower(folders[i].Name) < strings.ToLower(folders[j].Name)
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
+	})
+	return append(folders, files...)
+}
+
+// legacyDefaultFolders returns the folders older versions added to every
+// config, mapped to the merged folder that replaces them
+func legacyDefaultFolders() map[string]string {
+	legacy := make(map[string]string)
+	if programData := os.Getenv("PROGRAMDATA"); programData != "" {
+		legacy[filepath.Join(programData, "Microsoft", "Windows", "Start Menu", "Programs")] = VirtualStartMenu
+	}
+	if userProfile := os.Getenv("USERPROFILE"); userProfile != "" {
+		legacy[filepath.Join(userProfile, "Desktop")] = VirtualDesktop
+	}
+	return legacy
+}
+
+// mergedPathForLegacy maps a path inside a legacy default folder to the
+// same place in its merged folder
+func mergedPathForLegacy(legacy map[string]string, path string) (string, bool) {
+	for dir, id := range legacy {
+		if strings.EqualFold(path, dir) {
+			return id, true
+		}
+		prefix := dir + string(filepath.Separator)
+		if len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+			rel := strings.ReplaceAll(path[len(prefix):], string(filepath.Separator), mergedPathSeparator)
+			return id + mergedPathSeparator + rel, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitMergedPath(t *testing.T) {
+	tests := []struct {
+		path string
+		id   string
+		rel  string
+		ok   bool
+	}{
+		{VirtualStartMenu, VirtualStartMenu, "", true},
+		{VirtualStartMenu + `\`, VirtualStartMenu, "", true},
+		{VirtualStartMenu + `\Accessories`, VirtualStartMenu, "Accessories", true},
+		{VirtualDesktop + `\Games\Old\`, VirtualDesktop, `Games\Old`, true},
+		{VirtualTaskbar + `\a/b`, VirtualTaskbar, `a\b`, true},
+		{VirtualStartMenu + `\a..b\c.`, VirtualStartMenu, `a..b\c.`, true},
+		{VirtualStartMenu + `\..`, "", "", false},
+		{VirtualStartMenu + `\a\..\..\x`, "", "", false},
+		{VirtualStartMenu + `\a/../../x`, "", "", false},
+		{VirtualStartMenu + `\../x`, "", "", false},
+		{VirtualStartMenu + `\a\.\b`, "", "", false},
+		{VirtualStartMenu + `\a\...\b`, "", "", false},
+		{VirtualStartMenu + `\a\.. \b`, "", "", false},
+		{VirtualStartMenu + "x", "", "", false},
+		{virtualFolderPrefix + "recent", "", "", false},
+		{`C:\Users`, "", "", false},
+	}
+	for _, tt := range tests {
+		id, rel, ok := splitMergedPath(tt.path)
+		if id != tt.id || rel != tt.rel || ok != tt.ok {
+			t.Errorf("splitMergedPath(%q) = %q, %q, %v, want %q, %q, %v", tt.path, id, rel, ok, tt.id, tt.rel, tt.ok)
+		}
+	}
+}
+
+func TestMergedParent(t *testing.T) {
+	tests := []struct {
+		path   string
+		parent string
+		ok     bool
+	}{
+		{VirtualStartMenu, "", false},
+		{VirtualStartMenu + `\Accessories`, VirtualStartMenu, true},
+		{VirtualStartMenu + `\Accessories\Tools`, VirtualStartMenu + `\Accessories`, true},
+		{VirtualDesktop + `\a/b/`, VirtualDesktop + `\a`, true},
+		{VirtualStartMenu + `\a\..`, "", false},
+		{`C:\Users`, "", false},
+	}
+	for _, tt := range tests {
+		parent, ok := mergedParent(tt.path)
+		if parent != tt.parent || ok != tt.ok {
+			t.Errorf("mergedParent(%q) = %q, %v, want %q, %v", tt.path, parent, ok, tt.parent, tt.ok)
+		}
+	}
+}
+
+func TestMergedDirsStayInsideRoots(t *testing.T) {
+	base := t.TempDir()
+	user := filepath.Join(base, "user")
+	common := filepath.Join(base, "common")
+	for _, dir := range []string{filepath.Join(user, "Tools"), filepath.Join(common, "Tools"), filepath.Join(common, "Games")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved := mergedFolders[VirtualStartMenu]
+	mergedFolders[VirtualStartMenu] = func() []string { return []string{user, common} }
+	defer func() { mergedFolders[VirtualStartMenu] = saved }()
+
+	got := mergedDirs(VirtualStartMenu + `\Tools`)
+	if want := []string{filepath.Join(user, "Tools"), filepath.Join(common, "Tools")}; !slices.Equal(got, want) {
+		t.Errorf("mergedDirs(Tools) = %q, want %q", got, want)
+	}
+	if got := mergedDirs(VirtualStartMenu + `\Games`); !slices.Equal(got, []string{filepath.Join(common, "Games")}) {
+		t.Errorf("mergedDirs(Games) = %q", got)
+	}
+	for _, path := range []string{
+		VirtualStartMenu + `\Tools/../..`,
+		VirtualStartMenu + `\a/../../common`,
+		VirtualStartMenu + `\..\user`,
+	} {
+		if got := mergedDirs(path); len(got) != 0 {
+			t.Errorf("mergedDirs(%q) = %q, want nothing outside the roots", path, got)
+		}
+	}
+}
+
+func TestMergeListings(t *testing.T) {
+	perUser := []FileInfo{
+		{Name: "Code.lnk", Path: `C:\User\Code.lnk`},
+		{Name: "Tools", Path: `C:\User\Tools`, IsDir: true},
+		{Name: "notes", Path: `C:\User\notes`},
+	}
+	allUsers := []FileInfo{
+		{Name: "code.LNK", Path: `C:\Common\code.LNK`},
+		{Name: "tools", Path: `C:\Common\tools`, IsDir: true},
+		{Name: "Notes", Path: `C:\Common\Notes`, IsDir: true},
+		{Name: "Accessories", Path: `C:\Common\Accessories`, IsDir: true},
+		{Name: "Paint.lnk", Path: `C:\Common\Paint.lnk`},
+	}
+
+	got := mergeListings(VirtualStartMenu, [][]FileInfo{perUser, allUsers})
+	want := []FileInfo{
+		{Name: "Accessories", Path: VirtualStartMenu + `\Accessories`, IsDir: true},
+		{Name: "Notes", Path: VirtualStartMenu + `\Notes`, IsDir: true},
+		{Name: "Tools", Path: VirtualStartMenu + `\Tools`, IsDir: true},
+		{Name: "Code.lnk", Path: `C:\User\Code.lnk`},
+		{Name: "notes", Path: `C:\User\notes`},
+		{Name: "Paint.lnk", Path: `C:\Common\Paint.lnk`},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mergeListings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Path != want[i].Path || got[i].IsDir != want[i].IsDir {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Resource types and limits for reading icons without the shell
+const (
+	rtIcon      = 3  // RT_ICON
+	rtGroupIcon = 14 // RT_GROUP_ICON
+
+	iconDirSize        = 6  // ICONDIR / GRPICONDIR header
+	iconDirEntrySize   = 16 // ICONDIRENTRY in .ico files
+	groupIconEntrySize = 14 // GRPICONDIRENTRY in PE resources
+
+	maxResourceSize = 16 << 20 // Larger resources are treated as corrupt
+)
+
+// pngSignature starts PNG-compressed icon images
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// iconEntry is one image of an icon, as listed in its directory
+type iconEntry struct {
+	width    int // 0 in the directory means 256
+	bitCount int
+	data     []byte
+}
+
+// ReadFileIcon extracts the icon of a file by parsing it directly, without
+// shell32. It handles .ico files, PE files (.exe, .dll, ...) and shortcuts,
+// returning the image closest to size.
+func ReadFileIcon(path string, size int) (image.Image, error) {
+	if strings.EqualFold(filepath.Ext(path), ".lnk") {
+		link, err := ReadShellLink(path)
+		if err != nil {
+			return nil, err
+		}
+		if link.IconLocation != "" {
+			return readIconResource(link.IconLocation, link.IconIndex, size)
+		}
+		if link.Target != "" {
+			return readIconResource(link.Target, 0, size)
+		}
+		return nil, fmt.Errorf("shortcut has no icon: %s", path)
+	}
+	if isInternetShortcut(path) {
+		shortcut, err := ReadInternetShortcut(path)
+		if err != nil {
+			return nil, err
+		}
+		if shortcut.IconFile == "" {
+			return nil, fmt.Errorf("internet shortcut has no icon: %s", path)
+		}
+		return readIconResource(shortcut.IconFile, shortcut.IconIndex, size)
+	}
+	return readIconResource(path, 0, size)
+}
+
+// readIconResource reads icon index of an .ico or PE file. A negative
+// index selects a resource ID, as in shortcut icon locations.
+func readIconResource(path string, index int, size int) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var magic [2]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if magic == [2]byte{'M', 'Z'} {
+		img, err := ExtractPEIcon(f, index, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract icon from %s: %v", path, err)
+		}
+		return img, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxResourceSize {
+		return nil, fmt.Errorf("icon file too large: %s", path)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	img, err := DecodeICO(data, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return img, nil
+}
+
+// DecodeICO decodes the image closest to size from an .ico file
+func DecodeICO(data []byte, size int) (image.Image, error) {
+	count, err := readIconDir(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < iconDirSize+count*iconDirEntrySize {
+		return nil, fmt.Errorf("truncated icon directory")
+	}
+
+	var entries []iconEntry
+	for i := 0; i < count; i++ {
+		e := data[iconDirSize+i*iconDirEntrySize:]
+		length := int(binary.LittleEndian.Uint32(e[8:12]))
+		offset := int(binary.LittleEndian.Uint32(e[12:16]))
+		if offset < 0 || length <= 0 || offset > len(data) || length > len(data)-offset {
+			continue
+		}
+		entries = append(entries, iconEntry{
+			width:    int(e[0]),
+			bitCount: iconBitCount(e[2], binary.LittleEndian.Uint16(e[6:8])),
+			data:     data[offset : offset+length],
+		})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("icon has no images")
+	}
+	return decodeIconImage(entries[pickIconEntry(entries, size)].data)
+}
+
+// readIconDir checks an ICONDIR / GRPICONDIR header and returns its count
+func readIconDir(data []byte) (int, error) {
+	if len(data) < iconDirSize {
+		return 0, fmt.Errorf("icon directory too short: %d bytes", len(data))
+	}
+	if binary.LittleEndian.Uint16(data[0:2]) != 0 || binary.LittleEndian.Uint16(data[2:4]) != 1 {
+		return 0, fmt.Errorf("not an icon")
+	}
+	return int(binary.LittleEndian.Uint16(data[4:6])), nil
+}
+
+// iconBitCount returns an entry's color depth, deriving it from the color
+// count when the bit count field is not filled in
+func iconBitCount(colorCount uint8, bitCount uint16) int {
+	if bitCount != 0 {
+		return int(bitCount)
+	}
+	switch colorCount {
+	case 2:
+		return 1
+	case 16:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// pickIconEntry returns the index of the smallest image at least size wide
+// with the most colors, or of the largest image when none is big enough
+func pickIconEntry(entries []iconEntry, size int) int {
+	width := func(e iconEntry) int {
+		if e.width == 0 {
+			return 256
+		}
+		return e.width
+	}
+
+	best := 0
+	for i, e := range entries[1:] {
+		bw, ew := width(entries[best]), width(e)
+		switch {
+		case ew == bw:
+			if e.bitCount > entries[best].bitCount {
+				best = i + 1
+			}
+		case bw < size:
+			// Anything larger is closer to the requested size
+			if ew > bw {
+				best = i + 1
+			}
+		case ew >= size && ew < bw:
+			best = i + 1
+		}
+	}
+	return best
+}
+
+// decodeIconImage decodes one icon image, stored either as PNG or as a
+// DIB with an AND mask
+func decodeIconImage(data []byte) (image.Image, error) {
+	if bytes.HasPrefix(data, pngSignature) {
+		return png.Decode(bytes.NewReader(data))
+	}
+	return decodeIconDIB(data)
+}
+
+// decodeIconDIB decodes a BITMAPINFOHEADER icon image: the color bitmap
+// (1, 4, 8, 24 or 32 bits per pixel) followed by a 1-bit AND mask, both
+// stored bottom-up with twice the icon height in the header
+func decodeIconDIB(data []byte) (image.Image, error) {
+	if len(data) < 40 {
+		return nil, fmt.Errorf("icon bitmap too short: %d bytes", len(data))
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:12])))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	compression := binary.LittleEndian.Uint32(data[16:20])
+	colorsUsed := int(binary.LittleEndian.Uint32(data[32:36]))
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	height /= 2 // The header height covers both bitmaps
+	if headerSize < 40 || headerSize > len(data) || width <= 0 || height <= 0 || width > 1024 || height > 1024 {
+		return nil, fmt.Errorf("invalid icon bitmap: %dx%d", width, height)
+	}
+	// BI_RGB, or BI_BITFIELDS with the usual BGRA masks
+	if compression != 0 && !(compression == 3 && bitCount == 32) {
+		return nil, fmt.Errorf("unsupported icon compression: %d", compression)
+	}
+
+	pos := headerSize
+	var palette []byte
+	switch bitCount {
+	case 1, 4, 8:
+		if colorsUsed <= 0 || colorsUsed > 1<<bitCount {
+			colorsUsed = 1 << bitCount
+		}
+		if pos+colorsUsed*4 > len(data) {
+			return nil, fmt.Errorf("truncated icon palette")
+		}
+		palette = data[pos : pos+colorsUsed*4]
+		pos += colorsUsed * 4
+	case 24, 32:
+		if compression == 3 && headerSize == 40 {
+			pos += 12 // Color masks follow the header
+		}
+	default:
+		return nil, fmt.Errorf("unsupported icon bit count: %d", bitCount)
+	}
+
+	stride := (width*bitCount + 31) / 32 * 4
+	maskStride := (width + 31) / 32 * 4
+	if pos+stride*height > len(data) {
+		return nil, fmt.Errorf("truncated icon bitmap")
+	}
+	colors := data[pos : pos+stride*height]
+	var andMask []byte
+	if end := pos + stride*height + maskStride*height; end <= len(data) {
+		andMask = data[pos+stride*height : end]
+	}
+
+	// Convert to top-down BGRA and a per-pixel mask for imageFromBGRA
+	pixels := make([]byte, width*height*4)
+	mask := make([]byte, width*height*4)
+	for y := 0; y < height; y++ {
+		row := height - 1 - y
+		if topDown {
+			row = y
+		}
+		src := colors[row*stride : (row+1)*stride]
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 4
+			switch bitCount {
+			case 32:
+				copy(pixels[i:i+4], src[x*4:x*4+4])
+			case 24:
+				copy(pixels[i:i+3], src[x*3:x*3+3])
+			default:
+				bit := x * bitCount
+				index := int(src[bit/8]>>(8-bitCount-bit%8)) & (1<<bitCount - 1)
+				if index*4+3 < len(palette) {
+					copy(pixels[i:i+3], palette[index*4:index*4+3])
+				}
+			}
+			if andMask != nil && andMask[row*maskStride+x/8]&(0x80>>(x%8)) != 0 {
+				mask[i] = 0xFF
+			}
+		}
+	}
+	if andMask == nil {
+		mask = nil
+	}
+	return imageFromBGRA(pixels, mask, width, height), nil
+}
+
+// ExtractPEIcon extracts an icon from the resources of a PE file. A
+// non-negative index selects the index-th icon group in resource order,
+// a negative index the group with resource ID -index.
+func ExtractPEIcon(r io.ReaderAt, index int, size int) (image.Image, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dir pe.DataDirectory
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if h.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_RESOURCE {
+			dir = h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE]
+		}
+	case *pe.OptionalHeader64:
+		if h.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_RESOURCE {
+			dir = h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE]
+		}
+	}
+	if dir.VirtualAddress == 0 || dir.Size == 0 {
+		return nil, fmt.Errorf("no resources")
+	}
+
+	img := &peImage{r: r, sections: f.Sections}
+	rsrc, err := img.readRVA(dir.VirtualAddress, dir.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := img.resources(rsrc, rtGroupIcon)
+	if err != nil {
+		return nil, err
+	}
+	var group *peResource
+	if index >= 0 {
+		if index < len(groups) {
+			group = &groups[index]
+		}
+	} else {
+		for i := range groups {
+			if groups[i].id == -index {
+				group = &groups[i]
+				break
+			}
+		}
+	}
+	if group == nil {
+		return nil, fmt.Errorf("icon %d not found", index)
+	}
+
+	icons, err := img.resources(rsrc, rtIcon)
+	if err != nil {
+		return nil, err
+	}
+	iconsByID := make(map[int]peResource, len(icons))
+	for _, icon := range icons {
+		iconsByID[icon.id] = icon
+	}
+
+	data, err := img.readRVA(group.rva, group.size)
+	if err != nil {
+		return nil, err
+	}
+	count, err := readIconDir(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < iconDirSize+count*groupIconEntrySize {
+		return nil, fmt.Errorf("truncated icon group")
+	}
+
+	var entries []iconEntry
+	var resources []peResource
+	for i := 0; i < count; i++ {
+		e := data[iconDirSize+i*groupIconEntrySize:]
+		icon, ok := iconsByID[int(binary.LittleEndian.Uint16(e[12:14]))]
+		if !ok {
+			continue
+		}
+		entries = append(entries, iconEntry{
+			width:    int(e[0]),
+			bitCount: iconBitCount(e[2], binary.LittleEndian.Uint16(e[6:8])),
+		})
+		resources = append(resources, icon)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("icon group has no images")
+	}
+
+	// Only the chosen image is read from the file
+	icon := resources[pickIconEntry(entries, size)]
+	data, err = img.readRVA(icon.rva, icon.size)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIconImage(data)
+}
+
+// peImage reads data from a PE file by relative virtual address
+type peImage struct {
+	r        io.ReaderAt
+	sections []*pe.Section
+}
+
+// peResource is one resource in the resource directory
+type peResource struct {
+	id   int // -1 for resources with a name instead of an ID
+	rva  uint32
+	size uint32
+}
+
+// readRVA reads size bytes at rva from the section that contains it
+func (p *peImage) readRVA(rva uint32, size uint32) ([]byte, error) {
+	if size > maxResourceSize {
+		return nil, fmt.Errorf("resource too large: %d bytes", size)
+	}
+	for _, s := range p.sections {
+		if rva < s.VirtualAddress || rva-s.VirtualAddress >= max(s.VirtualSize, s.Size) {
+			continue
+		}
+		offset := rva - s.VirtualAddress
+		if uint64(offset)+uint64(size) > uint64(s.Size) {
+			return nil, fmt.Errorf("resource at 0x%x outside section %s", rva, s.Name)
+		}
+		data := make([]byte, size)
+		if _, err := p.r.ReadAt(data, int64(s.Offset)+int64(offset)); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("no section contains address 0x%x", rva)
+}
+
+// resources lists the resources of one type in directory order (named
+// entries first, then IDs ascending), using the first language of each
+func (p *peImage) resources(rsrc []byte, typeID int) ([]peResource, error) {
+	types, err := readResourceDir(rsrc, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []peResource
+	for _, t := range types {
+		if t.id != typeID || !t.isDir {
+			continue
+		}
+		names, err := readResourceDir(rsrc, t.offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			entry := name
+			if entry.isDir {
+				languages, err := readResourceDir(rsrc, entry.offset)
+				if err != nil || len(languages) == 0 {
+					continue
+				}
+				entry = languages[0]
+			}
+			if entry.isDir || int(entry.offset)+16 > len(rsrc) {
+				continue
+			}
+			result = append(result, peResource{
+				id:   name.id,
+				rva:  binary.LittleEndian.Uint32(rsrc[entry.offset:]),
+				size: binary.LittleEndian.Uint32(rsrc[entry.offset+4:]),
+			})
+		}
+	}
+	return result, nil
+}
+
+// resourceDirEntry is one entry of an IMAGE_RESOURCE_DIRECTORY
+type resourceDirEntry struct {
+	id     int    // -1 for named entries
+	isDir  bool   // offset points to a subdirectory rather than data
+	offset uint32 // Relative to the start of the resource section
+}
+
+// readResourceDir reads the entries of the resource directory at offset
+func readResourceDir(rsrc []byte, offset uint32) ([]resourceDirEntry, error) {
+	if uint64(offset)+16 > uint64(len(rsrc)) {
+		return nil, fmt.Errorf("resource directory out of range: 0x%x", offset)
+	}
+	named := int(binary.LittleEndian.Uint16(rsrc[offset+12:]))
+	ids := int(binary.LittleEndian.Uint16(rsrc[offset+14:]))
+	if uint64(offset)+16+uint64(named+ids)*8 > uint64(len(rsrc)) {
+		return nil, fmt.Errorf("truncated resource directory at 0x%x", offset)
+	}
+
+	entries := make([]resourceDirEntry, 0, named+ids)
+	for i := 0; i < named+ids; i++ {
+		e := rsrc[int(offset)+16+i*8:]
+		name := binary.LittleEndian.Uint32(e[0:4])
+		data := binary.LittleEndian.Uint32(e[4:8])
+		entry := resourceDirEntry{id: -1, isDir: data&0x80000000 != 0, offset: data &^ 0x80000000}
+		if name&0x80000000 == 0 {
+			entry.id = int(name & 0xFFFF)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
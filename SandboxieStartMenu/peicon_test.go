@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// webView2Setup is a real signed PE shipped with the installer
+const webView2Setup = "build/windows/installer/tmp/MicrosoftEdgeWebview2Setup.exe"
+
+func readFixture(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+// checkPixel compares one pixel of img as non-premultiplied RGBA
+func checkPixel(t *testing.T, img image.Image, x, y int, want color.NRGBA) {
+	t.Helper()
+	got := color.NRGBAModel.Convert(img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)).(color.NRGBA)
+	if want.A == 0 {
+		if got.A != 0 {
+			t.Errorf("pixel (%d,%d) = %v, want transparent", x, y, got)
+		}
+		return
+	}
+	if got != want {
+		t.Errorf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+	}
+}
+
+var (
+	red         = color.NRGBA{R: 255, A: 255}
+	green       = color.NRGBA{G: 255, A: 255}
+	transparent = color.NRGBA{}
+)
+
+func TestDecodeICO(t *testing.T) {
+	mixed := readFixture(t, "testdata/icons/mixed.ico")
+	app := readFixture(t, "testdata/icons/app.ico")
+
+	tests := []struct {
+		name   string
+		data   []byte
+		size   int
+		width  int
+		pixels map[image.Point]color.NRGBA
+	}{
+		{
+			name:  "4bpp BMP with AND mask",
+			data:  mixed,
+			size:  2,
+			width: 2,
+			pixels: map[image.Point]color.NRGBA{
+				{0, 0}: red,
+				{1, 0}: green,
+				{0, 1}: green,
+				{1, 1}: transparent,
+			},
+		},
+		{
+			name:  "32bpp BMP ignores AND mask",
+			data:  mixed,
+			size:  16,
+			width: 16,
+			pixels: map[image.Point]color.NRGBA{
+				{0, 0}:  {B: 255, A: 128},
+				{15, 7}: {B: 255, A: 128},
+				{0, 8}:  {R: 255, G: 255, B: 255, A: 255},
+			},
+		},
+		{
+			name:  "PNG entry",
+			data:  mixed,
+			size:  32,
+			width: 48,
+			pixels: map[image.Point]color.NRGBA{
+				{0, 0}: {R: 255, B: 255, A: 100},
+				{1, 1}: transparent,
+			},
+		},
+		{name: "largest when none is big enough", data: mixed, size: 256, width: 48},
+		{name: "app icon 16", data: app, size: 16, width: 16},
+		{name: "app icon 48", data: app, size: 48, width: 64},
+		{name: "app icon 256", data: app, size: 256, width: 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := DecodeICO(tt.data, tt.size)
+			if err != nil {
+				t.Fatalf("DecodeICO: %v", err)
+			}
+			if w := img.Bounds().Dx(); w != tt.width {
+				t.Fatalf("width = %d, want %d", w, tt.width)
+			}
+			for p, want := range tt.pixels {
+				checkPixel(t, img, p.X, p.Y, want)
+			}
+		})
+	}
+}
+
+func TestDecodeICOInvalid(t *testing.T) {
+	mixed := readFixture(t, "testdata/icons/mixed.ico")
+	for _, data := range [][]byte{nil, []byte("MZ not an icon"), {0, 0, 2, 0, 1, 0}} {
+		if _, err := DecodeICO(data, 32); err == nil {
+			t.Errorf("DecodeICO(%q) succeeded", data)
+		}
+	}
+	// Truncated files must fail cleanly, not panic
+	for n := 0; n < len(mixed); n++ {
+		DecodeICO(mixed[:n], 16)
+	}
+}
+
+func TestExtractPEIcon(t *testing.T) {
+	resources := readFixture(t, "testdata/icons/resources.exe")
+
+	tests := []struct {
+		name  string
+		index int
+		size  int
+		width int
+		pixel *color.NRGBA // Expected pixel (0,0)
+	}{
+		{name: "first group, smallest", index: 0, size: 2, width: 2, pixel: &red},
+		{name: "first group, BMP", index: 0, size: 16, width: 16},
+		{name: "first group, PNG", index: 0, size: 48, width: 48},
+		{name: "first group, too large", index: 0, size: 256, width: 48},
+		{name: "second group by index", index: 1, size: 48, width: 2},
+		{name: "group by resource ID", index: -7, size: 48, width: 2},
+		{name: "first group by resource ID", index: -1, size: 32, width: 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := ExtractPEIcon(bytes.NewReader(resources), tt.index, tt.size)
+			if err != nil {
+				t.Fatalf("ExtractPEIcon: %v", err)
+			}
+			if w := img.Bounds().Dx(); w != tt.width {
+				t.Fatalf("width = %d, want %d", w, tt.width)
+			}
+			if tt.pixel != nil {
+				checkPixel(t, img, 0, 0, *tt.pixel)
+			}
+		})
+	}
+
+	for _, index := range []int{2, -3} {
+		if _, err := ExtractPEIcon(bytes.NewReader(resources), index, 32); err == nil {
+			t.Errorf("ExtractPEIcon(index %d) succeeded", index)
+		}
+	}
+	for n := 0; n < len(resources); n += 3 {
+		ExtractPEIcon(bytes.NewReader(resources[:n]), 0, 32)
+	}
+}
+
+func TestExtractPEIconWebView2Setup(t *testing.T) {
+	f, err := os.Open(webView2Setup)
+	if err != nil {
+		t.Skipf("fixture not available: %v", err)
+	}
+	defer f.Close()
+
+	for _, size := range iconSizes {
+		img, err := ExtractPEIcon(f, 0, size)
+		if err != nil {
+			t.Fatalf("ExtractPEIcon(%d): %v", size, err)
+		}
+		if img.Bounds().Dx() == 0 || img.Bounds().Dx() != img.Bounds().Dy() {
+			t.Errorf("size %d: bounds %v", size, img.Bounds())
+		}
+	}
+}
+
+func TestReadFileIcon(t *testing.T) {
+	for _, path := range []string{"testdata/icons/mixed.ico", "testdata/icons/resources.exe"} {
+		img, err := ReadFileIcon(path, IconSizeExtraLarge)
+		if err != nil {
+			t.Fatalf("ReadFileIcon(%s): %v", path, err)
+		}
+		if w := img.Bounds().Dx(); w != 48 {
+			t.Errorf("ReadFileIcon(%s) width = %d, want 48", filepath.Base(path), w)
+		}
+	}
+	if _, err := ReadFileIcon("testdata/icons/missing.exe", 32); err == nil {
+		t.Error("ReadFileIcon of a missing file succeeded")
+	}
+}
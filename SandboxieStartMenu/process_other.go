@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// ProcessPath returns the executable path of a process
+func (s *sandboxieProcessSource) ProcessPath(pid int) (string, error) {
+	return os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe")
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	processPollInterval = 2 * time.Second  // How often active boxes are polled
+	pendingLaunchExpiry = 30 * time.Second // How long a launch waits for its process
+)
+
+// ProcessSource lists processes running inside a sandbox. The real
+// implementation asks Sandboxie; tests can provide fake processes.
+type ProcessSource interface {
+	BoxProcesses(box string) ([]int, error)
+	ProcessPath(pid int) (string, error)
+}
+
+// RunningProgram is a process running inside a sandbox
+type RunningProgram struct {
+	PID        int       `json:"pid"`
+	Box        string    `json:"box"`
+	Path       string    `json:"path"`       // Executable path, empty if it couldn't be read
+	Name       string    `json:"name"`       // Executable file name
+	LaunchedAs string    `json:"launchedAs"` // Menu entry that started it, empty for other processes
+	StartedAt  time.Time `json:"startedAt"`  // When the tracker first saw the process
+}
+
+// pendingLaunch is a launch whose process hasn't been seen yet
+type pendingLaunch struct {
+	box      string // boxKey of the box
+	entry    string // Path the user launched (e.g. the .lnk)
+	exeName  string // Expected executable name, empty if unknown
+	launched time.Time
+	matched  func(pid int) // Called with the program's PID once it is seen, may be nil
+}
+
+// trackedBox is a box being polled. Boxes are keyed by boxKey since
+// Sandboxie box names are case-insensitive.
+type trackedBox struct {
+	name     string                  // Name as first given, passed to the source
+	programs map[int]*RunningProgram // pid -> program
+}
+
+// boxKey returns the map key of a box name
+func boxKey(box string) string {
+	return strings.ToLower(box)
+}
+
+// sandboxieProcessSource lists boxed processes through Start.exe
+type sandboxieProcessSource struct {
+	sandboxieManager *SandboxieManager
+}
+
+// BoxProcesses returns the PIDs of the processes running in box
+func (s *sandboxieProcessSource) BoxProcesses(box string) ([]int, error) {
+	return s.sandboxieManager.ListProcessIDs(box)
+}
+
+// ProcessTracker follows the processes of boxes we launched programs in
+// and reports when they start and exit
+type ProcessTracker struct {
+	mu      sync.Mutex
+	source  ProcessSource
+	running map[string]*trackedBox // boxKey -> box
+	pending []pendingLaunch
+
+	onStarted func(RunningProgram)
+	onExited  func(RunningProgram)
+	now       func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewProcessTracker creates a tracker using source to list processes
+func NewProcessTracker(source ProcessSource) *ProcessTracker {
+	return &ProcessTracker{
+		source:  source,
+		running: make(map[string]*trackedBox),
+		now:     time.Now,
+	}
+}
+
+// Start begins polling in the background. The callbacks are called from
+// the polling goroutine.
+func (pt *ProcessTracker) Start(onStarted, onExited func(RunningProgram)) {
+	pt.mu.Lock()
+	pt.onStarted = onStarted
+	pt.onExited = onExited
+	pt.ctx, pt.cancel = context.WithCancel(context.Background())
+	pt.mu.Unlock()
+
+	pt.wg.Add(1)
+	go func() {
+		defer pt.wg.Done()
+		ticker := time.NewTicker(processPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pt.ctx.Done():
+				return
+			case <-ticker.C:
+				pt.PollAll()
+			}
+		}
+	}()
+}
+
+// Stop stops background polling
+func (pt *ProcessTracker) Stop() {
+	pt.mu.Lock()
+	cancel := pt.cancel
+	pt.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	pt.wg.Wait()
+}
+
+// BeginLaunch records the processes already running in box so they are
+// not mistaken for the program about to be launched
+func (pt *ProcessTracker) BeginLaunch(box string) {
+	if !isTrackableBox(box) {
+		return
+	}
+	pt.mu.Lock()
+	_, active := pt.running[boxKey(box)]
+	pt.mu.Unlock()
+
+	if !active {
+		pt.Poll(box)
+	}
+}
+
+// TrackLaunch registers a launch; the next new process in box whose name
+// matches target is attributed to entry and passed to matched
+func (pt *ProcessTracker) TrackLaunch(box string, entry string, target string, matched func(pid int)) {
+	if !isTrackableBox(box) {
+		return
+	}
+
+	exeName := ""
+	if strings.EqualFold(filepath.Ext(target), ".exe") {
+		exeName = strings.ToLower(filepath.Base(target))
+	}
+
+	pt.mu.Lock()
+	pt.trackedBoxLocked(box)
+	pt.pending = append(pt.pending, pendingLaunch{
+		box:      boxKey(box),
+		entry:    entry,
+		exeName:  exeName,
+		launched: pt.now(),
+		matched:  matched,
+	})
+	pt.mu.Unlock()
+}
+
+// PollAll polls every box that has tracked programs or pending launches
+func (pt *ProcessTracker) PollAll() {
+	pt.mu.Lock()
+	boxes := make([]string, 0, len(pt.running))
+	for _, tracked := range pt.running {
+		boxes = append(boxes, tracked.name)
+	}
+	pt.mu.Unlock()
+
+	for _, box := range boxes {
+		pt.Poll(box)
+	}
+}
+
+// Poll refreshes the processes of one box and fires started/exited callbacks
+func (pt *ProcessTracker) Poll(box string) {
+	pids, err := pt.source.BoxProcesses(box)
+	if err != nil {
+		return
+	}
+
+	current := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		current[pid] = true
+	}
+
+	key := boxKey(box)
+	pt.mu.Lock()
+	_, ok := pt.running[key]
+	baseline := !ok
+	known := pt.trackedBoxLocked(box).programs
+
+	var started, exited []RunningProgram
+	var matches []func()
+	for pid, prog := range known {
+		if !current[pid] {
+			exited = append(exited, *prog)
+			delete(known, pid)
+		}
+	}
+
+	var newPIDs []int
+	for _, pid := range pids {
+		if _, ok := known[pid]; !ok {
+			newPIDs = append(newPIDs, pid)
+		}
+	}
+	pt.mu.Unlock()
+
+	// Read executable paths without holding the lock
+	paths := make(map[int]string, len(newPIDs))
+	for _, pid := range newPIDs {
+		path, _ := pt.source.ProcessPath(pid)
+		paths[pid] = path
+	}
+
+	pt.mu.Lock()
+	// Another poll may have run meanwhile and already reported some of the
+	// new processes, or dropped the box
+	tracked := pt.trackedBoxLocked(box)
+	known = tracked.programs
+	now := pt.now()
+	sort.Ints(newPIDs)
+	for _, pid := range newPIDs {
+		if _, ok := known[pid]; ok {
+			continue
+		}
+		prog := &RunningProgram{
+			PID:       pid,
+			Box:       tracked.name,
+			Path:      paths[pid],
+			Name:      filepath.Base(paths[pid]),
+			StartedAt: now,
+		}
+		if paths[pid] == "" {
+			prog.Name = ""
+		}
+		if !baseline {
+			if launch, ok := pt.claimPendingLocked(key, prog.Name); ok {
+				prog.LaunchedAs = launch.entry
+				if launch.matched != nil {
+					matched, pid := launch.matched, pid
+					matches = append(matches, func() { matched(pid) })
+				}
+			}
+			started = append(started, *prog)
+		}
+		known[pid] = prog
+	}
+	pt.expirePendingLocked(now)
+
+	// Stop polling boxes with nothing left to follow
+	if len(known) == 0 && !pt.hasPendingLocked(key) {
+		delete(pt.running, key)
+	}
+	onStarted, onExited := pt.onStarted, pt.onExited
+	pt.mu.Unlock()
+
+	for _, prog := range exited {
+		if onExited != nil {
+			onExited(prog)
+		}
+	}
+	for _, prog := range started {
+		if onStarted != nil {
+			onStarted(prog)
+		}
+	}
+	for _, matched := range matches {
+		matched()
+	}
+}
+
+// trackedBoxLocked returns the tracked state of box, adding it if needed
+func (pt *ProcessTracker) trackedBoxLocked(box string) *trackedBox {
+	key := boxKey(box)
+	tracked, ok := pt.running[key]
+	if !ok {
+		tracked = &trackedBox{name: box, programs: make(map[int]*RunningProgram)}
+		pt.running[key] = tracked
+	}
+	return tracked
+}
+
+// claimPendingLocked attributes a new process to a pending launch. Launches
+// with a known executable name only match that name; others (.bat, .lnk
+// we couldn't resolve) take the first new process in the box.
+func (pt *ProcessTracker) claimPendingLocked(key string, name string) (pendingLaunch, bool) {
+	lower := strings.ToLower(name)
+	match := -1
+	for i, p := range pt.pending {
+		if p.box != key {
+			continue
+		}
+		if p.exeName != "" && p.exeName == lower {
+			match = i
+			break
+		}
+		if p.exeName == "" && match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return pendingLaunch{}, false
+	}
+	launch := pt.pending[match]
+	pt.pending = append(pt.pending[:match], pt.pending[match+1:]...)
+	return launch, true
+}
+
+// expirePendingLocked drops launches whose process never showed up
+func (pt *ProcessTracker) expirePendingLocked(now time.Time) {
+	kept := pt.pending[:0]
+	for _, p := range pt.pending {
+		if now.Sub(p.launched) < pendingLaunchExpiry {
+			kept = append(kept, p)
+		}
+	}
+	pt.pending = kept
+}
+
+// hasPendingLocked reports whether a box has launches waiting for a process
+func (pt *ProcessTracker) hasPendingLocked(key string) bool {
+	for _, p := range pt.pending {
+		if p.box == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RunningPrograms returns the tracked processes of box, or of all boxes
+// when box is empty, ordered by start time
+func (pt *ProcessTracker) RunningPrograms(box string) []RunningProgram {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	programs := []RunningProgram{}
+	for key, tracked := range pt.running {
+		if box != "" && key != boxKey(box) {
+			continue
+		}
+		for _, prog := range tracked.programs {
+			programs = append(programs, *prog)
+		}
+	}
+	sort.Slice(programs, func(i, j int) bool {
+		if !programs[i].StartedAt.Equal(programs[j].StartedAt) {
+			return programs[i].StartedAt.Before(programs[j].StartedAt)
+		}
+		return programs[i].PID < programs[j].PID
+	})
+	return programs
+}
+
+// isTrackableBox reports whether we know which box a launch runs in
+func isTrackableBox(box string) bool {
+	return box != "" && box != "__ask__"
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProcessSource serves processes from maps; box names are matched
+// case-insensitively like Sandboxie does
+type fakeProcessSource struct {
+	mu    sync.Mutex
+	boxes map[string][]int
+	paths map[int]string
+
+	// When set, the first two ProcessPath calls wait for each other
+	barrier   *sync.WaitGroup
+	pathCalls int
+}
+
+func newFakeProcessSource() *fakeProcessSource {
+	return &fakeProcessSource{boxes: make(map[string][]int), paths: make(map[int]string)}
+}
+
+func (f *fakeProcessSource) start(box string, pid int, path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.boxes[strings.ToLower(box)] = append(f.boxes[strings.ToLower(box)], pid)
+	f.paths[pid] = path
+}
+
+func (f *fakeProcessSource) exit(box string, pid int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pids := f.boxes[strings.ToLower(box)]
+	for i, p := range pids {
+		if p == pid {
+			f.boxes[strings.ToLower(box)] = append(pids[:i:i], pids[i+1:]...)
+			break
+		}
+	}
+}
+
+func (f *fakeProcessSource) BoxProcesses(box string) ([]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.boxes[strings.ToLower(box)]...), nil
+}
+
+func (f *fakeProcessSource) ProcessPath(pid int) (string, error) {
+	f.mu.Lock()
+	path, ok := f.paths[pid]
+	f.pathCalls++
+	barrier := f.barrier
+	if f.pathCalls > 2 {
+		barrier = nil
+	}
+	f.mu.Unlock()
+
+	if barrier != nil {
+		barrier.Done()
+		barrier.Wait()
+	}
+	if !ok {
+		return "", fmt.Errorf("no process %d", pid)
+	}
+	return path, nil
+}
+
+// trackerEvents collects the callbacks of a tracker
+type trackerEvents struct {
+	mu      sync.Mutex
+	started []RunningProgram
+	exited  []RunningProgram
+}
+
+func newTestTracker(source ProcessSource) (*ProcessTracker, *trackerEvents) {
+	pt := NewProcessTracker(source)
+	events := &trackerEvents{}
+	pt.onStarted = func(p RunningProgram) {
+		events.mu.Lock()
+		events.started = append(events.started, p)
+		events.mu.Unlock()
+	}
+	pt.onExited = func(p RunningProgram) {
+		events.mu.Lock()
+		events.exited = append(events.exited, p)
+		events.mu.Unlock()
+	}
+	return pt, events
+}
+
+func TestProcessTrackerLaunch(t *testing.T) {
+	source := newFakeProcessSource()
+	source.start("DefaultBox", 100, `C:\Windows\explorer.exe`)
+	pt, events := newTestTracker(source)
+
+	// Processes already in the box are the baseline, not new starts
+	pt.BeginLaunch("DefaultBox")
+	var matched []int
+	pt.TrackLaunch("DefaultBox", `C:\Menu\Notepad.lnk`, `C:\Windows\notepad.exe`, func(pid int) {
+		matched = append(matched, pid)
+	})
+	source.start("DefaultBox", 300, `C:\Windows\System32\conhost.exe`)
+	source.start("DefaultBox", 200, `C:\Windows\NOTEPAD.EXE`)
+	pt.PollAll()
+
+	if len(events.started) != 2 {
+		t.Fatalf("started %d programs, want 2", len(events.started))
+	}
+	for _, p := range events.started {
+		want := ""
+		if p.PID == 200 {
+			want = `C:\Menu\Notepad.lnk`
+		}
+		if p.LaunchedAs != want {
+			t.Errorf("pid %d launched as %q, want %q", p.PID, p.LaunchedAs, want)
+		}
+	}
+	if len(matched) != 1 || matched[0] != 200 {
+		t.Errorf("launch matched pids %v, want [200]", matched)
+	}
+	if got := pt.RunningPrograms("DefaultBox"); len(got) != 3 {
+		t.Errorf("RunningPrograms = %d programs, want 3", len(got))
+	}
+
+	source.exit("DefaultBox", 200)
+	pt.PollAll()
+	if len(events.exited) != 1 || events.exited[0].PID != 200 {
+		t.Fatalf("exited = %+v, want pid 200", events.exited)
+	}
+}
+
+func TestProcessTrackerBoxNameCase(t *testing.T) {
+	source := newFakeProcessSource()
+	pt, events := newTestTracker(source)
+
+	pt.BeginLaunch("DefaultBox")
+	pt.TrackLaunch("DefaultBox", `C:\Menu\App.lnk`, `C:\App\app.exe`, nil)
+	source.start("defaultbox", 10, `C:\App\app.exe`)
+	pt.Poll("DEFAULTBOX")
+	pt.PollAll()
+
+	if len(events.started) != 1 {
+		t.Fatalf("started %d programs, want 1", len(events.started))
+	}
+	if p := events.started[0]; p.Box != "DefaultBox" || p.LaunchedAs != `C:\Menu\App.lnk` {
+		t.Errorf("started %+v", p)
+	}
+	for _, box := range []string{"defaultbox", "DefaultBox", ""} {
+		if got := pt.RunningPrograms(box); len(got) != 1 {
+			t.Errorf("RunningPrograms(%q) = %d programs, want 1", box, len(got))
+		}
+	}
+	if got := pt.RunningPrograms("OtherBox"); len(got) != 0 {
+		t.Errorf("RunningPrograms(OtherBox) = %d programs, want 0", len(got))
+	}
+}
+
+func TestProcessTrackerConcurrentPolls(t *testing.T) {
+	source := newFakeProcessSource()
+	pt, events := newTestTracker(source)
+	pt.BeginLaunch("DefaultBox")
+	pt.TrackLaunch("DefaultBox", `C:\Menu\App.lnk`, `C:\App\app.exe`, nil)
+
+	// Both polls see pid 10 as new and read its path at the same time
+	source.barrier = &sync.WaitGroup{}
+	source.barrier.Add(2)
+	source.start("DefaultBox", 10, `C:\App\app.exe`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pt.Poll("DefaultBox")
+		}()
+	}
+	wg.Wait()
+
+	if len(events.started) != 1 {
+		t.Errorf("started %d events for one process, want 1", len(events.started))
+	}
+}
+
+func TestProcessTrackerPendingExpiry(t *testing.T) {
+	source := newFakeProcessSource()
+	pt, events := newTestTracker(source)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pt.now = func() time.Time { return now }
+
+	pt.BeginLaunch("DefaultBox")
+	pt.TrackLaunch("DefaultBox", `C:\Menu\Slow.lnk`, `C:\Slow\slow.exe`, nil)
+	now = now.Add(pendingLaunchExpiry + time.Second)
+	pt.PollAll()
+	if len(pt.RunningPrograms("")) != 0 || len(pt.pending) != 0 {
+		t.Fatal("expired launch still tracked")
+	}
+
+	// The box is no longer polled, so this is a new baseline
+	source.start("DefaultBox", 10, `C:\Slow\slow.exe`)
+	pt.PollAll()
+	if len(events.started) != 0 {
+		t.Errorf("started %+v after the launch expired", events.started)
+	}
+}
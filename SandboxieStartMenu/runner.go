@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os/exec"
+)
+
+// Command is a program invocation prepared for a CommandRunner
+type Command struct {
+	Path string
+	Args []string
+	Dir  string   // Working directory, empty for the current one
+	Env  []string // Full environment, nil to inherit ours
+}
+
+// CommandRunner runs external programs. SandboxieManager uses it for
+// every Start.exe call so tests can substitute a fake.
+type CommandRunner interface {
+	// Run runs the command to completion and returns its standard output
+	Run(cmd Command) ([]byte, error)
+	// Start starts the command without waiting and returns its PID
+	Start(cmd Command) (int, error)
+}
+
+// execRunner runs commands with os/exec
+type execRunner struct{}
+
+// build converts a Command into an exec.Cmd
+func (execRunner) build(c Command) *exec.Cmd {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = c.Env
+	return cmd
+}
+
+// Run runs the command and returns its standard output
+func (r execRunner) Run(c Command) ([]byte, error) {
+	return r.build(c).Output()
+}
+
+// Start starts the command and reaps it in the background
+func (r execRunner) Start(c Command) (int, error) {
+	cmd := r.build(c)
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	// Release the process handle once it exits
+	go cmd.Wait()
+
+	return cmd.Process.Pid, nil
+}
@@ -3,72 +3,193 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // SandboxieManager handles Sandboxie operations
 type SandboxieManager struct {
+	mu           sync.RWMutex
 	startExePath string
+	status       SandboxieStatus
+	runner       CommandRunner
 }
 
-// NewSandboxieManager creates a new Sandboxie manager
-func NewSandboxieManager() *SandboxieManager {
-	// Try common Sandboxie installation paths
-	paths := []string{
-		"C:\\Program Files\\Sandboxie-Plus\\Start.exe",
-		"C:\\Program Files\\Sandboxie\\Start.exe",
-		"C:\\Program Files (x86)\\Sandboxie\\Start.exe",
-	}
+// NewSandboxieManager creates a new Sandboxie manager, looking for
+// Start.exe at configuredPath first and then in the usual places
+func NewSandboxieManager(configuredPath string) *SandboxieManager {
+	sm := NewSandboxieManagerWithRunner("", execRunner{})
+	sm.Detect(configuredPath)
+	return sm
+}
 
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
-			return &SandboxieManager{startExePath: path}
-		}
+// NewSandboxieManagerWithRunner creates a Sandboxie manager using a given
+// Start.exe path and command runner
+func NewSandboxieManagerWithRunner(startExePath string, runner CommandRunner) *SandboxieManager {
+	return &SandboxieManager{
+		startExePath: startExePath,
+		status: SandboxieStatus{
+			Available:    startExePath != "",
+			StartExePath: startExePath,
+			Tried:        []string{},
+		},
+		runner: runner,
 	}
+}
+
+// Detect runs the discovery chain again and switches to the Start.exe it finds
+func (sm *SandboxieManager) Detect(configuredPath string) SandboxieStatus {
+	status := discoverSandboxie(discoveryChain(configuredPath))
+
+	sm.mu.Lock()
+	sm.startExePath = status.StartExePath
+	sm.status = status
+	sm.mu.Unlock()
 
-	return &SandboxieManager{startExePath: ""}
+	return status
+}
+
+// Status returns where Start.exe was found
+func (sm *SandboxieManager) Status() SandboxieStatus {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.status
 }
 
 // IsAvailable checks if Sandboxie is installed
 func (sm *SandboxieManager) IsAvailable() bool {
-	return sm.startExePath != ""
+	return sm.GetStartExePath() != ""
 }
 
 // GetStartExePath returns the path to Start.exe
 func (sm *SandboxieManager) GetStartExePath() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	return sm.startExePath
 }
 
+// GetSandboxieIniPath returns the Sandboxie.ini in use, or "" if none was found
+func (sm *SandboxieManager) GetSandboxieIniPath() string {
+	for _, path := range sandboxieIniCandidates(sm.GetStartExePath()) {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ListSandboxes returns the boxes defined in Sandboxie.ini
+func (sm *SandboxieManager) ListSandboxes() ([]SandboxInfo, error) {
+	iniPath := sm.GetSandboxieIniPath()
+	if iniPath == "" {
+		return nil, fmt.Errorf("Sandboxie.ini not found")
+	}
+
+	ini, err := LoadSandboxieIni(iniPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ini.Sandboxes(), nil
+}
+
+// mergeSandboxNames combines the enabled boxes from Sandboxie.ini with the
+// manually configured list, keeping DefaultBox first and __ask__ last
+func mergeSandboxNames(configured []string, discovered []SandboxInfo) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+
+	add("DefaultBox")
+	for _, box := range discovered {
+		if box.Enabled {
+			add(box.Name)
+		}
+	}
+	for _, name := range configured {
+		if name != "__ask__" {
+			add(name)
+		}
+	}
+	add("__ask__")
+
+	return names
+}
+
 // LaunchProgram launches a program in the specified sandbox
 func (sm *SandboxieManager) LaunchProgram(filePath string, sandbox string) (int, error) {
+	return sm.LaunchProgramWithOptions(filePath, sandbox, LaunchOptions{})
+}
+
+// LaunchProgramWithOptions launches a program in the specified sandbox
+// with arguments, working directory, environment and Start.exe switches
+func (sm *SandboxieManager) LaunchProgramWithOptions(filePath string, sandbox string, opts LaunchOptions) (int, error) {
 	if !sm.IsAvailable() {
 		return 0, fmt.Errorf("Sandboxie 未安装")
 	}
 
-	// Validate file exists
-	if _, err := os.Stat(filePath); err != nil {
-		return 0, fmt.Errorf("file not found: %s", filePath)
+	// Validate file exists; URLs are opened by the box's default browser
+	if !isLaunchableURL(filePath) {
+		if _, err := os.Stat(filePath); err != nil {
+			return 0, fmt.Errorf("file not found: %s", filePath)
+		}
 	}
 
-	// Build command arguments
-	args := []string{}
-	if sandbox == "__ask__" {
-		args = append(args, "/box:__ask__")
-	} else {
-		args = append(args, "/box:"+sandbox)
+	if err := validateLaunchOptions(opts); err != nil {
+		return 0, err
 	}
-	args = append(args, filePath)
 
-	cmd := exec.Command(sm.startExePath, args...)
+	cmd := Command{
+		Path: sm.GetStartExePath(),
+		Args: buildStartArgs(sandbox, filePath, opts),
+		Dir:  opts.WorkingDir,
+	}
+	if len(opts.Env) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), opts.Env)
+	}
 
-	// Start the process
-	err := cmd.Start()
+	return sm.runner.Start(cmd)
+}
+
+// ListProcessIDs returns the PIDs of the processes running in a sandbox
+func (sm *SandboxieManager) ListProcessIDs(sandbox string) ([]int, error) {
+	if !sm.IsAvailable() {
+		return nil, fmt.Errorf("Sandboxie 未安装")
+	}
+
+	output, err := sm.runner.Run(Command{
+		Path: sm.GetStartExePath(),
+		Args: []string{"/box:" + sandbox, "/listpids"},
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return cmd.Process.Pid, nil
+	return parseListPIDs(string(output)), nil
+}
+
+// parseListPIDs parses the output of Start.exe /listpids: the number of
+// processes followed by one PID per line
+func parseListPIDs(output string) []int {
+	var numbers []int
+	for _, field := range strings.Fields(output) {
+		if n, err := strconv.Atoi(field); err == nil && n >= 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) > 0 && numbers[0] == len(numbers)-1 {
+		numbers = numbers[1:]
+	}
+	return numbers
 }
 
 // TerminateAllPrograms terminates all programs in a sandbox
@@ -77,13 +198,11 @@ func (sm *SandboxieManager) TerminateAllPrograms(sandbox string) error {
 		return fmt.Errorf("Sandboxie 未安装")
 	}
 
-	cmd := exec.Command(
-		sm.startExePath,
-		"/box:"+sandbox,
-		"/terminate",
-	)
-
-	return cmd.Run()
+	_, err := sm.runner.Run(Command{
+		Path: sm.GetStartExePath(),
+		Args: []string{"/box:" + sandbox, "/terminate"},
+	})
+	return err
 }
 
 // DeleteSandboxContents deletes the contents of a sandbox
@@ -92,13 +211,11 @@ func (sm *SandboxieManager) DeleteSandboxContents(sandbox string) error {
 		return fmt.Errorf("Sandboxie 未安装")
 	}
 
-	cmd := exec.Command(
-		sm.startExePath,
-		"/box:"+sandbox,
-		"delete_sandbox_silent",
-	)
-
-	return cmd.Run()
+	_, err := sm.runner.Run(Command{
+		Path: sm.GetStartExePath(),
+		Args: []string{"/box:" + sandbox, "delete_sandbox_silent"},
+	})
+	return err
 }
 
 // OpenSandboxieManager opens the Sandboxie Manager (SandMan.exe)
@@ -108,7 +225,7 @@ func (sm *SandboxieManager) OpenSandboxieManager() error {
 	}
 
 	// Try to find SandMan.exe in the same directory as Start.exe
-	startDir := filepath.Dir(sm.startExePath)
+	startDir := filepath.Dir(sm.GetStartExePath())
 	sandManPath := filepath.Join(startDir, "SandMan.exe")
 
 	// Check if SandMan.exe exists
@@ -122,10 +239,8 @@ func (sm *SandboxieManager) OpenSandboxieManager() error {
 		}
 	}
 
-	cmd := exec.Command(sandManPath)
-
 	// Start the process
-	if err := cmd.Start(); err != nil {
+	if _, err := sm.runner.Start(Command{Path: sandManPath}); err != nil {
 		return fmt.Errorf("failed to start Sandboxie Manager: %v", err)
 	}
 
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Default FileRootPath used by Sandboxie when neither the box nor
+// [GlobalSettings] overrides it
+const defaultFileRootPath = `\??\%SystemDrive%\Sandbox\%USER%\%SANDBOX%`
+
+// IniEntry is a single Key=Value line. Sandboxie allows the same key
+// to appear several times in a section, so entries are kept in order.
+type IniEntry struct {
+	Key   string
+	Value string
+}
+
+// IniSection is a [Name] section of Sandboxie.ini
+type IniSection struct {
+	Name    string
+	Entries []IniEntry
+}
+
+// Get returns the first value for key (case-insensitive)
+func (s *IniSection) Get(key string) (string, bool) {
+	for _, e := range s.Entries {
+		if strings.EqualFold(e.Key, key) {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns every value for key (case-insensitive)
+func (s *IniSection) GetAll(key string) []string {
+	var values []string
+	for _, e := range s.Entries {
+		if strings.EqualFold(e.Key, key) {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// SandboxieIni is the parsed content of Sandboxie.ini
+type SandboxieIni struct {
+	GlobalSettings *IniSection
+	UserSettings   []*IniSection
+	Boxes          []*IniSection
+}
+
+// ParseSandboxieIni parses Sandboxie.ini content encoded as UTF-16LE
+// (the encoding Sandboxie writes) or UTF-8
+func ParseSandboxieIni(data []byte) (*SandboxieIni, error) {
+	text, err := decodeIniText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ini := &SandboxieIni{GlobalSettings: &IniSection{Name: "GlobalSettings"}}
+	var current *IniSection
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed section header: %s", lineNo, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = ini.addSection(name)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			// Sandboxie ignores lines without '=', so do we
+			continue
+		}
+		if current == nil {
+			// Settings before any section header are ignored by Sandboxie
+			continue
+		}
+		current.Entries = append(current.Entries, IniEntry{
+			Key:   strings.TrimSpace(key),
+			Value: strings.TrimSpace(value),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ini, nil
+}
+
+// addSection returns the section with the given name, creating it if needed.
+// Repeated headers are merged into the first occurrence.
+func (ini *SandboxieIni) addSection(name string) *IniSection {
+	switch {
+	case strings.EqualFold(name, "GlobalSettings"):
+		return ini.GlobalSettings
+	case isUserSettingsSection(name):
+		for _, s := range ini.UserSettings {
+			if strings.EqualFold(s.Name, name) {
+				return s
+			}
+		}
+		section := &IniSection{Name: name}
+		ini.UserSettings = append(ini.UserSettings, section)
+		return section
+	case isTemplateSection(name):
+		// Templates are not boxes; keep the entries out of Boxes
+		return &IniSection{Name: name}
+	default:
+		for _, s := range ini.Boxes {
+			if strings.EqualFold(s.Name, name) {
+				return s
+			}
+		}
+		section := &IniSection{Name: name}
+		ini.Boxes = append(ini.Boxes, section)
+		return section
+	}
+}
+
+// isUserSettingsSection reports whether name is a [UserSettings_XXXX] section
+func isUserSettingsSection(name string) bool {
+	return len(name) >= len("UserSettings_") && strings.EqualFold(name[:len("UserSettings_")], "UserSettings_")
+}
+
+// isTemplateSection reports whether name is a template section
+func isTemplateSection(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "templatesettings" || strings.HasPrefix(lower, "template_")
+}
+
+// Sandboxes returns the boxes defined in the ini with their effective settings
+func (ini *SandboxieIni) Sandboxes() []SandboxInfo {
+	sandboxes := make([]SandboxInfo, 0, len(ini.Boxes))
+	for _, box := range ini.Boxes {
+		info := SandboxInfo{
+			Name:         box.Name,
+			FileRootPath: defaultFileRootPath,
+		}
+
+		if v, ok := box.Get("Enabled"); ok {
+			info.Enabled = parseIniBool(v)
+		}
+
+		if v, ok := box.Get("FileRootPath"); ok && v != "" {
+			info.FileRootPath = v
+		} else if v, ok := ini.GlobalSettings.Get("FileRootPath"); ok && v != "" {
+			info.FileRootPath = v
+		}
+
+		if v, ok := box.Get("ConfigLevel"); ok {
+			if level, err := strconv.Atoi(v); err == nil {
+				info.ConfigLevel = level
+			}
+		}
+
+		sandboxes = append(sandboxes, info)
+	}
+	return sandboxes
+}
+
+// parseIniBool parses Sandboxie's y/n values. Enabled may carry extra
+// comma separated qualifiers (e.g. "y,Administrators").
+func parseIniBool(value string) bool {
+	first, _, _ := strings.Cut(value, ",")
+	switch strings.ToLower(strings.TrimSpace(first)) {
+	case "y", "yes", "true", "1":
+		return true
+	}
+	return false
+}
+
+// decodeIniText converts raw ini bytes into a Go string
+func decodeIniText(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true)
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), nil
+	case looksLikeUTF16LE(data):
+		return decodeUTF16(data, false)
+	}
+	return string(data), nil
+}
+
+// looksLikeUTF16LE detects BOM-less UTF-16LE by checking for zero high bytes
+func looksLikeUTF16LE(data []byte) bool {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return false
+	}
+	n := len(data)
+	if n > 256 {
+		n = 256
+	}
+	zeros := 0
+	for i := 1; i < n; i += 2 {
+		if data[i] == 0 {
+			zeros++
+		}
+	}
+	return zeros*2 >= n/2
+}
+
+// decodeUTF16 decodes UTF-16 bytes in the given byte order
+func decodeUTF16(data []byte, bigEndian bool) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("invalid UTF-16 data: odd length %d", len(data))
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// LoadSandboxieIni reads and parses a Sandboxie.ini file
+func LoadSandboxieIni(path string) (*SandboxieIni, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ini, err := ParseSandboxieIni(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return ini, nil
+}
+
+// sandboxieIniCandidates returns the locations Sandboxie reads its
+// configuration from, in priority order
+func sandboxieIniCandidates(startExePath string) []string {
+	var candidates []string
+
+	// Sandboxie prefers an ini next to its binaries (portable installs)
+	if startExePath != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(startExePath), "Sandboxie.ini"))
+	}
+
+	systemRoot := os.Getenv("SystemRoot")
+	if systemRoot == "" {
+		systemRoot = `C:\Windows`
+	}
+	candidates = append(candidates, filepath.Join(systemRoot, "Sandboxie.ini"))
+
+	return candidates
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+// iniFixtureBoxes is what every encoding of testdata/ini/*.ini parses to
+var iniFixtureBoxes = []SandboxInfo{
+	{Name: "DefaultBox", Enabled: true, FileRootPath: `D:\Sandbox\%USER%\%SANDBOX%`, ConfigLevel: 9},
+	{Name: "Browsers", Enabled: true, FileRootPath: `E:\浏览器沙盒\%SANDBOX%`},
+	{Name: "Disabled", FileRootPath: `D:\Sandbox\%USER%\%SANDBOX%`},
+	{Name: "NoEnabledKey", FileRootPath: `D:\Sandbox\%USER%\%SANDBOX%`},
+}
+
+func TestParseSandboxieIniFixtures(t *testing.T) {
+	for _, name := range []string{"utf16le-bom.ini", "utf16le.ini", "utf8.ini"} {
+		t.Run(name, func(t *testing.T) {
+			ini, err := LoadSandboxieIni("testdata/ini/" + name)
+			if err != nil {
+				t.Fatalf("LoadSandboxieIni: %v", err)
+			}
+
+			if got := ini.Sandboxes(); !slices.Equal(got, iniFixtureBoxes) {
+				t.Errorf("Sandboxes() =\n%+v\nwant\n%+v", got, iniFixtureBoxes)
+			}
+
+			// Repeated headers merge into the first one
+			if len(ini.UserSettings) != 1 {
+				t.Fatalf("%d UserSettings sections, want 1", len(ini.UserSettings))
+			}
+			if v, _ := ini.UserSettings[0].Get("SbieCtrl_BoxExpandedView"); v != "DefaultBox" {
+				t.Errorf("merged UserSettings lost SbieCtrl_BoxExpandedView")
+			}
+			defaultBox := ini.Boxes[0]
+			if got := defaultBox.GetAll("configlevel"); !slices.Equal(got, []string{"9", "10"}) {
+				t.Errorf("DefaultBox ConfigLevel values = %q", got)
+			}
+			if v, _ := defaultBox.Get("ClosedFilePath"); v != `C:\Secrets` {
+				t.Errorf("DefaultBox ClosedFilePath = %q", v)
+			}
+			if v, _ := ini.GlobalSettings.Get("Template"); v != "OfficeLicensing" {
+				t.Errorf("GlobalSettings Template = %q", v)
+			}
+		})
+	}
+}
+
+// utf16LE encodes s as UTF-16LE without a BOM
+func utf16LE(s string) []byte {
+	var data []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	return data
+}
+
+func TestParseSandboxieIni(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []SandboxInfo
+	}{
+		{
+			name: "default FileRootPath",
+			data: []byte("[GlobalSettings]\nFileRootPath=\n[Box]\nEnabled=yes\n"),
+			want: []SandboxInfo{{Name: "Box", Enabled: true, FileRootPath: defaultFileRootPath}},
+		},
+		{
+			name: "box overrides GlobalSettings",
+			data: []byte("[GlobalSettings]\nFileRootPath=D:\\G\n[Box]\nFileRootPath=D:\\B\nEnabled=y\n"),
+			want: []SandboxInfo{{Name: "Box", Enabled: true, FileRootPath: `D:\B`}},
+		},
+		{
+			name: "GlobalSettings after the box",
+			data: []byte("[Box]\nEnabled=y\n[GlobalSettings]\nFileRootPath=D:\\G\n"),
+			want: []SandboxInfo{{Name: "Box", Enabled: true, FileRootPath: `D:\G`}},
+		},
+		{
+			name: "Enabled with groups",
+			data: []byte("[A]\nEnabled=y,Administrators,Users\n[B]\nEnabled= Y , Group\n[C]\nEnabled=n,Users\n[D]\nEnabled=\n"),
+			want: []SandboxInfo{
+				{Name: "A", Enabled: true, FileRootPath: defaultFileRootPath},
+				{Name: "B", Enabled: true, FileRootPath: defaultFileRootPath},
+				{Name: "C", FileRootPath: defaultFileRootPath},
+				{Name: "D", FileRootPath: defaultFileRootPath},
+			},
+		},
+		{
+			name: "templates and user settings are not boxes",
+			data: []byte("[TemplateSettings]\n[Template_X]\nEnabled=y\n[UserSettings_1]\n[usersettings_2]\n[Box]\n"),
+			want: []SandboxInfo{{Name: "Box", FileRootPath: defaultFileRootPath}},
+		},
+		{
+			name: "comments, blank and bare lines",
+			data: []byte("; c\n\n[Box]\n# Enabled=n\nnot a setting\n  Enabled = y  \n"),
+			want: []SandboxInfo{{Name: "Box", Enabled: true, FileRootPath: defaultFileRootPath}},
+		},
+		{
+			name: "UTF-8 with BOM",
+			data: append([]byte{0xEF, 0xBB, 0xBF}, "[Box]\nFileRootPath=D:\\沙盒\n"...),
+			want: []SandboxInfo{{Name: "Box", FileRootPath: `D:\沙盒`}},
+		},
+		{
+			name: "UTF-16BE with BOM",
+			data: []byte{0xFE, 0xFF, 0, '[', 0, 'B', 0, ']', 0, '\n'},
+			want: []SandboxInfo{{Name: "B", FileRootPath: defaultFileRootPath}},
+		},
+		{
+			name: "short UTF-16LE without BOM",
+			data: utf16LE("[B]\n"),
+			want: []SandboxInfo{{Name: "B", FileRootPath: defaultFileRootPath}},
+		},
+		{
+			name: "empty",
+			data: nil,
+			want: []SandboxInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ini, err := ParseSandboxieIni(tt.data)
+			if err != nil {
+				t.Fatalf("ParseSandboxieIni: %v", err)
+			}
+			if got := ini.Sandboxes(); !slices.Equal(got, tt.want) {
+				t.Errorf("Sandboxes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSandboxieIniErrors(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("[Box\nEnabled=y\n"),
+		{0xFF, 0xFE, '[', 0, 'B'},
+	} {
+		if _, err := ParseSandboxieIni(data); err == nil {
+			t.Errorf("ParseSandboxieIni(%q) succeeded", data)
+		}
+	}
+	if _, err := LoadSandboxieIni("testdata/ini/missing.ini"); !os.IsNotExist(err) {
+		t.Errorf("LoadSandboxieIni of a missing file = %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	maxSearchDepth   = 8   // Folder levels below each root that are searched
+	maxSearchResults = 200 // Results returned to the frontend
+)
+
+// errSearchCancelled is returned when a newer query replaced the search
+var errSearchCancelled = errors.New("search cancelled")
+
+// SearchResult is a file matching a search query
+type SearchResult struct {
+	File  FileInfo `json:"file"`
+	Root  string   `json:"root"`  // Configured folder the file was found in
+	Score int      `json:"score"` // Higher is a better match
+}
+
+// Searcher runs recursive searches, cancelling the previous search
+// whenever a new one starts
+type Searcher struct {
+	mu          sync.Mutex
+	cancel      context.CancelFunc
+	fileManager *FileManager
+	index       *FileIndex
+}
+
+// NewSearcher creates a new searcher. Roots already in the index are
+// searched from memory; others are walked on disk.
+func NewSearcher(fileManager *FileManager, index *FileIndex) *Searcher {
+	return &Searcher{fileManager: fileManager, index: index}
+}
+
+// begin cancels the running search and returns a context for a new one
+func (s *Searcher) begin() (context.Context, context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	return ctx, cancel
+}
+
+// Search walks every root recursively and returns ranked matches
+func (s *Searcher) Search(query string, roots []string) ([]SearchResult, error) {
+	ctx, cancel := s.begin()
+	defer cancel()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []SearchResult{}, nil
+	}
+
+	results := []SearchResult{}
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		err := s.eachFile(ctx, root, func(file FileInfo) {
+			key := strings.ToLower(file.Path)
+			if seen[key] {
+				// Roots may overlap, report each file once
+				return
+			}
+			seen[key] = true
+
+			description := ""
+			if file.Shortcut != nil {
+				description = file.Shortcut.Description
+			}
+			if score := scoreSearchMatch(query, file.Name, description); score > 0 {
+				results = append(results, SearchResult{File: file, Root: root, Score: score})
+			}
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil, errSearchCancelled
+		}
+	}
+
+	sortSearchResults(results)
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+	return results, nil
+}
+
+// eachFile calls fn for every launchable file below root
+func (s *Searcher) eachFile(ctx context.Context, root string, fn func(FileInfo)) error {
+	if s.index != nil {
+		if files, ok := s.index.Files(root); ok {
+			for _, file := range files {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				fn(file)
+			}
+			return nil
+		}
+	}
+	return walkLaunchable(ctx, s.fileManager, root, maxSearchDepth, fn)
+}
+
+// sortSearchResults orders results by score, then by name
+func sortSearchResults(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].File.Name) < strings.ToLower(results[j].File.Name)
+	})
+}
+
+// walkLaunchable calls fn for every launchable file under root, descending
+// at most maxDepth folders. Unreadable and hidden folders are skipped.
+func walkLaunchable(ctx context.Context, fm *FileManager, root string, maxDepth int, fn func(FileInfo)) error {
+	root = filepath.Clean(root)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != root && (pathDepth(root, path) > maxDepth || fm.isHidden(d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fm.isHidden(d.Name()) {
+			return nil
+		}
+		if file, ok := fm.fileInfo(path); ok {
+			fn(file)
+		}
+		return nil
+	})
+}
+
+// pathDepth returns how many folders path is below root
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// searchTierSpan is the width of each score tier. Penalties are capped to
+// it, so a long name or a late match never falls into the tier below.
+const searchTierSpan = 100
+
+// scoreSearchMatch scores how well query matches a file name or shortcut
+// description. Zero means no match.
+func scoreSearchMatch(query, name, description string) int {
+	q := strings.ToLower(query)
+	n := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+
+	switch {
+	case n == q:
+		return 1000
+	case strings.HasPrefix(n, q):
+		return tierScore(800, len(n)-len(q))
+	}
+
+	if idx := indexWordStart(n, q); idx >= 0 {
+		return tierScore(600, idx)
+	}
+	if idx := strings.Index(n, q); idx >= 0 {
+		return tierScore(400, idx)
+	}
+	if score := fuzzyScore(q, n); score > 0 {
+		return 100 + score
+	}
+	if description != "" && strings.Contains(strings.ToLower(description), q) {
+		return 50
+	}
+	return 0
+}
+
+// tierScore lowers base by penalty without leaving its tier
+func tierScore(base, penalty int) int {
+	return base - min(penalty, searchTierSpan-1)
+}
+
+// indexWordStart finds q at the start of a word inside s
+func indexWordStart(s, q string) int {
+	for i := 1; i < len(s); i++ {
+		prev := rune(s[i-1])
+		if (prev == ' ' || prev == '-' || prev == '_' || prev == '.') && strings.HasPrefix(s[i:], q) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fuzzyScore matches q as a subsequence of s, rewarding consecutive and
+// word-initial characters. Returns 0 when q isn't a subsequence of s.
+func fuzzyScore(q, s string) int {
+	qr := []rune(q)
+	sr := []rune(s)
+	score := 0
+	qi := 0
+	lastMatch := -2
+	for si := 0; si < len(sr) && qi < len(qr); si++ {
+		if sr[si] != qr[qi] {
+			continue
+		}
+		score += 2
+		if lastMatch == si-1 {
+			score += 3
+		}
+		if si == 0 || !unicode.IsLetter(sr[si-1]) && !unicode.IsDigit(sr[si-1]) {
+			score += 4
+		}
+		lastMatch = si
+		qi++
+	}
+	if qi < len(qr) {
+		return 0
+	}
+	// Stays below the substring tier
+	if score > 2*searchTierSpan-1 {
+		score = 2*searchTierSpan - 1
+	}
+	return score
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreSearchMatchTiers(t *testing.T) {
+	long := strings.Repeat("x", 2*searchTierSpan)
+
+	// Each tier, from best to worst, with its best and worst names
+	tiers := []struct {
+		name  string
+		names []string
+	}{
+		{"exact", []string{"Note.exe", "NOTE.lnk"}},
+		{"prefix", []string{"Notes.exe", "Notepad " + long + ".exe"}},
+		{"word start", []string{"My note.exe", long + " note.exe", long + "-" + long + "_notes.lnk"}},
+		{"substring", []string{"Keynote.exe", long + "note.exe"}},
+		{"fuzzy", []string{"n-o-t-e.exe", "n" + long + "o" + long + "t" + long + "e.exe"}},
+	}
+
+	prevName, prevWorst := "", 0
+	for i, tier := range tiers {
+		best, worst := 0, 0
+		for j, name := range tier.names {
+			score := scoreSearchMatch("note", name, "")
+			if score <= 0 {
+				t.Errorf("%s: %q scored %d", tier.name, name, score)
+			}
+			if j == 0 || score > best {
+				best = score
+			}
+			if j == 0 || score < worst {
+				worst = score
+			}
+		}
+		if i > 0 && best >= prevWorst {
+			t.Errorf("best %s match scores %d, not below the worst %s match (%d)", tier.name, best, prevName, prevWorst)
+		}
+		prevName, prevWorst = tier.name, worst
+	}
+
+	if got := scoreSearchMatch("note", long+".exe", "Takes notes"); got <= 0 || got >= prevWorst {
+		t.Errorf("description match scores %d, want between 0 and %d", got, prevWorst)
+	}
+	if got := scoreSearchMatch("note", "Paint.exe", "Draws"); got != 0 {
+		t.Errorf("no match scores %d", got)
+	}
+}
+
+func TestScoreSearchMatchWithinTier(t *testing.T) {
+	tests := []struct {
+		better, worse string
+	}{
+		{"Notes.exe", "Notepad.exe"},
+		{"My note.exe", "My old note.exe"},
+		{"Keynote.exe", "Big keynote.exe"},
+	}
+	for _, tt := range tests {
+		better, worse := scoreSearchMatch("note", tt.better, ""), scoreSearchMatch("note", tt.worse, "")
+		if better <= worse {
+			t.Errorf("%q scores %d, not above %q (%d)", tt.better, better, tt.worse, worse)
+		}
+	}
+}
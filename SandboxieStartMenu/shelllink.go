@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ShellLink holds the parts of a .lnk shortcut (MS-SHLLINK) we care about
+type ShellLink struct {
+	Target       string `json:"target"`       // Resolved target path, empty if unknown
+	Arguments    string `json:"arguments"`    // Raw command-line arguments
+	WorkingDir   string `json:"workingDir"`   // Working directory
+	IconLocation string `json:"iconLocation"` // Icon file, empty to use the target's icon
+	IconIndex    int    `json:"iconIndex"`    // Icon index inside IconLocation
+	Description  string `json:"description"`  // Comment shown as the shortcut tooltip
+	RelativePath string `json:"relativePath"` // Target path relative to the .lnk file
+	ShowCommand  int    `json:"showCommand"`  // SW_SHOWNORMAL, SW_SHOWMAXIMIZED or SW_SHOWMINNOACTIVE
+	Advertised   bool   `json:"advertised"`   // Windows Installer shortcut without a plain target
+	Broken       bool   `json:"broken"`       // Target is known but doesn't exist
+}
+
+// Shell link header layout and flags, see [MS-SHLLINK] 2.1
+const (
+	shellLinkHeaderSize = 0x4C
+
+	linkFlagHasLinkTargetIDList = 0x00000001
+	linkFlagHasLinkInfo         = 0x00000002
+	linkFlagHasName             = 0x00000004
+	linkFlagHasRelativePath     = 0x00000008
+	linkFlagHasWorkingDir       = 0x00000010
+	linkFlagHasArguments        = 0x00000020
+	linkFlagHasIconLocation     = 0x00000040
+	linkFlagIsUnicode           = 0x00000080
+	linkFlagForceNoLinkInfo     = 0x00000100
+	linkFlagHasExpString        = 0x00000200
+	linkFlagHasDarwinID         = 0x00001000
+	linkFlagHasExpIcon          = 0x00004000
+
+	linkInfoFlagVolumeIDAndLocalBasePath = 0x1
+	linkInfoFlagCommonNetworkRelative    = 0x2
+
+	environmentVariableDataBlock = 0xA0000001
+	darwinDataBlock              = 0xA0000006
+	iconEnvironmentDataBlock     = 0xA0000007
+)
+
+// shellLinkCLSID is 00021401-0000-0000-C000-000000000046 in on-disk byte order
+var shellLinkCLSID = []byte{
+	0x01, 0x14, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46,
+}
+
+// ParseShellLink parses the binary content of a .lnk file
+func ParseShellLink(data []byte) (*ShellLink, error) {
+	if len(data) < shellLinkHeaderSize {
+		return nil, fmt.Errorf("shell link too short: %d bytes", len(data))
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != shellLinkHeaderSize || !bytes.Equal(data[4:20], shellLinkCLSID) {
+		return nil, fmt.Errorf("not a shell link")
+	}
+
+	flags := binary.LittleEndian.Uint32(data[0x14:0x18])
+	link := &ShellLink{
+		IconIndex:   int(int32(binary.LittleEndian.Uint32(data[0x38:0x3C]))),
+		ShowCommand: int(binary.LittleEndian.Uint32(data[0x3C:0x40])),
+	}
+
+	pos := shellLinkHeaderSize
+
+	// LinkTargetIDList: we don't interpret shell item IDs, just skip them
+	if flags&linkFlagHasLinkTargetIDList != 0 {
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("truncated LinkTargetIDList")
+		}
+		pos += 2 + int(binary.LittleEndian.Uint16(data[pos:]))
+		if pos > len(data) {
+			return nil, fmt.Errorf("truncated LinkTargetIDList")
+		}
+	}
+
+	if flags&linkFlagHasLinkInfo != 0 {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("truncated LinkInfo")
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos:]))
+		if size < 4 || pos+size > len(data) {
+			return nil, fmt.Errorf("invalid LinkInfo size: %d", size)
+		}
+		if flags&linkFlagForceNoLinkInfo == 0 {
+			target, err := parseLinkInfo(data[pos : pos+size])
+			if err != nil {
+				return nil, err
+			}
+			link.Target = target
+		}
+		pos += size
+	}
+
+	// StringData fields appear in this fixed order when present
+	unicode := flags&linkFlagIsUnicode != 0
+	stringFields := []struct {
+		flag uint32
+		dest *string
+	}{
+		{linkFlagHasName, &link.Description},
+		{linkFlagHasRelativePath, &link.RelativePath},
+		{linkFlagHasWorkingDir, &link.WorkingDir},
+		{linkFlagHasArguments, &link.Arguments},
+		{linkFlagHasIconLocation, &link.IconLocation},
+	}
+	for _, field := range stringFields {
+		if flags&field.flag == 0 {
+			continue
+		}
+		s, n, err := readStringData(data[pos:], unicode)
+		if err != nil {
+			return nil, err
+		}
+		*field.dest = s
+		pos += n
+	}
+
+	// ExtraData blocks carry environment-variable based paths
+	var envTarget, envIcon string
+	for pos+4 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos:]))
+		if size < 8 || pos+size > len(data) {
+			// TerminalBlock (size < 4) or truncated data
+			break
+		}
+		block := data[pos : pos+size]
+		switch binary.LittleEndian.Uint32(block[4:8]) {
+		case environmentVariableDataBlock:
+			envTarget = readEnvDataBlock(block)
+		case iconEnvironmentDataBlock:
+			envIcon = readEnvDataBlock(block)
+		case darwinDataBlock:
+			link.Advertised = true
+		}
+		pos += size
+	}
+
+	if flags&linkFlagHasDarwinID != 0 {
+		link.Advertised = true
+	}
+	if flags&linkFlagHasExpString != 0 && envTarget != "" {
+		link.Target = expandWindowsEnv(envTarget)
+	}
+	if flags&linkFlagHasExpIcon != 0 && envIcon != "" {
+		link.IconLocation = envIcon
+	}
+	link.IconLocation = expandWindowsEnv(link.IconLocation)
+	link.WorkingDir = expandWindowsEnv(link.WorkingDir)
+
+	return link, nil
+}
+
+// parseLinkInfo extracts the target path from a LinkInfo structure
+func parseLinkInfo(info []byte) (string, error) {
+	if len(info) < 0x1C {
+		return "", fmt.Errorf("LinkInfo too short")
+	}
+	headerSize := binary.LittleEndian.Uint32(info[4:8])
+	flags := binary.LittleEndian.Uint32(info[8:12])
+	localBasePathOffset := int(binary.LittleEndian.Uint32(info[0x10:0x14]))
+	networkOffset := int(binary.LittleEndian.Uint32(info[0x14:0x18]))
+	suffixOffset := int(binary.LittleEndian.Uint32(info[0x18:0x1C]))
+
+	// Headers of 0x24 bytes or more carry Unicode versions of the paths
+	var localBasePathOffsetUnicode, suffixOffsetUnicode int
+	if headerSize >= 0x24 && len(info) >= 0x24 {
+		localBasePathOffsetUnicode = int(binary.LittleEndian.Uint32(info[0x1C:0x20]))
+		suffixOffsetUnicode = int(binary.LittleEndian.Uint32(info[0x20:0x24]))
+	}
+
+	var suffix string
+	if suffixOffsetUnicode > 0 {
+		suffix = readUTF16Z(info, suffixOffsetUnicode)
+	} else if suffixOffset > 0 {
+		suffix = readANSIZ(info, suffixOffset)
+	}
+
+	if flags&linkInfoFlagVolumeIDAndLocalBasePath != 0 {
+		var base string
+		if localBasePathOffsetUnicode > 0 {
+			base = readUTF16Z(info, localBasePathOffsetUnicode)
+		} else {
+			base = readANSIZ(info, localBasePathOffset)
+		}
+		return joinLinkPath(base, suffix), nil
+	}
+
+	if flags&linkInfoFlagCommonNetworkRelative != 0 && networkOffset > 0 && networkOffset+0x14 <= len(info) {
+		net := info[networkOffset:]
+		netNameOffset := int(binary.LittleEndian.Uint32(net[8:12]))
+		var netName string
+		if netNameOffset > 0x14 && len(net) >= 0x1C {
+			netName = readUTF16Z(net, int(binary.LittleEndian.Uint32(net[0x14:0x18])))
+		} else {
+			netName = readANSIZ(net, netNameOffset)
+		}
+		return joinLinkPath(netName, suffix), nil
+	}
+
+	return "", nil
+}
+
+// joinLinkPath joins a base path and suffix with a single backslash
+func joinLinkPath(base, suffix string) string {
+	if suffix == "" {
+		return base
+	}
+	if base == "" || strings.HasSuffix(base, `\`) {
+		return base + suffix
+	}
+	return base + `\` + suffix
+}
+
+// readStringData reads a StringData entry (count-prefixed, not terminated)
+// and returns the string and the number of bytes consumed
+func readStringData(data []byte, unicode bool) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, fmt.Errorf("truncated StringData")
+	}
+	count := int(binary.LittleEndian.Uint16(data))
+	if unicode {
+		n := 2 + count*2
+		if n > len(data) {
+			return "", 0, fmt.Errorf("truncated StringData")
+		}
+		return decodeUTF16LE(data[2:n]), n, nil
+	}
+	n := 2 + count
+	if n > len(data) {
+		return "", 0, fmt.Errorf("truncated StringData")
+	}
+	return decodeANSI(data[2:n]), n, nil
+}
+
+// readEnvDataBlock reads the target from an EnvironmentVariableDataBlock
+// or IconEnvironmentDataBlock, preferring the Unicode copy
+func readEnvDataBlock(block []byte) string {
+	const ansiOffset, unicodeOffset = 8, 8 + 260
+	if len(block) >= unicodeOffset+520 {
+		if s := readUTF16Z(block[:unicodeOffset+520], unicodeOffset); s != "" {
+			return s
+		}
+	}
+	if len(block) >= ansiOffset+260 {
+		return readANSIZ(block[:ansiOffset+260], ansiOffset)
+	}
+	return ""
+}
+
+// readUTF16Z reads a NUL-terminated UTF-16LE string at offset
+func readUTF16Z(data []byte, offset int) string {
+	if offset < 0 || offset >= len(data) {
+		return ""
+	}
+	end := offset
+	for end+1 < len(data) && (data[end] != 0 || data[end+1] != 0) {
+		end += 2
+	}
+	return decodeUTF16LE(data[offset:end])
+}
+
+// readANSIZ reads a NUL-terminated ANSI string at offset
+func readANSIZ(data []byte, offset int) string {
+	if offset < 0 || offset >= len(data) {
+		return ""
+	}
+	end := bytes.IndexByte(data[offset:], 0)
+	if end < 0 {
+		return decodeANSI(data[offset:])
+	}
+	return decodeANSI(data[offset : offset+end])
+}
+
+// decodeUTF16LE decodes little-endian UTF-16 without a BOM
+func decodeUTF16LE(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeANSI decodes code-page strings. The real code page isn't known,
+// so UTF-8 is used when valid and Latin-1 otherwise.
+func decodeANSI(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// expandWindowsEnv expands %VAR% references using the process environment.
+// Unknown variables are left untouched, as Windows does.
+func expandWindowsEnv(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "%")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+1:], "%")
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		name := s[start+1 : end]
+		if value, ok := os.LookupEnv(name); ok && name != "" {
+			b.WriteString(s[:start])
+			b.WriteString(value)
+			s = s[end+1:]
+		} else {
+			// Keep the first '%' and continue from the second one
+			b.WriteString(s[:end])
+			s = s[end:]
+		}
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// ReadShellLink reads a .lnk file and resolves its target. Relative
+// targets are resolved against the shortcut's folder, and Broken is set
+// when a known target no longer exists.
+func ReadShellLink(lnkPath string) (*ShellLink, error) {
+	data, err := os.ReadFile(lnkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := ParseShellLink(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", lnkPath, err)
+	}
+
+	if link.Target == "" && link.RelativePath != "" {
+		link.Target = filepath.Clean(filepath.Join(filepath.Dir(lnkPath), link.RelativePath))
+	}
+
+	if link.Target != "" {
+		if _, err := os.Stat(link.Target); err != nil {
+			link.Broken = true
+		}
+	}
+
+	return link, nil
+}
+
+// splitCommandLine splits a Windows command line into arguments using the
+// same rules as CommandLineToArgvW
+func splitCommandLine(cmdLine string) []string {
+	var args []string
+	var arg strings.Builder
+	inQuotes := false
+	hasArg := false
+	backslashes := 0
+
+	runes := []rune(cmdLine)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			backslashes++
+			continue
+		case r == '"':
+			arg.WriteString(strings.Repeat(`\`, backslashes/2))
+			switch {
+			case backslashes%2 == 1:
+				// \" is a literal quote
+				arg.WriteRune('"')
+			case inQuotes && i+1 < len(runes) && runes[i+1] == '"':
+				// "" inside quotes is a literal quote
+				arg.WriteRune('"')
+				i++
+			default:
+				inQuotes = !inQuotes
+			}
+			backslashes = 0
+			hasArg = true
+			continue
+		}
+
+		if backslashes > 0 {
+			arg.WriteString(strings.Repeat(`\`, backslashes))
+			backslashes = 0
+			hasArg = true
+		}
+
+		if (r == ' ' || r == '\t') && !inQuotes {
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+			continue
+		}
+
+		arg.WriteRune(r)
+		hasArg = true
+	}
+
+	if backslashes > 0 {
+		arg.WriteString(strings.Repeat(`\`, backslashes))
+		hasArg = true
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseShellLink(t *testing.T) {
+	t.Setenv("SystemDrive", "C:")
+	t.Setenv("SystemRoot", `C:\Windows`)
+	t.Setenv("ProgramFiles", `C:\Program Files`)
+
+	tests := []struct {
+		file string
+		want ShellLink
+	}{
+		{
+			file: "local.lnk",
+			want: ShellLink{
+				Target:       `C:\Program Files\App\app.exe`,
+				Arguments:    `--profile "C:\My Data" -x`,
+				WorkingDir:   `C:\App`,
+				IconLocation: `C:\Windows\system32\shell32.dll`,
+				IconIndex:    12,
+				ShowCommand:  3,
+			},
+		},
+		{
+			file: "local-unicode.lnk",
+			want: ShellLink{Target: `C:\工具\编辑器.exe`, Description: "文本编辑器", ShowCommand: 1},
+		},
+		{
+			file: "network.lnk",
+			want: ShellLink{Target: `\\server\share\tools\app.exe`, Arguments: "/s", ShowCommand: 1},
+		},
+		{
+			file: "env.lnk",
+			want: ShellLink{Target: `C:\Program Files\App\app.exe`, ShowCommand: 1},
+		},
+		{
+			file: "advertised.lnk",
+			want: ShellLink{Description: "Microsoft Word", Advertised: true, ShowCommand: 1},
+		},
+		{
+			file: "relative.lnk",
+			want: ShellLink{RelativePath: `..\bin\tool.exe`, ShowCommand: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			link, err := ParseShellLink(readFixture(t, "testdata/lnk/"+tt.file))
+			if err != nil {
+				t.Fatalf("ParseShellLink: %v", err)
+			}
+			if *link != tt.want {
+				t.Errorf("ParseShellLink =\n%+v\nwant\n%+v", *link, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadShellLinkRelative(t *testing.T) {
+	link, err := ReadShellLink("testdata/lnk/relative.lnk")
+	if err != nil {
+		t.Fatalf("ReadShellLink: %v", err)
+	}
+	// Resolved against the shortcut's folder; the target isn't there
+	if !strings.HasPrefix(link.Target, filepath.Join("testdata", "lnk")) || !strings.HasSuffix(link.Target, "tool.exe") {
+		t.Errorf("Target = %q", link.Target)
+	}
+	if !link.Broken {
+		t.Error("missing target not reported as broken")
+	}
+}
+
+func TestParseShellLinkTruncated(t *testing.T) {
+	for _, file := range []string{"local.lnk", "local-unicode.lnk", "network.lnk", "relative.lnk"} {
+		data := readFixture(t, "testdata/lnk/"+file)
+		// Everything before the ExtraData TerminalBlock is required
+		for n := 0; n < len(data)-4; n++ {
+			if _, err := ParseShellLink(data[:n]); err == nil {
+				t.Errorf("%s truncated to %d bytes parsed", file, n)
+			}
+		}
+	}
+	// Truncated ExtraData blocks are skipped, never read past the end
+	data := readFixture(t, "testdata/lnk/env.lnk")
+	for n := shellLinkHeaderSize; n < len(data); n++ {
+		ParseShellLink(data[:n])
+	}
+
+	notLink := readFixture(t, "testdata/lnk/local.lnk")
+	notLink[4] ^= 0xFF
+	if _, err := ParseShellLink(notLink); err == nil {
+		t.Error("shell link with a wrong CLSID parsed")
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	// Cases from the CommandLineToArgvW documentation and the 2008 C
+	// runtime rules it follows for quotes inside quotes
+	tests := []struct {
+		cmdLine string
+		want    []string
+	}{
+		{``, nil},
+		{`   `, nil},
+		{`a b  c`, []string{"a", "b", "c"}},
+		{"a\tb", []string{"a", "b"}},
+		{`"abc" d e`, []string{"abc", "d", "e"}},
+		{`a\\b d"e f"g h`, []string{`a\\b`, "de fg", "h"}},
+		{`a\\\"b c d`, []string{`a\"b`, "c", "d"}},
+		{`a\\\\"b c" d e`, []string{`a\\b c`, "d", "e"}},
+		{`a"b"" c d`, []string{`ab" c d`}},
+		{`"C:\Program Files\App\" --flag`, []string{`C:\Program Files\App" --flag`}},
+		{`"C:\Program Files\App\\" --flag`, []string{`C:\Program Files\App\`, "--flag"}},
+		{`"" x`, []string{"", "x"}},
+		{`""""`, []string{`"`}},
+		{`\\server\share\a b`, []string{`\\server\share\a`, "b"}},
+		{`trailing\\`, []string{`trailing\\`}},
+		{`"unterminated quote`, []string{"unterminated quote"}},
+		{`--profile "C:\My Data" -x`, []string{"--profile", `C:\My Data`, "-x"}},
+		{`中文 "带 空格"`, []string{"中文", "带 空格"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitCommandLine(tt.cmdLine); !slices.Equal(got, tt.want) {
+			t.Errorf("splitCommandLine(%s) = %q, want %q", tt.cmdLine, got, tt.want)
+		}
+	}
+}
@@ -4,9 +4,13 @@ package main
 type FileInfo struct {
 	Name     string `json:"name"`
 	Path     string `json:"path"`
-	Type     string `json:"type"` // "exe", "bat", "cmd", "lnk", "folder"
+	Type     string `json:"type"` // Extension without the dot ("exe", "lnk", "pdf", ...), "file" or "folder"
 	Icon     string `json:"icon,omitempty"` // Base64 encoded icon or empty
 	IsDir    bool   `json:"isDir"` // true for folders, false for files
+	Sandbox  string `json:"sandbox,omitempty"` // Bound sandbox, empty if the selected sandbox is used
+	Shortcut *ShellLink `json:"shortcut,omitempty"` // Parsed .lnk details, nil for other types
+	URL      string `json:"url,omitempty"` // Target of a .url Internet Shortcut
+	Favorite bool   `json:"favorite,omitempty"` // Pinned to Favorites
 }
 
 // AppState represents the current application state
@@ -16,6 +20,20 @@ type AppState struct {
 	Files           []FileInfo `json:"files"`
 	SelectedSandbox string   `json:"selectedSandbox"`
 	AvailableSandboxes []string `json:"availableSandboxes"`
+	Sandboxes       []SandboxInfo `json:"sandboxes"` // Boxes discovered from Sandboxie.ini
+	VirtualFolders  []VirtualFolder `json:"virtualFolders"` // Recent, Most Used, ...
+	Sandboxie       SandboxieStatus `json:"sandboxie"` // Where Start.exe was found
+	ConfigWarnings  []string `json:"configWarnings,omitempty"` // Config recovery or migration problems
+	ActiveWorkspace string   `json:"activeWorkspace"`
+	Workspaces      []string `json:"workspaces"`
+}
+
+// SandboxInfo describes a box defined in Sandboxie.ini
+type SandboxInfo struct {
+	Name         string `json:"name"`
+	Enabled      bool   `json:"enabled"`
+	FileRootPath string `json:"fileRootPath"`
+	ConfigLevel  int    `json:"configLevel"`
 }
 
 // LaunchRequest represents a request to launch a program
@@ -28,5 +46,4 @@ type LaunchRequest struct {
 type LaunchResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
-	PID     int    `json:"pid"`
 }
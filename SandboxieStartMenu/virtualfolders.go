@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Virtual folders are selected like real folders but list programs from
+// elsewhere, e.g. the launch history
+const (
+	virtualFolderPrefix = "virtual:"
+	VirtualRecent       = virtualFolderPrefix + "recent"
+	VirtualMostUsed     = virtualFolderPrefix + "frequent"
+
+	virtualFolderSize = 30 // Programs listed in history-based folders
+)
+
+// VirtualFolder is a synthetic folder shown alongside FolderPaths
+type VirtualFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+// isVirtualFolder reports whether path names a virtual folder
+func isVirtualFolder(path string) bool {
+	return strings.HasPrefix(path, virtualFolderPrefix)
+}
+
+// getVirtualFolders returns the virtual folders shown in the sidebar
+func getVirtualFolders() []VirtualFolder {
+	return []VirtualFolder{
+		{ID: VirtualStartMenu, Name: "开始菜单", Icon: "📋"},
+		{ID: VirtualDesktop, Name: "桌面", Icon: "🖥️"},
+		{ID: VirtualTaskbar, Name: "任务栏", Icon: "📌"},
+		{ID: VirtualFavorites, Name: "收藏夹", Icon: "⭐"},
+		{ID: VirtualRecent, Name: "最近使用", Icon: "🕘"},
+		{ID: VirtualMostUsed, Name: "最常使用", Icon: "🔥"},
+	}
+}
+
+// filesFromPaths builds FileInfo entries for programs that still exist
+func filesFromPaths(fm *FileManager, paths []string) []FileInfo {
+	files := []FileInfo{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if file, ok := fm.fileInfo(path); ok {
+			files = append(files, file)
+		}
+	}
+	return files
+}
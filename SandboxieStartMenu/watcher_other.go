@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// newChangeWatcher fails; callers fall back to not watching folders
+func newChangeWatcher() (changeWatcher, error) {
+	return nil, errors.New("folder change notifications are only supported on Windows")
+}
+
+// newFolderWatcher fails like newChangeWatcher
+func newFolderWatcher() (changeWatcher, error) {
+	return newChangeWatcher()
+}
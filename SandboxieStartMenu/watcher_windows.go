@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// changeNotifyFilter covers shortcuts and programs being added, removed,
+// renamed or rewritten
+const changeNotifyFilter = windows.FILE_NOTIFY_CHANGE_FILE_NAME |
+	windows.FILE_NOTIFY_CHANGE_DIR_NAME |
+	windows.FILE_NOTIFY_CHANGE_LAST_WRITE
+
+// notifyWatcher watches folders with FindFirstChangeNotification. It only
+// reports which root changed, not what changed in it.
+type notifyWatcher struct {
+	mu      sync.Mutex
+	subtree bool // Also watch subfolders
+	events  chan string
+	watches map[string]windows.Handle // root -> stop event
+	wg      sync.WaitGroup
+}
+
+// newChangeWatcher creates the platform watcher for folder trees
+func newChangeWatcher() (changeWatcher, error) {
+	return newNotifyWatcher(true), nil
+}
+
+// newFolderWatcher creates a watcher that ignores changes in subfolders
+func newFolderWatcher() (changeWatcher, error) {
+	return newNotifyWatcher(false), nil
+}
+
+// newNotifyWatcher creates an empty notifyWatcher
+func newNotifyWatcher(subtree bool) *notifyWatcher {
+	return &notifyWatcher{
+		subtree: subtree,
+		events:  make(chan string, 16),
+		watches: make(map[string]windows.Handle),
+	}
+}
+
+// Watch starts watching root, and its subfolders for a tree watcher
+func (w *notifyWatcher) Watch(root string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.watches[root]; ok {
+		return nil
+	}
+
+	notify, err := windows.FindFirstChangeNotification(root, w.subtree, changeNotifyFilter)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %v", root, err)
+	}
+	stop, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		windows.FindCloseChangeNotification(notify)
+		return fmt.Errorf("failed to watch %s: %v", root, err)
+	}
+
+	w.watches[root] = stop
+	w.wg.Add(1)
+	go w.loop(root, notify, stop)
+	return nil
+}
+
+// loop waits for change notifications on one root until stopped
+func (w *notifyWatcher) loop(root string, notify, stop windows.Handle) {
+	defer w.wg.Done()
+	defer windows.CloseHandle(stop)
+	defer windows.FindCloseChangeNotification(notify)
+
+	handles := []windows.Handle{notify, stop}
+	for {
+		event, err := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		if err != nil || event != windows.WAIT_OBJECT_0 {
+			// Stopped, or the folder went away
+			return
+		}
+
+		select {
+		case w.events <- root:
+		default:
+			// A notification for this burst is already queued
+		}
+
+		if err := windows.FindNextChangeNotification(notify); err != nil {
+			return
+		}
+	}
+}
+
+// Unwatch stops watching root
+func (w *notifyWatcher) Unwatch(root string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if stop, ok := w.watches[root]; ok {
+		windows.SetEvent(stop)
+		delete(w.watches, root)
+	}
+}
+
+// Events returns the channel of changed roots
+func (w *notifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+// Close stops all watches
+func (w *notifyWatcher) Close() error {
+	w.mu.Lock()
+	for root, stop := range w.watches {
+		windows.SetEvent(stop)
+		delete(w.watches, root)
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return nil
+}
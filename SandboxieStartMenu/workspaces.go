@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const defaultWorkspaceName = "默认" // Created for configs without workspaces
+
+// Workspace is a named set of folders, box selection and bindings. The
+// active workspace's settings live in the top-level Config fields; its
+// entry in Config.Workspaces is refreshed whenever the config is saved.
+type Workspace struct {
+	Name            string           `json:"name"`
+	FolderPaths     []string         `json:"folderPaths"`
+	CurrentFolder   string           `json:"currentFolder"`
+	SelectedSandbox string           `json:"selectedSandbox"`
+	SandboxBindings []SandboxBinding `json:"sandboxBindings"`
+}
+
+// clone returns a copy that shares no slices with w
+func (w Workspace) clone() Workspace {
+	copied := w
+	copied.FolderPaths = slices.Clone(w.FolderPaths)
+	copied.SandboxBindings = slices.Clone(w.SandboxBindings)
+	return copied
+}
+
+// findWorkspace returns the index of the named workspace, or -1
+func findWorkspace(workspaces []Workspace, name string) int {
+	for i, w := range workspaces {
+		if strings.EqualFold(w.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentWorkspace captures the active settings of config as a workspace
+func (c *Config) currentWorkspace() Workspace {
+	return Workspace{
+		Name:            c.ActiveWorkspace,
+		FolderPaths:     slices.Clone(c.FolderPaths),
+		CurrentFolder:   c.CurrentFolder,
+		SelectedSandbox: c.SelectedSandbox,
+		SandboxBindings: slices.Clone(c.SandboxBindings),
+	}
+}
+
+// syncActiveWorkspace stores the active settings in the workspace list
+func (c *Config) syncActiveWorkspace() {
+	if i := findWorkspace(c.Workspaces, c.ActiveWorkspace); i >= 0 {
+		c.Workspaces[i] = c.currentWorkspace()
+	}
+}
+
+// loadWorkspace makes w the active settings of config
+func (c *Config) loadWorkspace(w Workspace) {
+	w = w.clone()
+	c.ActiveWorkspace = w.Name
+	c.FolderPaths = w.FolderPaths
+	c.CurrentFolder = w.CurrentFolder
+	c.SelectedSandbox = w.SelectedSandbox
+	c.SandboxBindings = w.SandboxBindings
+	if c.FolderPaths == nil {
+		c.FolderPaths = []string{}
+	}
+	if c.SelectedSandbox == "" {
+		c.SelectedSandbox = "DefaultBox"
+	}
+}
+
+// ensureWorkspaces makes sure the active workspace exists in the list.
+// Without an active workspace the first one is loaded, since the next
+// save would otherwise overwrite it with the top-level settings.
+func (cm *ConfigManager) ensureWorkspaces(config *Config) {
+	if config.ActiveWorkspace == "" {
+		if len(config.Workspaces) > 0 {
+			config.loadWorkspace(config.Workspaces[0])
+			cm.ensureDefaultFolders(config)
+		} else {
+			config.ActiveWorkspace = defaultWorkspaceName
+		}
+	}
+	if findWorkspace(config.Workspaces, config.ActiveWorkspace) < 0 {
+		config.Workspaces = append(config.Workspaces, config.currentWorkspace())
+	}
+}
+
+// validateWorkspaceName checks a new workspace name against the list
+func validateWorkspaceName(workspaces []Workspace, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("workspace name is empty")
+	}
+	if findWorkspace(workspaces, name) >= 0 {
+		return fmt.Errorf("workspace already exists: %s", name)
+	}
+	return nil
+}
+
+// GetWorkspaces returns the workspace names in order
+func (cm *ConfigManager) GetWorkspaces() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	names := []string{}
+	for _, w := range cm.config.Workspaces {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+// ActiveWorkspace returns the name of the active workspace
+func (cm *ConfigManager) ActiveWorkspace() string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.config.ActiveWorkspace
+}
+
+// CreateWorkspace adds a workspace. With copyCurrent it starts as a copy
+// of the active workspace, otherwise with the default folders and box.
+func (cm *ConfigManager) CreateWorkspace(name string, copyCurrent bool) error {
+	name = strings.TrimSpace(name)
+	return cm.Update(func(config *Config) error {
+		if err := validateWorkspaceName(config.Workspaces, name); err != nil {
+			return err
+		}
+
+		var workspace Workspace
+		if copyCurrent {
+			workspace = config.currentWorkspace()
+		} else {
+			empty := &Config{SelectedSandbox: "DefaultBox", FolderPaths: []string{}}
+			cm.ensureDefaultFolders(empty)
+			workspace = empty.currentWorkspace()
+		}
+		workspace.Name = name
+		config.Workspaces = append(config.Workspaces, workspace)
+		return nil
+	})
+}
+
+// SwitchWorkspace makes the named workspace active
+func (cm *ConfigManager) SwitchWorkspace(name string) error {
+	return cm.Update(func(config *Config) error {
+		i := findWorkspace(config.Workspaces, name)
+		if i < 0 {
+			return fmt.Errorf("workspace not found: %s", name)
+		}
+
+		config.syncActiveWorkspace()
+		config.loadWorkspace(config.Workspaces[i])
+		cm.ensureDefaultFolders(config)
+		return nil
+	})
+}
+
+// RenameWorkspace renames a workspace
+func (cm *ConfigManager) RenameWorkspace(oldName string, newName string) error {
+	newName = strings.TrimSpace(newName)
+	return cm.Update(func(config *Config) error {
+		i := findWorkspace(config.Workspaces, oldName)
+		if i < 0 {
+			return fmt.Errorf("workspace not found: %s", oldName)
+		}
+		if !strings.EqualFold(oldName, newName) {
+			if err := validateWorkspaceName(config.Workspaces, newName); err != nil {
+				return err
+			}
+		}
+
+		if strings.EqualFold(config.ActiveWorkspace, oldName) {
+			config.ActiveWorkspace = newName
+		}
+		config.Workspaces[i].Name = newName
+		return nil
+	})
+}
+
+// DeleteWorkspace removes an inactive workspace
+func (cm *ConfigManager) DeleteWorkspace(name string) error {
+	return cm.Update(func(config *Config) error {
+		i := findWorkspace(config.Workspaces, name)
+		if i < 0 {
+			return fmt.Errorf("workspace not found: %s", name)
+		}
+		if strings.EqualFold(config.ActiveWorkspace, name) {
+			return fmt.Errorf("cannot delete the active workspace")
+		}
+
+		config.Workspaces = append(config.Workspaces[:i], config.Workspaces[i+1:]...)
+		return nil
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSwitchWorkspaceRestoresSettings(t *testing.T) {
+	cm := newTestConfigManager(t)
+	work := SandboxBinding{Pattern: `C:\Work\app.exe`, MatchType: BindingExact, Sandbox: "Work"}
+	game := SandboxBinding{Pattern: `D:\Games`, MatchType: BindingFolder, Sandbox: "Games"}
+
+	home := cm.ActiveWorkspace()
+	if err := cm.AddFolderPath(`C:\Work`); err != nil {
+		t.Fatal(err)
+	}
+	cm.SetSelectedSandbox("Work")
+	cm.SetSandboxBinding(work)
+
+	if err := cm.CreateWorkspace("Games", false); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	if err := cm.SwitchWorkspace("Games"); err != nil {
+		t.Fatalf("SwitchWorkspace(Games): %v", err)
+	}
+	config := cm.GetConfig()
+	if config.ActiveWorkspace != "Games" || len(config.FolderPaths) != 0 || len(config.SandboxBindings) != 0 ||
+		config.SelectedSandbox != "DefaultBox" || config.CurrentFolder != VirtualStartMenu {
+		t.Errorf("new workspace = %+v, want the defaults", config.currentWorkspace())
+	}
+	cm.AddFolderPath(`D:\Games`)
+	cm.SetSandboxBinding(game)
+
+	if err := cm.SwitchWorkspace(home); err != nil {
+		t.Fatalf("SwitchWorkspace(%s): %v", home, err)
+	}
+	config = cm.GetConfig()
+	if config.ActiveWorkspace != home || !slices.Equal(config.FolderPaths, []string{`C:\Work`}) || config.CurrentFolder != `C:\Work` ||
+		config.SelectedSandbox != "Work" || !slices.Equal(config.SandboxBindings, []SandboxBinding{work}) {
+		t.Errorf("back in %s: %+v", home, config.currentWorkspace())
+	}
+	// Games kept what was set while it was active
+	if w := config.Workspaces[findWorkspace(config.Workspaces, "Games")]; !slices.Equal(w.FolderPaths, []string{`D:\Games`}) ||
+		!slices.Equal(w.SandboxBindings, []SandboxBinding{game}) {
+		t.Errorf("Games workspace = %+v", w)
+	}
+
+	// The switch is saved
+	reloaded := NewConfigManager().GetConfig()
+	if reloaded.ActiveWorkspace != home || !slices.Equal(reloaded.SandboxBindings, []SandboxBinding{work}) || len(reloaded.Workspaces) != 2 {
+		t.Errorf("reloaded config = %q / %+v", reloaded.ActiveWorkspace, reloaded.Workspaces)
+	}
+
+	if err := cm.SwitchWorkspace("Missing"); err == nil {
+		t.Error("SwitchWorkspace(Missing) succeeded")
+	}
+}
+
+func TestCreateWorkspaceCopyCurrent(t *testing.T) {
+	cm := newTestConfigManager(t)
+	binding := SandboxBinding{Pattern: `C:\Tools\*.exe`, MatchType: BindingGlob, Sandbox: "Tools"}
+	cm.AddFolderPath(`C:\Tools`)
+	cm.SetSandboxBinding(binding)
+
+	if err := cm.CreateWorkspace("  Copy  ", true); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	config := cm.GetConfig()
+	i := findWorkspace(config.Workspaces, "Copy")
+	if i < 0 {
+		t.Fatalf("workspaces = %q, want Copy with the spaces trimmed", cm.GetWorkspaces())
+	}
+	if w := config.Workspaces[i]; w.Name != "Copy" || !slices.Equal(w.FolderPaths, []string{`C:\Tools`}) || w.CurrentFolder != `C:\Tools` ||
+		!slices.Equal(w.SandboxBindings, []SandboxBinding{binding}) {
+		t.Errorf("copied workspace = %+v", w)
+	}
+	// Creating does not switch
+	if config.ActiveWorkspace == "Copy" {
+		t.Error("CreateWorkspace made the new workspace active")
+	}
+}
+
+func TestRenameWorkspace(t *testing.T) {
+	cm := newTestConfigManager(t)
+	active := cm.ActiveWorkspace()
+	cm.CreateWorkspace("Other", false)
+
+	if err := cm.RenameWorkspace(active, "Main"); err != nil {
+		t.Fatalf("RenameWorkspace: %v", err)
+	}
+	if got := cm.ActiveWorkspace(); got != "Main" {
+		t.Errorf("ActiveWorkspace = %q after renaming it, want Main", got)
+	}
+	if got := cm.GetWorkspaces(); !slices.Equal(got, []string{"Main", "Other"}) {
+		t.Errorf("GetWorkspaces = %q", got)
+	}
+
+	// Only the case changes, which the duplicate check must allow
+	if err := cm.RenameWorkspace("main", "MAIN"); err != nil {
+		t.Fatalf("RenameWorkspace to other case: %v", err)
+	}
+	if got := cm.ActiveWorkspace(); got != "MAIN" {
+		t.Errorf("ActiveWorkspace = %q, want MAIN", got)
+	}
+
+	// The renamed active workspace still gets the settings on save
+	cm.AddFolderPath(`C:\Main`)
+	config := NewConfigManager().GetConfig()
+	if config.ActiveWorkspace != "MAIN" || len(config.Workspaces) != 2 ||
+		!slices.Equal(config.Workspaces[0].FolderPaths, []string{`C:\Main`}) {
+		t.Errorf("reloaded workspaces = %q / %+v", config.ActiveWorkspace, config.Workspaces)
+	}
+
+	// Renaming an inactive workspace leaves the active one alone
+	if err := cm.RenameWorkspace("Other", "Spare"); err != nil {
+		t.Fatalf("RenameWorkspace(Other): %v", err)
+	}
+	if got := cm.ActiveWorkspace(); got != "MAIN" {
+		t.Errorf("ActiveWorkspace = %q, want MAIN", got)
+	}
+	if err := cm.RenameWorkspace("Missing", "X"); err == nil {
+		t.Error("RenameWorkspace(Missing) succeeded")
+	}
+}
+
+func TestWorkspaceNamesAreUnique(t *testing.T) {
+	cm := newTestConfigManager(t)
+	cm.CreateWorkspace("Games", false)
+	cm.CreateWorkspace("Work", false)
+
+	tests := []struct {
+		name string
+		op   func() error
+		want string
+	}{
+		{"create same name", func() error { return cm.CreateWorkspace("Games", false) }, "already exists"},
+		{"create other case", func() error { return cm.CreateWorkspace("gAMES", true) }, "already exists"},
+		{"create with spaces", func() error { return cm.CreateWorkspace(" games ", false) }, "already exists"},
+		{"create empty", func() error { return cm.CreateWorkspace("  ", false) }, "empty"},
+		{"rename onto other", func() error { return cm.RenameWorkspace("Work", "GAMES") }, "already exists"},
+		{"rename to empty", func() error { return cm.RenameWorkspace("Work", "") }, "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if got := cm.GetWorkspaces(); len(got) != 3 || got[1] != "Games" || got[2] != "Work" {
+		t.Errorf("GetWorkspaces = %q after refused changes", got)
+	}
+}
+
+func TestDeleteWorkspace(t *testing.T) {
+	cm := newTestConfigManager(t)
+	active := cm.ActiveWorkspace()
+	cm.CreateWorkspace("Spare", false)
+
+	if err := cm.DeleteWorkspace(strings.ToUpper(active)); err == nil {
+		t.Error("DeleteWorkspace removed the active workspace")
+	}
+	if err := cm.DeleteWorkspace("spare"); err != nil {
+		t.Fatalf("DeleteWorkspace: %v", err)
+	}
+	if got := cm.GetWorkspaces(); !slices.Equal(got, []string{active}) {
+		t.Errorf("GetWorkspaces = %q, want only %q", got, active)
+	}
+	if err := cm.DeleteWorkspace("Spare"); err == nil {
+		t.Error("DeleteWorkspace of a missing workspace succeeded")
+	}
+}
+
+func TestLoadWithoutActiveWorkspace(t *testing.T) {
+	// The top-level settings are stale; the first workspace must win,
+	// or the next save would copy them over it
+	old, _ := json.Marshal(map[string]interface{}{
+		"version":         currentConfigVersion,
+		"folderPaths":     []string{`C:\Stale`},
+		"currentFolder":   `C:\Stale`,
+		"selectedSandbox": "Stale",
+		"workspaces": []map[string]interface{}{
+			{
+				"name": "Work", "folderPaths": []string{`C:\Work`}, "currentFolder": `C:\Work`, "selectedSandbox": "Work",
+				"sandboxBindings": []map[string]string{{"pattern": `C:\Work\app.exe`, "matchType": BindingExact, "sandbox": "Work"}},
+			},
+			{"name": "Games", "folderPaths": []string{`D:\Games`}},
+		},
+	})
+	writeTestConfig(t, string(old))
+
+	cm := NewConfigManager()
+	config := cm.GetConfig()
+	wantBindings := []SandboxBinding{{Pattern: `C:\Work\app.exe`, MatchType: BindingExact, Sandbox: "Work"}}
+	if config.ActiveWorkspace != "Work" || !slices.Equal(config.FolderPaths, []string{`C:\Work`}) || config.CurrentFolder != `C:\Work` ||
+		config.SelectedSandbox != "Work" || !slices.Equal(config.SandboxBindings, wantBindings) {
+		t.Errorf("active settings = %+v, want the Work workspace", config.currentWorkspace())
+	}
+	if got := cm.GetWorkspaces(); !slices.Equal(got, []string{"Work", "Games"}) {
+		t.Errorf("GetWorkspaces = %q", got)
+	}
+
+	// A save keeps Work's settings
+	cm.SetCurrentFolder(`C:\Work\Sub`)
+	w := NewConfigManager().GetConfig().Workspaces[0]
+	if !slices.Equal(w.FolderPaths, []string{`C:\Work`}) || w.CurrentFolder != `C:\Work\Sub` || !slices.Equal(w.SandboxBindings, wantBindings) {
+		t.Errorf("Work workspace after save = %+v", w)
+	}
+
+	// Switching to a workspace saved without a box or folder fills them in
+	cm.SwitchWorkspace("Games")
+	if config := cm.GetConfig(); config.SelectedSandbox != "DefaultBox" || config.CurrentFolder != VirtualStartMenu {
+		t.Errorf("Games settings = %+v", config.currentWorkspace())
+	}
+}